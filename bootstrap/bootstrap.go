@@ -7,32 +7,118 @@ package bootstrap
 
 import (
 	"fmt"
+	"go/build/constraint"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 const genPackage = "github.com/mailru/easyjson/gen"
 const pkgWriter = "github.com/mailru/easyjson/jwriter"
 const pkgLexer = "github.com/mailru/easyjson/jlexer"
+const pkgEasyJSON = "github.com/mailru/easyjson"
 
 type Generator struct {
 	PkgPath, PkgName string
 	Types            []string
 
-	NoStdMarshalers bool
-	SnakeCase       bool
-	OmitEmpty       bool
+	// MethodNames are custom names of MarshalJSON/UnmarshalJSON methods by type name.
+	MethodNames map[string][2]string
+
+	// KeepMarshalJSON are the types with a hand-written MarshalJSON method, which is used instead
+	// of generating one.
+	KeepMarshalJSON map[string]bool
+
+	// Samples are expressions of sample values by type name, used to seed generated benchmarks.
+	Samples map[string]string
+
+	// VirtualFields are the keys and the method names of virtual fields by type name.
+	VirtualFields map[string][][2]string
+
+	// FieldPositions are the positions of the struct fields by type and field name, passed to the
+	// generator to point at the declarations of unsupported fields with RejectUnsupported.
+	FieldPositions map[string]map[string]string
+
+	// TypeCodecs are codecs ("string" or "number") of external types by the full type name,
+	// e.g. "github.com/google/uuid.UUID", usually read from a file with ReadTypeMap.
+	TypeCodecs map[string]string
+
+	NoStdMarshalers    bool
+	IOInterfaces       bool
+	SliceMarshalers    bool
+	FilteredMarshalers bool
+	BinaryMarshalers   bool
+	MergePatches       bool
+	QuotedNumbers      bool
+	EmptyAsZero        bool
+	NilAsEmpty         bool
+	FlattenDotted      bool
+	Canonical          bool
+	SnakeCase          bool
+	OmitEmpty          bool
+	OmitNull           bool
+	TrackPresence      bool
+
+	// RejectUnsupported fails the generation on fields of unsupported types (channels, funcs)
+	// not tagged with json:"-" instead of skipping them.
+	RejectUnsupported bool
 
 	OutName   string
 	BuildTags string
 
+	// BuildConstraint is a //go:build expression added to the generated file, e.g. "linux && !js".
+	BuildConstraint string
+	// Header replaces the default header comment of the generated file.
+	Header string
+
+	// Benchmarks enables generation of a _test.go file next to the output file with marshal
+	// and unmarshal benchmarks for the types having a sample value.
+	Benchmarks bool
+
 	StubsOnly  bool
 	LeaveTemps bool
 	NoFormat   bool
 }
 
+// ReadTypeMap reads a mapping of external types to codecs from a file. Each line holds a
+// type name qualified with the package path and a codec name separated by whitespace, e.g.
+//
+//	github.com/google/uuid.UUID  string
+//
+// Empty lines and lines starting with '#' are ignored.
+func ReadTypeMap(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	codecs := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%v:%d: expected 'type codec', got %q", path, i+1, line)
+		}
+		if dot := strings.LastIndex(fields[0], "."); dot <= 0 || dot == len(fields[0])-1 {
+			return nil, fmt.Errorf("%v:%d: type %q is not qualified with a package path", path, i+1, fields[0])
+		}
+		switch fields[1] {
+		case "string", "number", "uint128", "int128":
+		default:
+			return nil, fmt.Errorf("%v:%d: unknown codec %q: supported codecs are string, number, uint128 and int128", path, i+1, fields[1])
+		}
+		codecs[fields[0]] = fields[1]
+	}
+	return codecs, nil
+}
+
 // writeStub outputs an initial stubs for marshalers/unmarshalers so that the package
 // using marshalers/unmarshales compiles correctly for boostrapping code.
 func (g *Generator) writeStub() error {
@@ -42,8 +128,13 @@ func (g *Generator) writeStub() error {
 	}
 	defer f.Close()
 
+	if g.BuildConstraint != "" {
+		fmt.Fprintln(f, "//go:build", g.BuildConstraint)
+	}
 	if g.BuildTags != "" {
 		fmt.Fprintln(f, "// +build ", g.BuildTags)
+	}
+	if g.BuildConstraint != "" || g.BuildTags != "" {
 		fmt.Fprintln(f)
 	}
 	fmt.Fprintln(f, "// TEMPORARY AUTOGENERATED FILE: easyjson stub code to make the package")
@@ -56,17 +147,40 @@ func (g *Generator) writeStub() error {
 		fmt.Fprintln(f, "import (")
 		fmt.Fprintln(f, `  "`+pkgWriter+`"`)
 		fmt.Fprintln(f, `  "`+pkgLexer+`"`)
+		if g.IOInterfaces {
+			fmt.Fprintln(f, `  "io"`)
+		}
 		fmt.Fprintln(f, ")")
 	}
 
 	for _, t := range g.Types {
 		fmt.Fprintln(f)
 		if !g.NoStdMarshalers {
-			fmt.Fprintln(f, "func (", t, ") MarshalJSON() ([]byte, error) { return nil, nil }")
-			fmt.Fprintln(f, "func (*", t, ") UnmarshalJSON([]byte) error { return nil }")
+			marshal, unmarshal := "MarshalJSON", "UnmarshalJSON"
+			if names, ok := g.MethodNames[t]; ok {
+				marshal, unmarshal = names[0], names[1]
+			}
+			if !g.KeepMarshalJSON[t] {
+				fmt.Fprintln(f, "func (", t, ") "+marshal+"() ([]byte, error) { return nil, nil }")
+			}
+			fmt.Fprintln(f, "func (*", t, ") "+unmarshal+"([]byte) error { return nil }")
+		}
+		if g.IOInterfaces {
+			fmt.Fprintln(f, "func (", t, ") WriteTo(io.Writer) (int64, error) { return 0, nil }")
+			fmt.Fprintln(f, "func (*", t, ") ReadFrom(io.Reader) (int64, error) { return 0, nil }")
+		}
+
+		if g.SliceMarshalers {
+			fmt.Fprintln(f, "func Marshal"+t+"Slice([]"+t+") ([]byte, error) { return nil, nil }")
+		}
+		if g.MergePatches && !g.KeepMarshalJSON[t] {
+			fmt.Fprintln(f, "func MergePatch"+t+"(from, to "+t+") ([]byte, error) { return nil, nil }")
 		}
 
 		fmt.Fprintln(f, "func (", t, ") MarshalEasyJSON(w *jwriter.Writer) {}")
+		if g.FilteredMarshalers && !g.KeepMarshalJSON[t] {
+			fmt.Fprintln(f, "func (", t, ") MarshalEasyJSONFiltered(w *jwriter.Writer, include map[string]bool) {}")
+		}
 		fmt.Fprintln(f, "func (*", t, ") UnmarshalEasyJSON(l *jlexer.Lexer) {}")
 		fmt.Fprintln(f)
 		fmt.Fprintln(f, "type EasyJSON_exporter_"+t+" *"+t)
@@ -74,6 +188,81 @@ func (g *Generator) writeStub() error {
 	return nil
 }
 
+// benchmarksName returns the name of the generated benchmarks file.
+func (g *Generator) benchmarksName() string {
+	return strings.TrimSuffix(g.OutName, ".go") + "_test.go"
+}
+
+// writeBenchmarks outputs marshal and unmarshal benchmarks for the types with sample values.
+func (g *Generator) writeBenchmarks() error {
+	var types []string
+	for _, t := range g.Types {
+		if g.Samples[t] != "" {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	f, err := os.Create(g.benchmarksName())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if g.BuildConstraint != "" {
+		fmt.Fprintln(f, "//go:build", g.BuildConstraint)
+	}
+	if g.BuildTags != "" {
+		fmt.Fprintln(f, "// +build ", g.BuildTags)
+	}
+	if g.BuildConstraint != "" || g.BuildTags != "" {
+		fmt.Fprintln(f)
+	}
+	fmt.Fprintln(f, "// AUTOGENERATED FILE: easyjson benchmarks.")
+	fmt.Fprintln(f)
+	fmt.Fprintln(f, "package", g.PkgName)
+	fmt.Fprintln(f)
+	fmt.Fprintln(f, "import (")
+	fmt.Fprintln(f, `	"testing"`)
+	fmt.Fprintln(f)
+	fmt.Fprintf(f, "\t%q\n", pkgEasyJSON)
+	fmt.Fprintln(f, ")")
+
+	for _, t := range types {
+		sample := g.Samples[t]
+
+		fmt.Fprintln(f)
+		fmt.Fprintln(f, "func BenchmarkEasyJSONMarshal"+t+"(b *testing.B) {")
+		fmt.Fprintln(f, "	var v "+t+" = "+sample)
+		fmt.Fprintln(f, "	b.ReportAllocs()")
+		fmt.Fprintln(f, "	for i := 0; i < b.N; i++ {")
+		fmt.Fprintln(f, "		if _, err := easyjson.Marshal(v); err != nil {")
+		fmt.Fprintln(f, "			b.Fatal(err)")
+		fmt.Fprintln(f, "		}")
+		fmt.Fprintln(f, "	}")
+		fmt.Fprintln(f, "}")
+		fmt.Fprintln(f)
+		fmt.Fprintln(f, "func BenchmarkEasyJSONUnmarshal"+t+"(b *testing.B) {")
+		fmt.Fprintln(f, "	var v "+t+" = "+sample)
+		fmt.Fprintln(f, "	data, err := easyjson.Marshal(v)")
+		fmt.Fprintln(f, "	if err != nil {")
+		fmt.Fprintln(f, "		b.Fatal(err)")
+		fmt.Fprintln(f, "	}")
+		fmt.Fprintln(f, "	b.SetBytes(int64(len(data)))")
+		fmt.Fprintln(f, "	b.ReportAllocs()")
+		fmt.Fprintln(f, "	for i := 0; i < b.N; i++ {")
+		fmt.Fprintln(f, "		var v "+t)
+		fmt.Fprintln(f, "		if err := easyjson.Unmarshal(data, &v); err != nil {")
+		fmt.Fprintln(f, "			b.Fatal(err)")
+		fmt.Fprintln(f, "		}")
+		fmt.Fprintln(f, "	}")
+		fmt.Fprintln(f, "}")
+	}
+	return nil
+}
+
 // writeMain creates a .go file that launches the generator if 'go run'.
 func (g *Generator) writeMain() (path string, err error) {
 	f, err := ioutil.TempFile(filepath.Dir(g.OutName), "easyjson-bootstrap")
@@ -105,17 +294,89 @@ func (g *Generator) writeMain() (path string, err error) {
 	if g.BuildTags != "" {
 		fmt.Fprintf(f, "  g.SetBuildTags(%q)\n", g.BuildTags)
 	}
+	if g.BuildConstraint != "" {
+		fmt.Fprintf(f, "  g.SetBuildConstraint(%q)\n", g.BuildConstraint)
+	}
+	if g.Header != "" {
+		fmt.Fprintf(f, "  g.SetHeader(%q)\n", g.Header)
+	}
 	if g.SnakeCase {
 		fmt.Fprintln(f, "  g.UseSnakeCase()")
 	}
 	if g.OmitEmpty {
 		fmt.Fprintln(f, "  g.OmitEmpty()")
 	}
+	if g.OmitNull {
+		fmt.Fprintln(f, "  g.OmitNull()")
+	}
+	if g.TrackPresence {
+		fmt.Fprintln(f, "  g.TrackPresence()")
+	}
+	if g.RejectUnsupported {
+		fmt.Fprintln(f, "  g.RejectUnsupported()")
+	}
 	if g.NoStdMarshalers {
 		fmt.Fprintln(f, "  g.NoStdMarshalers()")
 	}
+	if g.IOInterfaces {
+		fmt.Fprintln(f, "  g.IOInterfaces()")
+	}
+	if g.SliceMarshalers {
+		fmt.Fprintln(f, "  g.SliceMarshalers()")
+	}
+	if g.FilteredMarshalers {
+		fmt.Fprintln(f, "  g.FilteredMarshalers()")
+	}
+	if g.BinaryMarshalers {
+		fmt.Fprintln(f, "  g.BinaryMarshalers()")
+	}
+	if g.MergePatches {
+		fmt.Fprintln(f, "  g.MergePatches()")
+	}
+	if g.QuotedNumbers {
+		fmt.Fprintln(f, "  g.AcceptQuotedNumbers()")
+	}
+	if g.EmptyAsZero {
+		fmt.Fprintln(f, "  g.EmptyStringAsZero()")
+	}
+	if g.NilAsEmpty {
+		fmt.Fprintln(f, "  g.NilAsEmpty()")
+	}
+	if g.FlattenDotted {
+		fmt.Fprintln(f, "  g.FlattenDotted()")
+	}
+	if g.Canonical {
+		fmt.Fprintln(f, "  g.Canonical()")
+	}
+	typeNames := make([]string, 0, len(g.TypeCodecs))
+	for name := range g.TypeCodecs {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		fmt.Fprintf(f, "  g.SetTypeCodec(%q, %q)\n", name, g.TypeCodecs[name])
+	}
 	for _, v := range g.Types {
 		fmt.Fprintln(f, "  g.Add(pkg.EasyJSON_exporter_"+v+"(nil))")
+		if names, ok := g.MethodNames[v]; ok {
+			fmt.Fprintf(f, "  g.SetMethodNames(pkg.EasyJSON_exporter_%v(nil), %q, %q)\n", v, names[0], names[1])
+		}
+		if g.KeepMarshalJSON[v] {
+			fmt.Fprintf(f, "  g.KeepMarshalJSON(pkg.EasyJSON_exporter_%v(nil))\n", v)
+		}
+		for _, field := range g.VirtualFields[v] {
+			fmt.Fprintf(f, "  g.AddVirtualField(pkg.EasyJSON_exporter_%v(nil), %q, %q)\n", v, field[0], field[1])
+		}
+		if g.RejectUnsupported {
+			fields := make([]string, 0, len(g.FieldPositions[v]))
+			for name := range g.FieldPositions[v] {
+				fields = append(fields, name)
+			}
+			sort.Strings(fields)
+			for _, name := range fields {
+				fmt.Fprintf(f, "  g.SetFieldPosition(pkg.EasyJSON_exporter_%v(nil), %q, %q)\n", v, name, g.FieldPositions[v][name])
+			}
+		}
 	}
 
 	fmt.Fprintln(f, "  if err := g.Run(os.Stdout); err != nil {")
@@ -134,6 +395,11 @@ func (g *Generator) writeMain() (path string, err error) {
 }
 
 func (g *Generator) Run() error {
+	if g.BuildConstraint != "" {
+		if _, err := constraint.Parse("//go:build " + g.BuildConstraint); err != nil {
+			return fmt.Errorf("invalid build constraint %q: %v", g.BuildConstraint, err)
+		}
+	}
 	if err := g.writeStub(); err != nil {
 		return err
 	}
@@ -176,5 +442,12 @@ func (g *Generator) Run() error {
 		}
 	}
 
-	return os.Rename(f.Name(), g.OutName)
+	if err := os.Rename(f.Name(), g.OutName); err != nil {
+		return err
+	}
+
+	if g.Benchmarks {
+		return g.writeBenchmarks()
+	}
+	return nil
 }
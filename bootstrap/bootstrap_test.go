@@ -0,0 +1,98 @@
+package bootstrap
+
+import (
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStubBuildConstraint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "easyjson-bootstrap-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	g := Generator{
+		PkgName:         "test",
+		Types:           []string{"A"},
+		OutName:         filepath.Join(dir, "a_easyjson.go"),
+		BuildConstraint: "linux && !appengine",
+		BuildTags:       "linux,!appengine",
+	}
+	if err := g.writeStub(); err != nil {
+		t.Fatalf("writeStub() error: %v", err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), g.OutName, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parser.ParseFile() error: %v", err)
+	}
+
+	lines := f.Comments[0].List
+	if len(lines) < 2 {
+		t.Fatalf("got %d build constraint lines; want 2", len(lines))
+	}
+	if want := "//go:build linux && !appengine"; lines[0].Text != want {
+		t.Errorf("first line = %q; want %q", lines[0].Text, want)
+	}
+	for _, l := range lines[:2] {
+		if _, err := constraint.Parse(l.Text); err != nil {
+			t.Errorf("constraint.Parse(%q) error: %v", l.Text, err)
+		}
+	}
+}
+
+func TestInvalidBuildConstraint(t *testing.T) {
+	g := Generator{PkgName: "test", OutName: "a_easyjson.go", BuildConstraint: "linux &&"}
+	if err := g.Run(); err == nil {
+		t.Errorf("Run() ok; want error for an invalid build constraint")
+	}
+}
+
+func TestReadTypeMap(t *testing.T) {
+	for i, test := range []struct {
+		data    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			data: "# codecs\n\ngithub.com/google/uuid.UUID string\n  example.com/x/decimal.Decimal\tnumber  \n" +
+				"example.com/x.U128 uint128\nexample.com/x.I128 int128\n",
+			want: map[string]string{
+				"github.com/google/uuid.UUID":   "string",
+				"example.com/x/decimal.Decimal": "number",
+				"example.com/x.U128":            "uint128",
+				"example.com/x.I128":            "int128",
+			},
+		},
+		{data: "", want: map[string]string{}},
+		{data: "github.com/google/uuid.UUID", wantErr: true},
+		{data: "github.com/google/uuid.UUID string extra", wantErr: true},
+		{data: "UUID string", wantErr: true},
+		{data: "github.com/google/uuid. string", wantErr: true},
+		{data: "github.com/google/uuid.UUID bytes", wantErr: true},
+	} {
+		f, err := ioutil.TempFile("", "easyjson-type-map")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString(test.data)
+		f.Close()
+
+		got, err := ReadTypeMap(f.Name())
+		os.Remove(f.Name())
+		if err != nil && !test.wantErr {
+			t.Errorf("[%d, %q] ReadTypeMap() error: %v", i, test.data, err)
+		} else if err == nil && test.wantErr {
+			t.Errorf("[%d, %q] ReadTypeMap() ok; want error", i, test.data)
+		} else if err == nil && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %q] ReadTypeMap() = %v; want %v", i, test.data, got, test.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@
 package buffer
 
 import (
+	"errors"
 	"io"
 	"sync"
 )
@@ -65,6 +66,9 @@ func getBuf(size int) []byte {
 	return make([]byte, 0, size)
 }
 
+// ErrBufferFull is reported when the data does not fit in a fixed buffer.
+var ErrBufferFull = errors.New("buffer: fixed buffer is full")
+
 // Buffer is a buffer optimized for serialization without extra copying.
 type Buffer struct {
 
@@ -73,6 +77,37 @@ type Buffer struct {
 
 	toPool []byte
 	bufs   [][]byte
+
+	fixed    bool // Whether the data is written to a caller-provided buffer, see SetFixed.
+	fixedCap int  // Capacity of the fixed buffer.
+	full     bool // Whether the data did not fit in the fixed buffer.
+}
+
+// SetFixed makes the buffer write into buf, from its start up to its capacity, without allocating
+// or pooling chunks. Data beyond the capacity is discarded and Full reports true. The buffer
+// becomes a regular one again once it is reset by BuildBytes, DumpTo or ResetFixed.
+func (b *Buffer) SetFixed(buf []byte) {
+	b.Buf = buf[:0]
+	b.toPool = nil
+	b.bufs = nil
+	b.fixed = true
+	b.fixedCap = cap(buf)
+	b.full = false
+}
+
+// Full returns true if the data did not fit in the fixed buffer set with SetFixed.
+func (b *Buffer) Full() bool {
+	// The chunk may be also grown by an append that did not ensure the space.
+	return b.full || b.fixed && cap(b.Buf) != b.fixedCap
+}
+
+// ResetFixed releases the fixed buffer set with SetFixed, discarding the data, so that the buffer
+// becomes a regular empty one. It does nothing if no fixed buffer is set.
+func (b *Buffer) ResetFixed() {
+	if b.fixed {
+		b.Buf = nil
+		b.fixed, b.full = false, false
+	}
 }
 
 // EnsureSpace makes sure that the current chunk contains at least s free bytes,
@@ -81,6 +116,20 @@ func (b *Buffer) EnsureSpace(s int) {
 	if cap(b.Buf)-len(b.Buf) >= s {
 		return
 	}
+	if b.fixed {
+		// The space asked for is an upper bound (e.g. for numbers), so the data that may fit is
+		// appended, and the buffer is full only when it overflows growing the chunk.
+		if len(b.Buf) < cap(b.Buf) && cap(b.Buf) == b.fixedCap {
+			return
+		}
+		// The data is lost anyway, so the rest is written over the start of the buffer.
+		b.full = true
+		b.Buf = b.Buf[:0]
+		if cap(b.Buf) < s {
+			b.Buf = make([]byte, 0, s)
+		}
+		return
+	}
 	l := len(b.Buf)
 	if l > 0 {
 		if cap(b.toPool) != cap(b.Buf) {
@@ -174,10 +223,25 @@ func (b *Buffer) DumpTo(w io.Writer) (written int, err error) {
 	b.bufs = nil
 	b.Buf = nil
 	b.toPool = nil
+	b.fixed, b.full = false, false
 
 	return
 }
 
+// AppendTo appends the contents of a buffer to dst and returns the extended slice. Unlike
+// BuildBytes and DumpTo, the buffer is left intact.
+func (b *Buffer) AppendTo(dst []byte) []byte {
+	if n := b.Size(); cap(dst)-len(dst) < n {
+		grown := make([]byte, len(dst), len(dst)+n)
+		copy(grown, dst)
+		dst = grown
+	}
+	for _, buf := range b.bufs {
+		dst = append(dst, buf...)
+	}
+	return append(dst, b.Buf...)
+}
+
 // BuildBytes creates a single byte slice with all the contents of the buffer. Data is
 // copied if it does not fit in a single chunk.
 func (b *Buffer) BuildBytes() []byte {
@@ -186,6 +250,7 @@ func (b *Buffer) BuildBytes() []byte {
 		ret := b.Buf
 		b.toPool = nil
 		b.Buf = nil
+		b.fixed, b.full = false, false
 
 		return ret
 	}
@@ -202,6 +267,7 @@ func (b *Buffer) BuildBytes() []byte {
 	b.bufs = nil
 	b.toPool = nil
 	b.Buf = nil
+	b.fixed, b.full = false, false
 
 	return ret
 }
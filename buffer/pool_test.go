@@ -52,6 +52,29 @@ func TestAppendString(t *testing.T) {
 	}
 }
 
+func TestAppendTo(t *testing.T) {
+	var b Buffer
+	var want []byte
+
+	s := "test"
+	for i := 0; i < 1000; i++ {
+		b.AppendString(s)
+		want = append(want, s...)
+	}
+
+	for _, dst := range [][]byte{nil, []byte("prefix:"), make([]byte, 2, 10000)} {
+		got := b.AppendTo(dst)
+		if w := append(append([]byte(nil), dst...), want...); !bytes.Equal(got, w) {
+			t.Errorf("AppendTo(%q) = %q; want %q", dst, got, w)
+		}
+	}
+
+	// The buffer contents are kept.
+	if got := b.BuildBytes(); !bytes.Equal(got, want) {
+		t.Errorf("BuildBytes() after AppendTo() = %v; want %v", got, want)
+	}
+}
+
 func TestDumpTo(t *testing.T) {
 	var b Buffer
 	var want []byte
@@ -77,3 +100,52 @@ func TestDumpTo(t *testing.T) {
 		t.Errorf("DumpTo() = %v; want %v", n, len(want))
 	}
 }
+
+func TestSetFixed(t *testing.T) {
+	for i, test := range []struct {
+		size     int
+		data     string
+		wantFull bool
+	}{
+		{size: 5, data: "hello"},
+		{size: 16, data: "hello"},
+		{size: 4, data: "hello", wantFull: true},
+		{size: 0, data: "x", wantFull: true},
+		{size: 600, data: string(bytes.Repeat([]byte("x"), 1000)), wantFull: true},
+	} {
+		fixed := make([]byte, test.size)
+		var b Buffer
+		b.SetFixed(fixed)
+		b.AppendString(test.data[:1])
+		b.AppendBytes([]byte(test.data[1:]))
+
+		if full := b.Full(); full != test.wantFull {
+			t.Errorf("[%d] Full() = %v; want %v", i, full, test.wantFull)
+		}
+		got := b.BuildBytes()
+		if test.wantFull {
+			continue
+		}
+		if string(got) != test.data {
+			t.Errorf("[%d] BuildBytes() = %q; want %q", i, got, test.data)
+		}
+		if &got[0] != &fixed[0] {
+			t.Errorf("[%d] BuildBytes() does not return the fixed buffer", i)
+		}
+	}
+}
+
+func TestSetFixedReset(t *testing.T) {
+	var b Buffer
+	b.SetFixed(make([]byte, 1))
+	b.AppendString("too long")
+	b.BuildBytes()
+
+	b.AppendString("grown")
+	if b.Full() {
+		t.Errorf("Full() after BuildBytes() = true; want a regular buffer")
+	}
+	if got := string(b.BuildBytes()); got != "grown" {
+		t.Errorf("BuildBytes() = %q; want %q", got, "grown")
+	}
+}
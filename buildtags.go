@@ -0,0 +1,27 @@
+package easyjson
+
+import "sync"
+
+// buildTags holds the enabled tags gating struct fields marked with `easyjson:"buildtag=name"`.
+var buildTags = struct {
+	sync.RWMutex
+	enabled map[string]bool
+}{enabled: make(map[string]bool)}
+
+// SetBuildTag enables or disables the tag, so that the generated marshalers and unmarshalers
+// include or ignore the fields marked with `easyjson:"buildtag=<tag>"`. All tags are disabled
+// by default. The tag is a runtime switch, e.g. to be enabled from an init function in a file
+// built with the corresponding Go build tag.
+func SetBuildTag(tag string, enabled bool) {
+	buildTags.Lock()
+	buildTags.enabled[tag] = enabled
+	buildTags.Unlock()
+}
+
+// BuildTag returns true if the tag is enabled with SetBuildTag.
+func BuildTag(tag string) bool {
+	buildTags.RLock()
+	enabled := buildTags.enabled[tag]
+	buildTags.RUnlock()
+	return enabled
+}
@@ -15,9 +15,26 @@ import (
 )
 
 var buildTags = flag.String("build_tags", "", "build tags to add to generated file")
+var buildConstraint = flag.String("build_constraint", "", "//go:build constraint expression to add to generated file")
+var header = flag.String("header", "", "header comment of generated file")
 var snakeCase = flag.Bool("snake_case", false, "use snake_case names instead of CamelCase by default")
 var noStdMarshalers = flag.Bool("no_std_marshalers", false, "don't generate MarshalJSON/UnmarshalJSON methods")
+var ioInterfaces = flag.Bool("io_interfaces", false, "generate WriteTo/ReadFrom methods (io.WriterTo/io.ReaderFrom)")
+var sliceMarshalers = flag.Bool("slice_marshalers", false, "generate Marshal<Type>Slice functions marshaling []T with a single writer")
+var filteredMarshalers = flag.Bool("filtered_marshalers", false, "generate MarshalEasyJSONFiltered methods outputting only the given top-level fields of structs")
+var binaryMarshalers = flag.Bool("binary_marshalers", false, "encode types implementing only encoding.BinaryMarshaler/BinaryUnmarshaler as base64 strings")
+var mergePatches = flag.Bool("merge_patches", false, "generate MergePatch<Type> functions outputting RFC 7386 merge patches between two values")
+var quotedNumbers = flag.Bool("accept_quoted_numbers", false, "accept numbers enclosed in quotes when decoding")
+var emptyAsZero = flag.Bool("empty_string_as_zero", false, "decode empty strings as zero values of number and bool fields")
+var canonical = flag.Bool("canonical", false, "output canonical JSON (RFC 8785): sorted keys, canonical numbers and strings")
+var flattenDotted = flag.Bool("flatten_dotted", false, "output fields of nested structs with dotted keys instead of nested objects")
+var nilAsEmpty = flag.Bool("nil_as_empty", false, "output nil maps as empty objects instead of null")
 var omitEmpty = flag.Bool("omit_empty", false, "omit empty fields by default")
+var omitNull = flag.Bool("omit_null", false, "omit fields that would be encoded as null (nil pointers, interfaces and maps)")
+var trackPresence = flag.Bool("track_presence", false, "record the keys present in decoded objects to the easyjson.Presence field of structs")
+var rejectUnsupported = flag.Bool("reject_unsupported", false, "fail on fields of unsupported types (channels, funcs) instead of skipping them")
+var genBenchmarks = flag.Bool("gen_benchmarks", false, "generate a _test.go file with benchmarks for types with 'sample=expr' in the easyjson:json comment")
+var typeMap = flag.String("type_map", "", "file mapping external types to codecs, one 'pkgpath.Type codec' per line")
 var allStructs = flag.Bool("all", false, "generate un-/marshallers for all structs in a file")
 var leaveTemps = flag.Bool("leave_temps", false, "do not delete temporary files")
 var stubs = flag.Bool("stubs", false, "only generate stubs for marshallers/unmarshallers methods")
@@ -25,7 +42,10 @@ var noformat = flag.Bool("noformat", false, "do not run 'gofmt -w' on output fil
 var specifiedName = flag.String("output_filename", "", "specify the filename of the output")
 
 func generate(fname string) (err error) {
-	p := parser.Parser{AllStructs: *allStructs}
+	p := parser.Parser{
+		AllStructs: *allStructs,
+		BuildTags:  strings.FieldsFunc(*buildTags, func(r rune) bool { return r == ',' || r == ' ' }),
+	}
 	if err := p.Parse(fname); err != nil {
 		return fmt.Errorf("Error parsing %v: %v", fname, err)
 	}
@@ -41,18 +61,47 @@ func generate(fname string) (err error) {
 		outName = *specifiedName
 	}
 
+	var typeCodecs map[string]string
+	if *typeMap != "" {
+		if typeCodecs, err = bootstrap.ReadTypeMap(*typeMap); err != nil {
+			return fmt.Errorf("Error reading type map: %v", err)
+		}
+	}
+
 	g := bootstrap.Generator{
-		BuildTags:       *buildTags,
-		PkgPath:         p.PkgPath,
-		PkgName:         p.PkgName,
-		Types:           p.StructNames,
-		SnakeCase:       *snakeCase,
-		NoStdMarshalers: *noStdMarshalers,
-		OmitEmpty:       *omitEmpty,
-		LeaveTemps:      *leaveTemps,
-		OutName:         outName,
-		StubsOnly:       *stubs,
-		NoFormat:        *noformat,
+		BuildTags:          *buildTags,
+		BuildConstraint:    *buildConstraint,
+		Header:             *header,
+		PkgPath:            p.PkgPath,
+		PkgName:            p.PkgName,
+		Types:              p.StructNames,
+		MethodNames:        p.MethodNames,
+		KeepMarshalJSON:    p.KeepMarshalJSON,
+		Samples:            p.Samples,
+		VirtualFields:      p.VirtualFields,
+		FieldPositions:     p.FieldPositions,
+		TypeCodecs:         typeCodecs,
+		SnakeCase:          *snakeCase,
+		NoStdMarshalers:    *noStdMarshalers,
+		IOInterfaces:       *ioInterfaces,
+		SliceMarshalers:    *sliceMarshalers,
+		FilteredMarshalers: *filteredMarshalers,
+		BinaryMarshalers:   *binaryMarshalers,
+		MergePatches:       *mergePatches,
+		QuotedNumbers:      *quotedNumbers,
+		EmptyAsZero:        *emptyAsZero,
+		NilAsEmpty:         *nilAsEmpty,
+		FlattenDotted:      *flattenDotted,
+		Canonical:          *canonical,
+		OmitEmpty:          *omitEmpty,
+		OmitNull:           *omitNull,
+		TrackPresence:      *trackPresence,
+		RejectUnsupported:  *rejectUnsupported,
+		LeaveTemps:         *leaveTemps,
+		OutName:            outName,
+		Benchmarks:         *genBenchmarks,
+		StubsOnly:          *stubs,
+		NoFormat:           *noformat,
 	}
 
 	if err := g.Run(); err != nil {
@@ -1,15 +1,40 @@
 package gen
 
 import (
+	"encoding"
 	"encoding/json"
 	"fmt"
+	"os"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/mailru/easyjson"
 )
 
+// rawMessageType is a type of json.RawMessage, which needs to be output as is.
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// presenceType is a type of easyjson.Presence, which records the keys of decoded objects.
+var presenceType = reflect.TypeOf(easyjson.Presence(nil))
+
+// textUnmarshalerType is a type of encoding.TextUnmarshaler interface.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// timeType is a type of time.Time, which can be encoded as a timestamp.
+var timeType = reflect.TypeOf(time.Time{})
+
+// errorType is a type of error interface, which is encoded as the message string.
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// isGenericOptional returns true if t is an instance of the opt.Optional[T] generic type, which
+// is encoded as the value if it is set, and omitted from objects otherwise.
+func isGenericOptional(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.PkgPath() == pkgOpt && strings.HasPrefix(t.Name(), "Optional[")
+}
+
 // Target this byte size for initial slice allocation to reduce garbage collection.
 const minSliceBytes = 64
 
@@ -35,6 +60,7 @@ var primitiveDecoders = map[reflect.Kind]string{
 }
 
 var primitiveStringDecoders = map[reflect.Kind]string{
+	reflect.Bool:   "in.BoolStr()",
 	reflect.Int:    "in.IntStr()",
 	reflect.Int8:   "in.Int8Str()",
 	reflect.Int16:  "in.Int16Str()",
@@ -47,10 +73,110 @@ var primitiveStringDecoders = map[reflect.Kind]string{
 	reflect.Uint64: "in.Uint64Str()",
 }
 
+// isInteger returns true if the type is a signed or unsigned integer.
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return isUnsigned(t)
+}
+
+// isUnsigned returns true if the type is an unsigned integer.
+func isUnsigned(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// isNumber returns true if the type is decoded from a JSON number.
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+
+		return true
+	}
+	return false
+}
+
 // genTypeDecoder generates decoding code for the type t, but uses unmarshaler interface if implemented by t.
 func (g *Generator) genTypeDecoder(t reflect.Type, out string, tags fieldTags, indent int) error {
 	ws := strings.Repeat("  ", indent)
 
+	if isGenericOptional(t) {
+		fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+		fmt.Fprintln(g.out, ws+"  in.Skip()")
+		fmt.Fprintln(g.out, ws+"  "+out+" = "+g.getType(t)+"{}")
+		fmt.Fprintln(g.out, ws+"} else {")
+		fmt.Fprintln(g.out, ws+"  ("+out+").Set = true")
+		if err := g.genTypeDecoder(t.Field(0).Type, "("+out+").Value", tags, indent+1); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, ws+"}")
+		return nil
+	}
+	if t == timeType && tags.tz != "" {
+		return g.genTimeZoneDecoder(out, tags, indent)
+	}
+	if t == timeType && tags.format != "" {
+		return g.genTimeDecoder(out, tags.format, indent)
+	}
+	if t.Kind() == reflect.Interface && tags.oneOf != "" {
+		return g.genOneOfDecoder(t, out, tags.oneOf, indent)
+	}
+	if tags.format == packedUint32sFormat {
+		if err := checkPackedUint32s(t); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+		fmt.Fprintln(g.out, ws+"  in.Skip()")
+		fmt.Fprintln(g.out, ws+"  "+out+" = nil")
+		fmt.Fprintln(g.out, ws+"} else {")
+		fmt.Fprintln(g.out, ws+"  "+out+" = "+g.getType(t)+"(in.Uint32sLE())")
+		fmt.Fprintln(g.out, ws+"}")
+		return nil
+	}
+	if tags.format == rleFormat && t.Kind() != reflect.Ptr {
+		return g.genRLEDecoder(t, out, tags, indent)
+	}
+	if tags.format == jsonStringFormat && t.Kind() != reflect.Ptr {
+		return g.genJSONStringDecoder(t, out, tags, indent)
+	}
+
+	// json.RawMessage gets a copy of the raw value, since the input buffer may be reused.
+	if t == rawMessageType {
+		fmt.Fprintln(g.out, ws+"if data := in.Raw(); in.Ok() {")
+		fmt.Fprintln(g.out, ws+"  "+out+" = append(("+out+")[:0], data...)")
+		fmt.Fprintln(g.out, ws+"}")
+		return nil
+	}
+
+	if codec, ok := g.typeCodecs[fullTypeName(t)]; ok {
+		if _, ok := int128Codecs[codec]; ok {
+			return g.genInt128Decoder(t, out, codec, indent)
+		}
+		return g.genTextCodecDecoder(t, out, codec, indent)
+	}
+
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*easyjson.Enum)(nil)).Elem()) {
+		return g.genEnumDecoder(t, out, tags.enumFallback, indent)
+	}
+
+	// An error is decoded from the message string, the original error type is not restored.
+	if t == errorType {
+		fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+		fmt.Fprintln(g.out, ws+"  in.Skip()")
+		fmt.Fprintln(g.out, ws+"  "+out+" = nil")
+		fmt.Fprintln(g.out, ws+"} else {")
+		fmt.Fprintln(g.out, ws+"  "+out+" = "+g.pkgAlias("errors")+".New(in.String())")
+		fmt.Fprintln(g.out, ws+"}")
+		return nil
+	}
+
 	unmarshalerIface := reflect.TypeOf((*easyjson.Unmarshaler)(nil)).Elem()
 	if reflect.PtrTo(t).Implements(unmarshalerIface) {
 		fmt.Fprintln(g.out, ws+"("+out+").UnmarshalEasyJSON(in)")
@@ -65,19 +191,280 @@ func (g *Generator) genTypeDecoder(t reflect.Type, out string, tags fieldTags, i
 		return nil
 	}
 
+	// Binary values are decoded from base64 strings, null leaves the value unchanged.
+	unmarshalerIface = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	if g.binaryMarshalers && reflect.PtrTo(t).Implements(unmarshalerIface) && !reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+		fmt.Fprintln(g.out, ws+"  in.Skip()")
+		fmt.Fprintln(g.out, ws+"} else if data := in.Bytes(); in.Ok() {")
+		fmt.Fprintln(g.out, ws+"  in.AddError( ("+out+").UnmarshalBinary(data) )")
+		fmt.Fprintln(g.out, ws+"}")
+		return nil
+	}
+
 	err := g.genTypeDecoderNoCheck(t, out, tags, indent)
 	return err
 }
 
+// genTextCodecDecoder generates code that decodes a value using its encoding.TextUnmarshaler
+// implementation from a string or the raw number literal, depending on the codec registered for
+// the type. null leaves the value unchanged.
+func (g *Generator) genTextCodecDecoder(t reflect.Type, out, codec string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	var read string
+	switch codec {
+	case "string":
+		read = "[]byte(in.String())"
+	case "number":
+		read = "in.Raw()"
+	default:
+		return fmt.Errorf("unknown codec %q for type %v: supported codecs are string, number, uint128 and int128", codec, t)
+	}
+
+	unmarshalerIface := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	if !reflect.PtrTo(t).Implements(unmarshalerIface) {
+		return fmt.Errorf("type %v mapped to %v codec does not implement encoding.TextUnmarshaler", t, codec)
+	}
+
+	fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+	fmt.Fprintln(g.out, ws+"  in.Skip()")
+	fmt.Fprintln(g.out, ws+"} else if data := "+read+"; in.Ok() {")
+	fmt.Fprintln(g.out, ws+"  in.AddError( ("+out+").UnmarshalText(data) )")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// genEnumDecoder generates code that decodes an easyjson.Enum value from a string label. An
+// unknown label is decoded as the fallback constant of the enum type if it is set, or is an
+// error. null leaves the value unchanged.
+func (g *Generator) genEnumDecoder(t reflect.Type, out, fallback string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+	fmt.Fprintln(g.out, ws+"  in.Skip()")
+	fmt.Fprintln(g.out, ws+"} else if label := in.UnsafeString(); in.Ok() && !("+out+").SetEnumLabel(label) {")
+	if fallback == "" {
+		fmt.Fprintln(g.out, ws+"  in.UnknownEnumLabel(label)")
+	} else {
+		if t.PkgPath() != g.pkgPath {
+			fallback = g.pkgAlias(t.PkgPath()) + "." + fallback
+		}
+		fmt.Fprintln(g.out, ws+"  "+out+" = "+fallback)
+	}
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// int128Codecs are the codecs of 128-bit integer types, such as struct{ Hi, Lo uint64 }, by the
+// type of the high word. The low word is always uint64.
+var int128Codecs = map[string]reflect.Type{
+	"uint128": reflect.TypeOf(uint64(0)),
+	"int128":  reflect.TypeOf(int64(0)),
+}
+
+// checkInt128Type verifies that t is a struct with Hi and Lo words of the types the 128-bit
+// integer codec expects.
+func checkInt128Type(t reflect.Type, codec string) error {
+	if t.Kind() == reflect.Struct {
+		hi, hiOk := t.FieldByName("Hi")
+		lo, loOk := t.FieldByName("Lo")
+		if hiOk && loOk && hi.Type == int128Codecs[codec] && lo.Type == reflect.TypeOf(uint64(0)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("type %v mapped to %v codec must be a struct with Hi %v and Lo uint64 fields", t, codec, int128Codecs[codec])
+}
+
+// genInt128Decoder generates code that decodes a 128-bit integer type with Hi and Lo words from
+// a string with a decimal number. null leaves the value unchanged.
+func (g *Generator) genInt128Decoder(t reflect.Type, out, codec string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	if err := checkInt128Type(t, codec); err != nil {
+		return err
+	}
+
+	method := "Uint128Str"
+	if codec == "int128" {
+		method = "Int128Str"
+	}
+	fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+	fmt.Fprintln(g.out, ws+"  in.Skip()")
+	fmt.Fprintln(g.out, ws+"} else {")
+	fmt.Fprintln(g.out, ws+"  ("+out+").Hi, ("+out+").Lo = in."+method+"()")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// fullTypeName returns the name of a named type qualified with the package path, e.g.
+// github.com/google/uuid.UUID, or an empty string for unnamed types.
+func fullTypeName(t reflect.Type) string {
+	if t.Name() == "" {
+		return ""
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// timeConstructors are expressions creating a time.Time from a timestamp n for the supported
+// formats.
+var timeConstructors = map[string]string{
+	"unix":      "Unix(%v, 0)",
+	"unixmilli": "UnixMilli(%v)",
+	"unixnano":  "Unix(0, %v)",
+}
+
+// genTimeDecoder generates code that decodes a time.Time from an integer timestamp. The decoded
+// time is in UTC, and 0 is decoded as the zero time.
+func (g *Generator) genTimeDecoder(out, format string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	constructor, ok := timeConstructors[format]
+	if !ok {
+		return fmt.Errorf("unknown time format %q: only unix, unixmilli and unixnano are supported", format)
+	}
+	tmpVar := g.uniqueVarName()
+	pkg := g.pkgAlias("time")
+
+	fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+	fmt.Fprintln(g.out, ws+"  in.Skip()")
+	fmt.Fprintln(g.out, ws+"} else if "+tmpVar+" := in.Int64(); "+tmpVar+" == 0 {")
+	fmt.Fprintln(g.out, ws+"  "+out+" = "+pkg+".Time{}")
+	fmt.Fprintln(g.out, ws+"} else {")
+	fmt.Fprintln(g.out, ws+"  "+out+" = "+pkg+"."+fmt.Sprintf(constructor, tmpVar)+".UTC()")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// timeZoneMethods are methods of time.Time converting it to the supported time zones.
+var timeZoneMethods = map[string]string{
+	"UTC":   "UTC",
+	"Local": "Local",
+}
+
+// genTimeZoneDecoder generates code that decodes a time.Time as given by the other tags, and then
+// converts it to the time zone of the tz tag, keeping the instant. null leaves the value unchanged.
+func (g *Generator) genTimeZoneDecoder(out string, tags fieldTags, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	method, ok := timeZoneMethods[tags.tz]
+	if !ok {
+		return fmt.Errorf("unknown time zone %q: only UTC and Local are supported", tags.tz)
+	}
+	tags.tz = ""
+
+	fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+	fmt.Fprintln(g.out, ws+"  in.Skip()")
+	fmt.Fprintln(g.out, ws+"} else {")
+	if err := g.genTypeDecoder(timeType, out, tags, indent+1); err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, ws+"  if in.Ok() {")
+	fmt.Fprintln(g.out, ws+"    "+out+" = ("+out+")."+method+"()")
+	fmt.Fprintln(g.out, ws+"  }")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// genTransformDecoder generates code that decodes a string applying the transforms of the field
+// in order, after trimming it if it is tagged with trim as well.
+func (g *Generator) genTransformDecoder(t reflect.Type, out string, tags fieldTags, indent int) error {
+	ws := strings.Repeat("  ", indent)
+	tmpVar := g.uniqueVarName()
+
+	names := tags.transforms
+	if tags.trim {
+		names = append([]string{"trim"}, names...)
+	}
+
+	fmt.Fprintln(g.out, ws+"{")
+	fmt.Fprintln(g.out, ws+"  "+tmpVar+" := in.String()")
+	for _, name := range names {
+		switch name {
+		case "lower":
+			fmt.Fprintln(g.out, ws+"  "+tmpVar+" = "+g.pkgAlias("strings")+".ToLower("+tmpVar+")")
+		case "upper":
+			fmt.Fprintln(g.out, ws+"  "+tmpVar+" = "+g.pkgAlias("strings")+".ToUpper("+tmpVar+")")
+		case "trim":
+			fmt.Fprintln(g.out, ws+"  "+tmpVar+" = "+g.pkgAlias("strings")+".TrimSpace("+tmpVar+")")
+		case "":
+			return fmt.Errorf("empty transform name")
+		default:
+			fmt.Fprintf(g.out, ws+"  if s, err := %v.Transform(%q, %v); err != nil {\n", g.pkgAlias(pkgEasyJSON), name, tmpVar)
+			fmt.Fprintln(g.out, ws+"    in.AddError(err)")
+			fmt.Fprintln(g.out, ws+"  } else {")
+			fmt.Fprintln(g.out, ws+"    "+tmpVar+" = s")
+			fmt.Fprintln(g.out, ws+"  }")
+		}
+	}
+	fmt.Fprintln(g.out, ws+"  "+out+" = "+g.getType(t)+"("+tmpVar+")")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
 // genTypeDecoderNoCheck generates decoding code for the type t.
 func (g *Generator) genTypeDecoderNoCheck(t reflect.Type, out string, tags fieldTags, indent int) error {
 	ws := strings.Repeat("  ", indent)
 	// Check whether type is primitive, needs to be done after interface check.
+	if tags.format == hexFormat && isInteger(t) {
+		dec := "in.IntHexStr"
+		if isUnsigned(t) {
+			dec = "in.UintHexStr"
+		}
+		fmt.Fprintln(g.out, ws+out+" = "+g.getType(t)+"("+dec+"("+fmt.Sprint(t.Bits())+"))")
+		return nil
+	}
+	if tags.format == groupedFormat && isInteger(t) {
+		dec := "in.IntGroupedStr"
+		if isUnsigned(t) {
+			dec = "in.UintGroupedStr"
+		}
+		fmt.Fprintln(g.out, ws+out+" = "+g.getType(t)+"("+dec+"("+fmt.Sprint(t.Bits())+"))")
+		return nil
+	}
+	if width, err := padWidth(tags.format); err != nil {
+		return err
+	} else if width > 0 && isInteger(t) {
+		dec := "in.IntPaddedStr"
+		if isUnsigned(t) {
+			dec = "in.UintPaddedStr"
+		}
+		fmt.Fprintln(g.out, ws+out+" = "+g.getType(t)+"("+dec+"("+fmt.Sprint(t.Bits())+"))")
+		return nil
+	}
+	if len(tags.transforms) > 0 && t.Kind() == reflect.String {
+		return g.genTransformDecoder(t, out, tags, indent)
+	}
+	if tags.trim && t.Kind() == reflect.String {
+		fmt.Fprintln(g.out, ws+out+" = "+g.getType(t)+"("+g.pkgAlias("strings")+".TrimSpace(in.String()))")
+		return nil
+	}
+	if tags.format == "intbool" && t.Kind() == reflect.Bool {
+		fmt.Fprintln(g.out, ws+out+" = "+g.getType(t)+"(in.IntBool())")
+		return nil
+	}
 	if dec := primitiveStringDecoders[t.Kind()]; dec != "" && tags.asString {
 		fmt.Fprintln(g.out, ws+out+" = "+g.getType(t)+"("+dec+")")
 		return nil
 	} else if dec := primitiveDecoders[t.Kind()]; dec != "" {
+		if g.emptyAsZero && (isNumber(t) || t.Kind() == reflect.Bool) {
+			zero := "0"
+			if t.Kind() == reflect.Bool {
+				zero = "false"
+			}
+			fmt.Fprintln(g.out, ws+"if in.IsEmptyString() {")
+			fmt.Fprintln(g.out, ws+"  in.Skip()")
+			fmt.Fprintln(g.out, ws+"  "+out+" = "+g.getType(t)+"("+zero+")")
+			fmt.Fprintln(g.out, ws+"} else {")
+			ws += "  "
+		}
+		if g.quotedNumbers && isNumber(t) {
+			fmt.Fprintln(g.out, ws+"in.UnquoteNumber()")
+		}
 		fmt.Fprintln(g.out, ws+out+" = "+g.getType(t)+"("+dec+")")
+		if g.emptyAsZero && (isNumber(t) || t.Kind() == reflect.Bool) {
+			fmt.Fprintln(g.out, ws[2:]+"}")
+		}
 		return nil
 	}
 
@@ -91,21 +478,26 @@ func (g *Generator) genTypeDecoderNoCheck(t reflect.Type, out string, tags field
 			capacity = 1
 		}
 
-		fmt.Fprintln(g.out, ws+"in.Delim('[')")
-		fmt.Fprintln(g.out, ws+"if !in.IsDelim(']') {")
-		fmt.Fprintln(g.out, ws+"  "+out+" = make("+g.getType(t)+", 0, "+fmt.Sprint(capacity)+")")
-		fmt.Fprintln(g.out, ws+"} else {")
+		fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+		fmt.Fprintln(g.out, ws+"  in.Skip()")
 		fmt.Fprintln(g.out, ws+"  "+out+" = nil")
-		fmt.Fprintln(g.out, ws+"}")
-		fmt.Fprintln(g.out, ws+"for !in.IsDelim(']') {")
-		fmt.Fprintln(g.out, ws+"  var "+tmpVar+" "+g.getType(elem))
+		fmt.Fprintln(g.out, ws+"} else {")
+		fmt.Fprintln(g.out, ws+"  in.Delim('[')")
+		fmt.Fprintln(g.out, ws+"  if !in.IsDelim(']') {")
+		fmt.Fprintln(g.out, ws+"    "+out+" = make("+g.getType(t)+", 0, "+fmt.Sprint(capacity)+")")
+		fmt.Fprintln(g.out, ws+"  } else {")
+		fmt.Fprintln(g.out, ws+"    "+out+" = nil")
+		fmt.Fprintln(g.out, ws+"  }")
+		fmt.Fprintln(g.out, ws+"  for !in.IsDelim(']') {")
+		fmt.Fprintln(g.out, ws+"    var "+tmpVar+" "+g.getType(elem))
 
-		g.genTypeDecoder(elem, tmpVar, tags, indent+1)
+		g.genTypeDecoder(elem, tmpVar, tags, indent+2)
 
-		fmt.Fprintln(g.out, ws+"  "+out+" = append("+out+", "+tmpVar+")")
-		fmt.Fprintln(g.out, ws+"  in.WantComma()")
+		fmt.Fprintln(g.out, ws+"    "+out+" = append("+out+", "+tmpVar+")")
+		fmt.Fprintln(g.out, ws+"    in.WantComma()")
+		fmt.Fprintln(g.out, ws+"  }")
+		fmt.Fprintln(g.out, ws+"  in.Delim(']')")
 		fmt.Fprintln(g.out, ws+"}")
-		fmt.Fprintln(g.out, ws+"in.Delim(']')")
 
 	case reflect.Struct:
 		dec := g.getDecoderName(t)
@@ -128,28 +520,37 @@ func (g *Generator) genTypeDecoderNoCheck(t reflect.Type, out string, tags field
 
 	case reflect.Map:
 		key := t.Key()
-		if key.Kind() != reflect.String {
-			return fmt.Errorf("map type %v not supported: only string keys are allowed", key)
+		if err := checkMapKey(key); err != nil {
+			return err
 		}
 		elem := t.Elem()
 		tmpVar := g.uniqueVarName()
 
 		fmt.Fprintln(g.out, ws+"if in.IsNull() {")
 		fmt.Fprintln(g.out, ws+"  in.Skip()")
+		fmt.Fprintln(g.out, ws+"  "+out+" = nil")
 		fmt.Fprintln(g.out, ws+"} else {")
 		fmt.Fprintln(g.out, ws+"  in.Delim('{')")
-		fmt.Fprintln(g.out, ws+"  if !in.IsDelim('}') {")
-		fmt.Fprintln(g.out, ws+"  "+out+" = make("+g.getType(t)+")")
-		fmt.Fprintln(g.out, ws+"  } else {")
-		fmt.Fprintln(g.out, ws+"  "+out+" = nil")
+		// Keys are merged into an existing map, same as in encoding/json.
+		fmt.Fprintln(g.out, ws+"  if !in.IsDelim('}') && "+out+" == nil {")
+		fmt.Fprintln(g.out, ws+"    "+out+" = make("+g.getType(t)+")")
 		fmt.Fprintln(g.out, ws+"  }")
 
 		fmt.Fprintln(g.out, ws+"  for !in.IsDelim('}') {")
-		fmt.Fprintln(g.out, ws+"    key := "+g.getType(t.Key())+"(in.String())")
+		if key.Kind() == reflect.String {
+			fmt.Fprintln(g.out, ws+"    key := "+g.getType(key)+"(in.String())")
+		} else {
+			fmt.Fprintln(g.out, ws+"    var key "+g.getType(key))
+			fmt.Fprintln(g.out, ws+"    if data := []byte(in.String()); in.Ok() {")
+			fmt.Fprintln(g.out, ws+"      in.AddError( key.UnmarshalText(data) )")
+			fmt.Fprintln(g.out, ws+"    }")
+		}
 		fmt.Fprintln(g.out, ws+"    in.WantColon()")
 		fmt.Fprintln(g.out, ws+"    var "+tmpVar+" "+g.getType(elem))
 
-		g.genTypeDecoder(elem, tmpVar, tags, indent+2)
+		if err := g.genTypeDecoder(elem, tmpVar, tags, indent+2); err != nil {
+			return err
+		}
 
 		fmt.Fprintln(g.out, ws+"    ("+out+")[key] = "+tmpVar)
 		fmt.Fprintln(g.out, ws+"    in.WantComma()")
@@ -159,7 +560,7 @@ func (g *Generator) genTypeDecoderNoCheck(t reflect.Type, out string, tags field
 
 	case reflect.Interface:
 		if t.NumMethod() != 0 {
-			return fmt.Errorf("interface type %v not supported: only interface{} is allowed", t)
+			return g.genInterfaceDecoder(t, out, indent)
 		}
 		fmt.Fprintln(g.out, ws+out+" = in.Interface()")
 
@@ -170,17 +571,210 @@ func (g *Generator) genTypeDecoderNoCheck(t reflect.Type, out string, tags field
 
 }
 
+// genRLEDecoder generates code that expands the runs of [value,count] pairs into an integer
+// slice. null and [] are decoded as a nil slice.
+func (g *Generator) genRLEDecoder(t reflect.Type, out string, tags fieldTags, indent int) error {
+	if err := checkRLE(t); err != nil {
+		return err
+	}
+	ws := strings.Repeat("  ", indent)
+	tmpVar := g.uniqueVarName()
+	nVar := g.uniqueVarName()
+
+	fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+	fmt.Fprintln(g.out, ws+"  in.Skip()")
+	fmt.Fprintln(g.out, ws+"  "+out+" = nil")
+	fmt.Fprintln(g.out, ws+"} else {")
+	fmt.Fprintln(g.out, ws+"  "+out+" = nil")
+	fmt.Fprintln(g.out, ws+"  in.Delim('[')")
+	fmt.Fprintln(g.out, ws+"  for !in.IsDelim(']') {")
+	fmt.Fprintln(g.out, ws+"    var "+tmpVar+" "+g.getType(t.Elem()))
+	fmt.Fprintln(g.out, ws+"    in.Delim('[')")
+	tags.format = ""
+	if err := g.genTypeDecoder(t.Elem(), tmpVar, tags, indent+2); err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, ws+"    in.WantComma()")
+	fmt.Fprintln(g.out, ws+"    for "+nVar+" := in.RunLength(len("+out+")); "+nVar+" > 0; "+nVar+"-- {")
+	fmt.Fprintln(g.out, ws+"      "+out+" = append("+out+", "+tmpVar+")")
+	fmt.Fprintln(g.out, ws+"    }")
+	fmt.Fprintln(g.out, ws+"    in.WantComma()")
+	fmt.Fprintln(g.out, ws+"    in.Delim(']')")
+	fmt.Fprintln(g.out, ws+"    in.WantComma()")
+	fmt.Fprintln(g.out, ws+"  }")
+	fmt.Fprintln(g.out, ws+"  in.Delim(']')")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// genJSONStringDecoder generates code that reads a string and decodes the JSON document in it
+// with a separate lexer.
+func (g *Generator) genJSONStringDecoder(t reflect.Type, out string, tags fieldTags, indent int) error {
+	ws := strings.Repeat("  ", indent)
+	outerVar := g.uniqueVarName()
+
+	fmt.Fprintln(g.out, ws+"{")
+	fmt.Fprintln(g.out, ws+"  "+outerVar+" := in")
+	fmt.Fprintln(g.out, ws+"  in := "+outerVar+".StringDocument()")
+	tags.format = ""
+	if err := g.genTypeDecoder(t, out, tags, indent+1); err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, ws+"  in.Consumed()")
+	fmt.Fprintln(g.out, ws+"  "+outerVar+".AddError(in.Error())")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// genOneOfDecoder generates code that decodes a value of a sealed interface type from an object
+// with a key naming the variant, unmarshaling the variant with its easyjson.Unmarshaler
+// implementation. Unknown keys are skipped, so null, {} and an object with no known variant set
+// the interface to nil.
+func (g *Generator) genOneOfDecoder(t reflect.Type, out, tag string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	variants, err := parseOneOf(tag)
+	if err != nil {
+		return fmt.Errorf("type %v: %v", t, err)
+	}
+	tmpVar := g.uniqueVarName()
+
+	fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+	fmt.Fprintln(g.out, ws+"  in.Skip()")
+	fmt.Fprintln(g.out, ws+"  "+out+" = nil")
+	fmt.Fprintln(g.out, ws+"} else {")
+	fmt.Fprintln(g.out, ws+"  "+out+" = nil")
+	fmt.Fprintln(g.out, ws+"  in.Delim('{')")
+	fmt.Fprintln(g.out, ws+"  for !in.IsDelim('}') {")
+	fmt.Fprintln(g.out, ws+"    key := in.UnsafeString()")
+	fmt.Fprintln(g.out, ws+"    in.WantColon()")
+	fmt.Fprintln(g.out, ws+"    switch key {")
+	for _, v := range variants {
+		fmt.Fprintf(g.out, ws+"    case %q:\n", v.key)
+		if v.ptr {
+			fmt.Fprintln(g.out, ws+"      "+tmpVar+" := new("+strings.TrimPrefix(g.oneOfType(t, v), "*")+")")
+		} else {
+			fmt.Fprintln(g.out, ws+"      var "+tmpVar+" "+g.oneOfType(t, v))
+		}
+		fmt.Fprintln(g.out, ws+"      "+tmpVar+".UnmarshalEasyJSON(in)")
+		fmt.Fprintln(g.out, ws+"      "+out+" = "+tmpVar)
+	}
+	fmt.Fprintln(g.out, ws+"    default:")
+	fmt.Fprintln(g.out, ws+"      in.SkipRecursive()")
+	fmt.Fprintln(g.out, ws+"    }")
+	fmt.Fprintln(g.out, ws+"    in.WantComma()")
+	fmt.Fprintln(g.out, ws+"  }")
+	fmt.Fprintln(g.out, ws+"  in.Delim('}')")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// genInterfaceDecoder generates code that decodes a value of a non-empty interface type into the
+// dynamic value the interface already holds, which has to implement one of the unmarshaler
+// interfaces. null sets the interface to nil.
+func (g *Generator) genInterfaceDecoder(t reflect.Type, out string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+	tmpVar := g.uniqueVarName()
+
+	fmt.Fprintln(g.out, ws+"if in.IsNull() {")
+	fmt.Fprintln(g.out, ws+"  in.Skip()")
+	fmt.Fprintln(g.out, ws+"  "+out+" = nil")
+	fmt.Fprintln(g.out, ws+"} else if "+tmpVar+", ok := "+out+".("+g.pkgAlias(pkgEasyJSON)+".Unmarshaler); ok {")
+	fmt.Fprintln(g.out, ws+"  "+tmpVar+".UnmarshalEasyJSON(in)")
+	fmt.Fprintln(g.out, ws+"} else if "+tmpVar+", ok := "+out+".(json.Unmarshaler); ok {")
+	fmt.Fprintln(g.out, ws+"  if data := in.Raw(); in.Ok() {")
+	fmt.Fprintln(g.out, ws+"    in.AddError( "+tmpVar+".UnmarshalJSON(data) )")
+	fmt.Fprintln(g.out, ws+"  }")
+	fmt.Fprintln(g.out, ws+"} else {")
+	fmt.Fprintf(g.out, ws+"  in.AddError(fmt.Errorf(\"can't decode into %%T: a value implementing an unmarshaler must be set for %v\", %v))\n", g.getType(t), out)
+	fmt.Fprintln(g.out, ws+"  in.SkipRecursive()")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
 func (g *Generator) genStructFieldDecoder(t reflect.Type, f reflect.StructField) error {
 	jsonName := g.fieldNamer.GetJSONFieldName(t, f)
 	tags := parseFieldTags(f)
 
-	if tags.omit {
+	if tags.omit || tags.inline || g.trackPresence && f.Type == presenceType {
 		return nil
 	}
+	if tags.enumFallback != "" {
+		// The fallback applies to enum values, also when these are elements of the field.
+		elem := f.Type
+		for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array || elem.Kind() == reflect.Map {
+			elem = elem.Elem()
+		}
+		if !reflect.PtrTo(elem).Implements(reflect.TypeOf((*easyjson.Enum)(nil)).Elem()) {
+			return fmt.Errorf("field %v is tagged with enum_fallback, but %v does not implement easyjson.Enum", f.Name, elem)
+		}
+	}
 
-	fmt.Fprintf(g.out, "    case %q:\n", jsonName)
-	if err := g.genTypeDecoder(f.Type, "out."+f.Name, tags, 3); err != nil {
-		return err
+	// An object with several of the names leaves the value of the last one, as for duplicate keys.
+	if keyExpr := fieldKeyExpr(t, f, "out"); keyExpr != "" {
+		cond := "key == " + keyExpr
+		for _, alias := range tags.aliases {
+			cond += fmt.Sprintf(" || key == %q", alias)
+		}
+		fmt.Fprintln(g.out, "    case "+cond+":")
+	} else {
+		names := strconv.Quote(jsonName)
+		for _, alias := range tags.aliases {
+			names += ", " + strconv.Quote(alias)
+		}
+		fmt.Fprintln(g.out, "    case "+names+":")
+	}
+	if tags.buildTag != "" {
+		// A field of a disabled tag is skipped as an unknown one.
+		fmt.Fprintf(g.out, "      if !%v.BuildTag(%q) {\n", g.pkgAlias(pkgEasyJSON), tags.buildTag)
+		fmt.Fprintln(g.out, "        in.SkipRecursive()")
+		fmt.Fprintln(g.out, "        break")
+		fmt.Fprintln(g.out, "      }")
+	}
+	// Embedded pointers are allocated on demand, keeping the already set ones to merge into them.
+	for _, p := range embeddedPtrs(t, f, "out") {
+		fmt.Fprintln(g.out, "      if "+p.selector+" == nil {")
+		fmt.Fprintln(g.out, "        "+p.selector+" = new("+g.getType(p.elem)+")")
+		fmt.Fprintln(g.out, "      }")
+	}
+
+	var startVar, rawField string
+	if tags.preserve {
+		raw, ok := t.FieldByName(f.Name + "Raw")
+		if !ok || raw.Type != rawMessageType {
+			return fmt.Errorf("field %v is tagged with preserve, but %vRaw json.RawMessage field is missing", f.Name, f.Name)
+		}
+		startVar, rawField = g.uniqueVarName(), "out."+raw.Name
+		fmt.Fprintln(g.out, "      "+startVar+" := in.ValueStart()")
+	}
+
+	if decodesNull(f.Type) {
+		if err := g.genTypeDecoder(f.Type, "out."+f.Name, tags, 3); err != nil {
+			return err
+		}
+	} else {
+		// null does not change values that cannot be nil, same as in encoding/json.
+		fmt.Fprintln(g.out, "      if in.IsNull() {")
+		fmt.Fprintln(g.out, "        in.Skip()")
+		fmt.Fprintln(g.out, "      } else {")
+		if err := g.genTypeDecoder(f.Type, "out."+f.Name, tags, 4); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, "      }")
+	}
+
+	if tags.preserve {
+		fmt.Fprintln(g.out, "      if in.Ok() {")
+		fmt.Fprintln(g.out, "        "+rawField+" = append("+rawField+"[:0], in.Data["+startVar+":in.ValueEnd()]...)")
+		fmt.Fprintln(g.out, "      }")
+	}
+
+	if p := g.presenceField(t); p != "" {
+		key := strconv.Quote(jsonName)
+		if keyExpr := fieldKeyExpr(t, f, "out"); keyExpr != "" {
+			key = keyExpr
+		}
+		fmt.Fprintln(g.out, "      out."+p+".Set("+key+")")
 	}
 
 	if tags.required {
@@ -190,6 +784,75 @@ func (g *Generator) genStructFieldDecoder(t reflect.Type, f reflect.StructField)
 	return nil
 }
 
+// presenceField returns the name of the easyjson.Presence field of the struct type t that the
+// keys of the present fields are recorded to with -track_presence, or an empty string.
+func (g *Generator) presenceField(t reflect.Type) string {
+	if !g.trackPresence || t.Kind() != reflect.Struct {
+		return ""
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Type == presenceType {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// decodesNull returns true if the generated decoder for the type handles null itself: values
+// that can be nil are reset to nil, unmarshalers get null as the input.
+func decodesNull(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*easyjson.Unmarshaler)(nil)).Elem()) ||
+		reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface, reflect.Struct:
+		return true
+	}
+	return false
+}
+
+// getInlineField returns the map field tagged with 'inline' collecting unknown keys, if any.
+func getInlineField(fs []reflect.StructField) (*reflect.StructField, error) {
+	var ret *reflect.StructField
+	for i, f := range fs {
+		if tags := parseFieldTags(f); tags.omit || !tags.inline {
+			continue
+		}
+		if f.Type.Kind() != reflect.Map || f.Type.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("inline field %v must be a map with string keys", f.Name)
+		}
+		if ret != nil {
+			return nil, fmt.Errorf("fields %v and %v: only one inline field is allowed", ret.Name, f.Name)
+		}
+		ret = &fs[i]
+	}
+	return ret, nil
+}
+
+// genInlineMapDecoder generates code that stores a value for an unknown key to the inline map.
+func (g *Generator) genInlineMapDecoder(f reflect.StructField) error {
+	elem := f.Type.Elem()
+	tmpVar := g.uniqueVarName()
+
+	fmt.Fprintln(g.out, "      if in.IsNull() {")
+	fmt.Fprintln(g.out, "        in.Skip()")
+	fmt.Fprintln(g.out, "        break")
+	fmt.Fprintln(g.out, "      }")
+	fmt.Fprintln(g.out, "      if out."+f.Name+" == nil {")
+	fmt.Fprintln(g.out, "        out."+f.Name+" = make("+g.getType(f.Type)+")")
+	fmt.Fprintln(g.out, "      }")
+	fmt.Fprintln(g.out, "      var "+tmpVar+" "+g.getType(elem))
+
+	if err := g.genTypeDecoder(elem, tmpVar, fieldTags{}, 3); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(g.out, "      out."+f.Name+"["+g.getType(f.Type.Key())+"(key)] = "+tmpVar)
+	return nil
+}
+
 func (g *Generator) genRequiredFieldSet(t reflect.Type, f reflect.StructField) {
 	tags := parseFieldTags(f)
 
@@ -210,7 +873,11 @@ func (g *Generator) genRequiredFieldCheck(t reflect.Type, f reflect.StructField)
 
 	g.imports["fmt"] = "fmt"
 
-	fmt.Fprintf(g.out, "if !%sSet {\n", f.Name)
+	if tags.buildTag != "" {
+		fmt.Fprintf(g.out, "if !%sSet && %v.BuildTag(%q) {\n", f.Name, g.pkgAlias(pkgEasyJSON), tags.buildTag)
+	} else {
+		fmt.Fprintf(g.out, "if !%sSet {\n", f.Name)
+	}
 	fmt.Fprintf(g.out, "    in.AddError(fmt.Errorf(\"key '%s' is required\"))\n", jsonName)
 	fmt.Fprintf(g.out, "}\n")
 }
@@ -230,9 +897,41 @@ func mergeStructFields(fields1, fields2 []reflect.StructField) (fields []reflect
 	return
 }
 
-func getStructFields(t reflect.Type) ([]reflect.StructField, error) {
+// embeddedPtr is an embedded pointer field a promoted field is reached through.
+type embeddedPtr struct {
+	selector string       // Selector of the pointer field, e.g. in.Meta.
+	elem     reflect.Type // Type the pointer points to.
+}
+
+// embeddedPtrs returns the embedded pointer fields, from the outermost one, the field f of the
+// struct type t is promoted through, with selectors relative to v. These need to be checked
+// for nil before accessing the field for encoding, and allocated for decoding.
+func embeddedPtrs(t reflect.Type, f reflect.StructField, v string) []embeddedPtr {
+	sf, ok := t.FieldByName(f.Name)
+	if !ok {
+		return nil
+	}
+
+	var ptrs []embeddedPtr
+	selector := v
+	for _, i := range sf.Index[:len(sf.Index)-1] {
+		ef := t.Field(i)
+		selector += "." + ef.Name
+		t = ef.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+			ptrs = append(ptrs, embeddedPtr{selector: selector, elem: t})
+		}
+	}
+	return ptrs
+}
+
+// getStructFields returns the fields of a struct including the fields of embedded structs.
+// Fields of types that cannot be represented in JSON (funcs, channels) are returned separately
+// as skipped, unless they are explicitly omitted with a tag.
+func getStructFields(t reflect.Type) (fields, skipped []reflect.StructField, err error) {
 	if t.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("got %v; expected a struct", t)
+		return nil, nil, fmt.Errorf("got %v; expected a struct", t)
 	}
 
 	var efields []reflect.StructField
@@ -246,36 +945,72 @@ func getStructFields(t reflect.Type) ([]reflect.StructField, error) {
 		if t1.Kind() == reflect.Ptr {
 			t1 = t1.Elem()
 		}
+		if t1.Kind() != reflect.Struct {
+			// Embedded non-structs, e.g. interfaces, are regular fields named after the type.
+			continue
+		}
 
-		fs, err := getStructFields(t1)
+		fs, es, err := getStructFields(t1)
 		if err != nil {
-			return nil, fmt.Errorf("error processing embedded field: %v", err)
+			return nil, nil, fmt.Errorf("error processing embedded field: %v", err)
 		}
 		efields = mergeStructFields(efields, fs)
+		skipped = append(skipped, es...)
 	}
 
-	var fields []reflect.StructField
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
-		if f.Anonymous {
+		if f.Anonymous && (f.Type.Kind() == reflect.Struct || f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct) {
 			continue
 		}
 
 		c := []rune(f.Name)[0]
-		if unicode.IsUpper(c) {
-			fields = append(fields, f)
+		if !unicode.IsUpper(c) {
+			continue
 		}
+		if isUnsupportedKind(f.Type.Kind()) {
+			if !parseFieldTags(f).omit {
+				skipped = append(skipped, f)
+			}
+			continue
+		}
+		fields = append(fields, f)
 	}
-	return mergeStructFields(efields, fields), nil
+	return mergeStructFields(efields, fields), skipped, nil
+}
+
+// isUnsupportedKind returns true for kinds of types that have no JSON representation.
+func isUnsupportedKind(k reflect.Kind) bool {
+	return k == reflect.Func || k == reflect.Chan || k == reflect.UnsafePointer
 }
 
 func (g *Generator) genDecoder(t reflect.Type) error {
 	switch t.Kind() {
 	case reflect.Slice:
 		return g.genSliceDecoder(t)
-	default:
+	case reflect.Struct:
 		return g.genStructDecoder(t)
+	default:
+		return g.genPrimitiveDecoder(t)
+	}
+}
+
+func (g *Generator) genPrimitiveDecoder(t reflect.Type) error {
+	if primitiveDecoders[t.Kind()] == "" {
+		return fmt.Errorf("cannot generate encoder/decoder for %v, not a primitive type", t)
+	}
+
+	fname := g.getDecoderName(t)
+	typ := g.getType(t)
+
+	fmt.Fprintln(g.out, "func "+fname+"(in *jlexer.Lexer, out *"+typ+") {")
+	err := g.genTypeDecoderNoCheck(t, "*out", fieldTags{}, 1)
+	if err != nil {
+		return err
 	}
+	fmt.Fprintln(g.out, "}")
+
+	return nil
 }
 
 func (g *Generator) genSliceDecoder(t reflect.Type) error {
@@ -296,6 +1031,43 @@ func (g *Generator) genSliceDecoder(t reflect.Type) error {
 	return nil
 }
 
+// checkFieldKeys verifies that no two fields are encoded with the same key, e.g. due to tags or
+// a field namer, and that the aliases of the fields and the virtual fields do not clash with the
+// keys of other fields or with each other, since all of them are cases of a single key switch.
+// The keys of types implementing easyjson.FieldKeyer are only known at runtime, so these are not
+// checked.
+func (g *Generator) checkFieldKeys(t reflect.Type, fs []reflect.StructField) error {
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*easyjson.FieldKeyer)(nil)).Elem()) {
+		return nil
+	}
+
+	names := make(map[string]string, len(fs))
+	for _, f := range fs {
+		if tags := parseFieldTags(f); !tags.omit && !tags.inline {
+			name := g.fieldNamer.GetJSONFieldName(t, f)
+			if other, ok := names[name]; ok {
+				return fmt.Errorf("fields %v and %v have the same key %q", other, f.Name, name)
+			}
+			names[name] = f.Name
+		}
+	}
+	for _, f := range fs {
+		for _, alias := range parseFieldTags(f).aliases {
+			if other, ok := names[alias]; ok {
+				return fmt.Errorf("alias %q of field %v clashes with field %v", alias, f.Name, other)
+			}
+			names[alias] = f.Name
+		}
+	}
+	for _, f := range g.virtualFields[t] {
+		if other, ok := names[f.key]; ok {
+			return fmt.Errorf("virtual field %q of method %v clashes with field %v", f.key, f.method, other)
+		}
+		names[f.key] = f.method
+	}
+	return nil
+}
+
 func (g *Generator) genStructDecoder(t reflect.Type) error {
 	if t.Kind() != reflect.Struct {
 		return fmt.Errorf("cannot generate encoder/decoder for %v, not a struct type", t)
@@ -310,17 +1082,22 @@ func (g *Generator) genStructDecoder(t reflect.Type) error {
 	fmt.Fprintln(g.out, "    return")
 	fmt.Fprintln(g.out, "  }")
 
-	// Init embedded pointer fields.
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		if !f.Anonymous || f.Type.Kind() != reflect.Ptr {
-			continue
+	fs, skipped, err := getStructFields(t)
+	if err != nil {
+		return fmt.Errorf("cannot generate decoder for %v: %v", t, err)
+	}
+	for _, f := range skipped {
+		if g.rejectUnsupported {
+			err := fmt.Errorf("field %v.%v of unsupported type %v: tag it with json:\"-\"", t, f.Name, f.Type)
+			if pos := g.fieldPositions[t][f.Name]; pos != "" {
+				err = fmt.Errorf("%v: %v", pos, err)
+			}
+			return err
 		}
-		fmt.Fprintln(g.out, "  out."+f.Name+" = new("+g.getType(f.Type.Elem())+")")
+		fmt.Fprintf(os.Stderr, "easyjson: warning: skipping field %v.%v of unsupported type %v\n", t, f.Name, f.Type)
 	}
 
-	fs, err := getStructFields(t)
-	if err != nil {
+	if err := g.checkFieldKeys(t, fs); err != nil {
 		return fmt.Errorf("cannot generate decoder for %v: %v", t, err)
 	}
 
@@ -328,25 +1105,53 @@ func (g *Generator) genStructDecoder(t reflect.Type) error {
 		g.genRequiredFieldSet(t, f)
 	}
 
+	inline, err := getInlineField(fs)
+	if err != nil {
+		return fmt.Errorf("cannot generate decoder for %v: %v", t, err)
+	}
+
+	if p := g.presenceField(t); p != "" {
+		fmt.Fprintln(g.out, "  out."+p+" = nil")
+	}
 	fmt.Fprintln(g.out, "  in.Delim('{')")
 	fmt.Fprintln(g.out, "  for !in.IsDelim('}') {")
-	fmt.Fprintln(g.out, "    key := in.UnsafeString()")
+	if inline != nil {
+		// Keys are stored to the inline map, so they cannot point to the input buffer.
+		fmt.Fprintln(g.out, "    key := in.String()")
+	} else {
+		fmt.Fprintln(g.out, "    key := in.UnsafeString()")
+	}
 	fmt.Fprintln(g.out, "    in.WantColon()")
-	fmt.Fprintln(g.out, "    if in.IsNull() {")
-	fmt.Fprintln(g.out, "       in.Skip()")
-	fmt.Fprintln(g.out, "       in.WantComma()")
-	fmt.Fprintln(g.out, "       continue")
-	fmt.Fprintln(g.out, "    }")
 
-	fmt.Fprintln(g.out, "    switch key {")
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*easyjson.FieldKeyer)(nil)).Elem()) {
+		fmt.Fprintln(g.out, "    switch {")
+	} else {
+		fmt.Fprintln(g.out, "    switch key {")
+	}
 	for _, f := range fs {
 		if err := g.genStructFieldDecoder(t, f); err != nil {
 			return err
 		}
 	}
+	// Virtual fields are only output, their keys are skipped instead of being stored to an inline
+	// map as unknown ones.
+	for _, f := range g.virtualFields[t] {
+		if reflect.PtrTo(t).Implements(reflect.TypeOf((*easyjson.FieldKeyer)(nil)).Elem()) {
+			fmt.Fprintf(g.out, "    case key == %q:\n", f.key)
+		} else {
+			fmt.Fprintf(g.out, "    case %q:\n", f.key)
+		}
+		fmt.Fprintln(g.out, "      in.SkipRecursive()")
+	}
 
 	fmt.Fprintln(g.out, "    default:")
-	fmt.Fprintln(g.out, "      in.SkipRecursive()")
+	if inline != nil {
+		if err := g.genInlineMapDecoder(*inline); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(g.out, "      in.SkipRecursive()")
+	}
 	fmt.Fprintln(g.out, "    }")
 	fmt.Fprintln(g.out, "    in.WantComma()")
 	fmt.Fprintln(g.out, "  }")
@@ -362,26 +1167,54 @@ func (g *Generator) genStructDecoder(t reflect.Type) error {
 }
 
 func (g *Generator) genStructUnmarshaller(t reflect.Type) error {
-	if t.Kind() != reflect.Struct && t.Kind() != reflect.Slice {
-		return fmt.Errorf("cannot generate encoder/decoder for %v, not a struct/slice type", t)
+	if t.Kind() != reflect.Struct && t.Kind() != reflect.Slice && primitiveDecoders[t.Kind()] == "" {
+		return fmt.Errorf("cannot generate encoder/decoder for %v, not a struct/slice/primitive type", t)
 	}
 
 	fname := g.getDecoderName(t)
 	typ := g.getType(t)
 
 	if !g.noStdMarshalers {
-		fmt.Fprintln(g.out, "// UnmarshalJSON supports json.Unmarshaler interface")
-		fmt.Fprintln(g.out, "func (v *"+typ+") UnmarshalJSON(data []byte) error {")
+		if names, ok := g.methodNames[t]; ok {
+			fmt.Fprintln(g.out, "// "+names[1]+" unmarshals the value from JSON bytes")
+			fmt.Fprintln(g.out, "func (v *"+typ+") "+names[1]+"(data []byte) error {")
+		} else {
+			fmt.Fprintln(g.out, "// UnmarshalJSON supports json.Unmarshaler interface")
+			fmt.Fprintln(g.out, "func (v *"+typ+") UnmarshalJSON(data []byte) error {")
+		}
 		fmt.Fprintln(g.out, "  r := jlexer.Lexer{Data: data}")
 		fmt.Fprintln(g.out, "  "+fname+"(&r, v)")
 		fmt.Fprintln(g.out, "  return r.Error()")
 		fmt.Fprintln(g.out, "}")
 	}
 
+	if g.ioInterfaces {
+		g.imports["io"] = "io"
+		g.imports["io/ioutil"] = "ioutil"
+
+		fmt.Fprintln(g.out, "// ReadFrom supports io.ReaderFrom interface")
+		fmt.Fprintln(g.out, "func (v *"+typ+") ReadFrom(r io.Reader) (int64, error) {")
+		fmt.Fprintln(g.out, "  data, err := ioutil.ReadAll(r)")
+		fmt.Fprintln(g.out, "  if err != nil {")
+		fmt.Fprintln(g.out, "    return int64(len(data)), err")
+		fmt.Fprintln(g.out, "  }")
+		fmt.Fprintln(g.out, "  l := jlexer.Lexer{Data: data}")
+		fmt.Fprintln(g.out, "  "+fname+"(&l, v)")
+		fmt.Fprintln(g.out, "  return int64(len(data)), l.Error()")
+		fmt.Fprintln(g.out, "}")
+	}
+
 	fmt.Fprintln(g.out, "// UnmarshalEasyJSON supports easyjson.Unmarshaler interface")
 	fmt.Fprintln(g.out, "func (v *"+typ+") UnmarshalEasyJSON(l *jlexer.Lexer) {")
 	fmt.Fprintln(g.out, "  "+fname+"(l, v)")
 	fmt.Fprintln(g.out, "}")
 
+	if p := g.presenceField(t); p != "" {
+		fmt.Fprintln(g.out, "// PresentFields supports easyjson.PresenceTracker interface")
+		fmt.Fprintln(g.out, "func (v "+typ+") PresentFields() "+g.pkgAlias(pkgEasyJSON)+".Presence {")
+		fmt.Fprintln(g.out, "  return v."+p)
+		fmt.Fprintln(g.out, "}")
+	}
+
 	return nil
 }
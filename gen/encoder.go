@@ -1,13 +1,16 @@
 package gen
 
 import (
+	"encoding"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/mailru/easyjson"
+	"github.com/mailru/easyjson/jwriter"
 )
 
 func (g *Generator) getEncoderName(t reflect.Type) string {
@@ -32,6 +35,7 @@ var primitiveEncoders = map[reflect.Kind]string{
 }
 
 var primitiveStringEncoders = map[reflect.Kind]string{
+	reflect.Bool:   "out.BoolStr(bool(%v))",
 	reflect.Int:    "out.IntStr(int(%v))",
 	reflect.Int8:   "out.Int8Str(int8(%v))",
 	reflect.Int16:  "out.Int16Str(int16(%v))",
@@ -53,15 +57,107 @@ type fieldTags struct {
 	noOmitEmpty bool
 	asString    bool
 	required    bool
+	inline      bool
+
+	// preserve is set by `easyjson:"preserve"` tag, raw bytes of the field value are stored to
+	// the companion <Field>Raw json.RawMessage field during decoding.
+	preserve bool
+
+	// trim is set by `easyjson:"trim"` tag, leading and trailing whitespace is removed from
+	// decoded string values.
+	trim bool
+
+	// transforms are set by `easyjson:"transform=name;name"` tag, the functions are applied in
+	// order to decoded string values. Builtin ones are called directly, others are looked up
+	// with easyjson.Transform at runtime.
+	transforms []string
+
+	// buildTag is set by `easyjson:"buildtag=name"` tag, the field is only marshaled and
+	// unmarshaled if the tag is enabled with easyjson.SetBuildTag at runtime.
+	buildTag string
+
+	// format is set by `easyjson:"format=..."` tag, one of:
+	//   - unix, unixmilli or unixnano: an integer timestamp, on time.Time fields;
+	//   - intbool: a 0 or 1 number, on bool fields;
+	//   - base64le_u32: a base64 string of little-endian packed integers, on []uint32 fields;
+	//   - rle: an array of [value,count] runs, on integer slice fields;
+	//   - hex: a 0x-prefixed hex string, on integer fields;
+	//   - grouped: a string with thousands separators, on integer fields;
+	//   - pad:<width>: a string zero-padded to width digits, on integer fields;
+	//   - jsonstring: a string containing the JSON document of the value, on any field.
+	format string
+
+	// tz is set by `easyjson:"tz=..."` tag: UTC or Local, decoded time.Time values are converted
+	// to the time zone.
+	tz string
+
+	// enumFallback is set by `easyjson:"enum_fallback=Name"` tag, the constant Name of the enum
+	// type is decoded instead of an unknown label, which is an error otherwise.
+	enumFallback string
+
+	// oneOf is set by `easyjson:"oneof=key:Type|key:*Type"` tag on a field of a sealed interface
+	// type: the value is wrapped in an object with the key of its variant type, see parseOneOf.
+	oneOf string
+
+	// aliases are set by `easyjson:"aliases=old;legacy"` tag, the additional keys the field is
+	// decoded from. The field is encoded with its primary name.
+	aliases []string
+
+	// maxLen is set by `easyjson:"maxlen=N"` tag, encoded strings are truncated to N runes
+	// followed by an ellipsis.
+	maxLen string
+
+	// valueOmitEmpty is set by `easyjson:"value_omitempty"` tag on a map field, entries with
+	// empty values are skipped during encoding.
+	valueOmitEmpty bool
+}
+
+// oneOfVariant is a variant of a sealed interface: a type of its package, possibly a pointer,
+// and the key naming it in the JSON output.
+type oneOfVariant struct {
+	key string
+	typ string
+	ptr bool
+}
+
+// parseOneOf parses the variants of a oneof tag, e.g. circle:Circle|square:*Square.
+func parseOneOf(tag string) ([]oneOfVariant, error) {
+	var ret []oneOfVariant
+	for _, s := range strings.Split(tag, "|") {
+		parts := strings.Split(s, ":")
+		if len(parts) != 2 || parts[0] == "" || strings.TrimPrefix(parts[1], "*") == "" {
+			return nil, fmt.Errorf("expected oneof=key:Type|key:*Type, got variant %q", s)
+		}
+		v := oneOfVariant{key: parts[0], typ: strings.TrimPrefix(parts[1], "*")}
+		v.ptr = v.typ != parts[1]
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
+
+// oneOfType returns the type of a variant of the sealed interface t, qualified if t is declared
+// in another package. The variants implement the unexported methods of t, so they are declared
+// in the same package.
+func (g *Generator) oneOfType(t reflect.Type, v oneOfVariant) string {
+	typ := v.typ
+	if t.PkgPath() != g.pkgPath {
+		typ = g.pkgAlias(t.PkgPath()) + "." + typ
+	}
+	if v.ptr {
+		typ = "*" + typ
+	}
+	return typ
 }
 
 // parseFieldTags parses the json field tag into a structure.
 func parseFieldTags(f reflect.StructField) fieldTags {
 	var ret fieldTags
 
-	for i, s := range strings.Split(f.Tag.Get("json"), ",") {
+	// As in encoding/json, only the tag "-" skips the field, while "-," names the field "-".
+	tag := f.Tag.Get("json")
+	for i, s := range strings.Split(tag, ",") {
 		switch {
-		case i == 0 && s == "-":
+		case i == 0 && tag == "-":
 			ret.omit = true
 		case i == 0:
 			ret.name = s
@@ -73,6 +169,38 @@ func parseFieldTags(f reflect.StructField) fieldTags {
 			ret.asString = true
 		case s == "required":
 			ret.required = true
+		case s == "inline":
+			ret.inline = true
+		case s == "format=hex":
+			// Deprecated: the format is read from the easyjson tag, which overrides this one.
+			ret.format = hexFormat
+		}
+	}
+
+	for _, s := range strings.Split(f.Tag.Get("easyjson"), ",") {
+		switch {
+		case s == "preserve":
+			ret.preserve = true
+		case s == "trim":
+			ret.trim = true
+		case s == "value_omitempty":
+			ret.valueOmitEmpty = true
+		case strings.HasPrefix(s, "format="):
+			ret.format = strings.TrimPrefix(s, "format=")
+		case strings.HasPrefix(s, "maxlen="):
+			ret.maxLen = strings.TrimPrefix(s, "maxlen=")
+		case strings.HasPrefix(s, "tz="):
+			ret.tz = strings.TrimPrefix(s, "tz=")
+		case strings.HasPrefix(s, "buildtag="):
+			ret.buildTag = strings.TrimPrefix(s, "buildtag=")
+		case strings.HasPrefix(s, "enum_fallback="):
+			ret.enumFallback = strings.TrimPrefix(s, "enum_fallback=")
+		case strings.HasPrefix(s, "oneof="):
+			ret.oneOf = strings.TrimPrefix(s, "oneof=")
+		case strings.HasPrefix(s, "transform="):
+			ret.transforms = strings.Split(strings.TrimPrefix(s, "transform="), ";")
+		case strings.HasPrefix(s, "aliases="):
+			ret.aliases = strings.Split(strings.TrimPrefix(s, "aliases="), ";")
 		}
 	}
 
@@ -83,15 +211,91 @@ func parseFieldTags(f reflect.StructField) fieldTags {
 func (g *Generator) genTypeEncoder(t reflect.Type, in string, tags fieldTags, indent int) error {
 	ws := strings.Repeat("  ", indent)
 
+	if isGenericOptional(t) {
+		fmt.Fprintln(g.out, ws+"if ("+in+").Set {")
+		if err := g.genTypeEncoder(t.Field(0).Type, "("+in+").Value", tags, indent+1); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, ws+"} else {")
+		fmt.Fprintln(g.out, ws+`  out.RawString("null")`)
+		fmt.Fprintln(g.out, ws+"}")
+		return nil
+	}
+	if t == timeType && tags.format != "" {
+		return g.genTimeEncoder(in, tags.format, indent)
+	}
+	if t.Kind() == reflect.Interface && tags.oneOf != "" {
+		return g.genOneOfEncoder(t, in, tags.oneOf, indent)
+	}
+	if tags.format == packedUint32sFormat {
+		if err := checkPackedUint32s(t); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, ws+"out.Uint32sLE([]uint32("+in+"))")
+		return nil
+	}
+	if tags.format == jsonStringFormat && t.Kind() != reflect.Ptr {
+		return g.genJSONStringEncoder(t, in, tags, indent)
+	}
+	if tags.format == rleFormat && t.Kind() != reflect.Ptr {
+		return g.genRLEEncoder(t, in, tags, indent)
+	}
+
+	// json.RawMessage is written as is, without a call through json.Marshaler interface.
+	if t == rawMessageType {
+		fmt.Fprintln(g.out, ws+"out.Raw("+in+", nil)")
+		return nil
+	}
+
+	if codec, ok := g.typeCodecs[fullTypeName(t)]; ok {
+		if _, ok := int128Codecs[codec]; ok {
+			return g.genInt128Encoder(t, in, codec, indent)
+		}
+		return g.genTextCodecEncoder(t, in, codec, indent)
+	}
+
+	enumIface := reflect.TypeOf((*easyjson.Enum)(nil)).Elem()
+	if reflect.PtrTo(t).Implements(enumIface) {
+		in = g.addressableValue(t, enumIface, in, indent)
+		fmt.Fprintln(g.out, ws+"out.String(("+in+").EnumLabel())")
+		g.closeAddressableValue(t, enumIface, indent)
+		return nil
+	}
+
+	if t == errorType {
+		fmt.Fprintln(g.out, ws+"if "+in+" == nil {")
+		fmt.Fprintln(g.out, ws+`  out.RawString("null")`)
+		fmt.Fprintln(g.out, ws+"} else {")
+		fmt.Fprintln(g.out, ws+"  out.String(("+in+").Error())")
+		fmt.Fprintln(g.out, ws+"}")
+		return nil
+	}
+
 	marshalerIface := reflect.TypeOf((*easyjson.Marshaler)(nil)).Elem()
 	if reflect.PtrTo(t).Implements(marshalerIface) {
+		in = g.addressableValue(t, marshalerIface, in, indent)
 		fmt.Fprintln(g.out, ws+"("+in+").MarshalEasyJSON(out)")
+		g.closeAddressableValue(t, marshalerIface, indent)
 		return nil
 	}
 
 	marshalerIface = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
 	if reflect.PtrTo(t).Implements(marshalerIface) {
+		in = g.addressableValue(t, marshalerIface, in, indent)
 		fmt.Fprintln(g.out, ws+"out.Raw( ("+in+").MarshalJSON() )")
+		g.closeAddressableValue(t, marshalerIface, indent)
+		return nil
+	}
+
+	marshalerIface = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	if g.isBinaryMarshaler(t) {
+		in = g.addressableValue(t, marshalerIface, in, indent)
+		fmt.Fprintln(g.out, ws+"if data, err := ("+in+").MarshalBinary(); err != nil {")
+		fmt.Fprintln(g.out, ws+"  out.Raw(nil, err)")
+		fmt.Fprintln(g.out, ws+"} else {")
+		fmt.Fprintln(g.out, ws+"  out.Base64Bytes(data)")
+		fmt.Fprintln(g.out, ws+"}")
+		g.closeAddressableValue(t, marshalerIface, indent)
 		return nil
 	}
 
@@ -99,11 +303,307 @@ func (g *Generator) genTypeEncoder(t reflect.Type, in string, tags fieldTags, in
 	return err
 }
 
+// genOneOfEncoder generates code that encodes a value of a sealed interface type as an object
+// with a single key naming the variant, e.g. {"circle":{"R":1}}. A nil value is output as null,
+// a value of a type that is not a variant is an error. The variants are marshaled with their
+// easyjson.Marshaler implementations.
+func (g *Generator) genOneOfEncoder(t reflect.Type, in, tag string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	variants, err := parseOneOf(tag)
+	if err != nil {
+		return fmt.Errorf("type %v: %v", t, err)
+	}
+	tmpVar := g.uniqueVarName()
+
+	fmt.Fprintln(g.out, ws+"switch "+tmpVar+" := ("+in+").(type) {")
+	fmt.Fprintln(g.out, ws+"case nil:")
+	fmt.Fprintln(g.out, ws+`  out.RawString("null")`)
+	for _, v := range variants {
+		fmt.Fprintln(g.out, ws+"case "+g.oneOfType(t, v)+":")
+		fmt.Fprintf(g.out, ws+"  out.RawString(%q)\n", "{"+strconv.Quote(v.key)+":")
+		if v.ptr {
+			fmt.Fprintln(g.out, ws+"  if "+tmpVar+" == nil {")
+			fmt.Fprintln(g.out, ws+`    out.RawString("null")`)
+			fmt.Fprintln(g.out, ws+"  } else {")
+			fmt.Fprintln(g.out, ws+"    "+tmpVar+".MarshalEasyJSON(out)")
+			fmt.Fprintln(g.out, ws+"  }")
+		} else {
+			fmt.Fprintln(g.out, ws+"  "+tmpVar+".MarshalEasyJSON(out)")
+		}
+		fmt.Fprintln(g.out, ws+"  out.RawByte('}')")
+	}
+	fmt.Fprintln(g.out, ws+"default:")
+	fmt.Fprintf(g.out, ws+"  out.Raw(nil, %v.Errorf(\"can't encode %%T as a variant of %v\", %v))\n", g.pkgAlias("fmt"), g.getType(t), tmpVar)
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// packedUint32sFormat is the format of []uint32 values output as a base64 string of the
+// integers packed in little-endian order.
+const packedUint32sFormat = "base64le_u32"
+
+// checkPackedUint32s verifies that t is a slice of uint32, as expected by packedUint32sFormat.
+func checkPackedUint32s(t reflect.Type) error {
+	if t.Kind() != reflect.Slice || t.Elem() != reflect.TypeOf(uint32(0)) {
+		return fmt.Errorf("type %v with format=%v must be a []uint32", t, packedUint32sFormat)
+	}
+	return nil
+}
+
+// rleFormat is the format of integer slices output run-length encoded as an array of
+// [value,count] pairs, e.g. [[0,1000],[1,1]] for 1000 zeros followed by a one.
+const rleFormat = "rle"
+
+func checkRLE(t reflect.Type) error {
+	if t.Kind() != reflect.Slice || !isInteger(t.Elem()) {
+		return fmt.Errorf("type %v with format=%v must be a slice of integers", t, rleFormat)
+	}
+	return nil
+}
+
+// genRLEEncoder generates code that outputs the runs of equal elements of an integer slice.
+func (g *Generator) genRLEEncoder(t reflect.Type, in string, tags fieldTags, indent int) error {
+	if err := checkRLE(t); err != nil {
+		return err
+	}
+	ws := strings.Repeat("  ", indent)
+	iVar := g.uniqueVarName()
+	jVar := g.uniqueVarName()
+
+	fmt.Fprintln(g.out, ws+"out.RawByte('[')")
+	fmt.Fprintln(g.out, ws+"for "+iVar+" := 0; "+iVar+" < len("+in+"); {")
+	fmt.Fprintln(g.out, ws+"  "+jVar+" := "+iVar+" + 1")
+	fmt.Fprintln(g.out, ws+"  for "+jVar+" < len("+in+") && ("+in+")["+jVar+"] == ("+in+")["+iVar+"] {")
+	fmt.Fprintln(g.out, ws+"    "+jVar+"++")
+	fmt.Fprintln(g.out, ws+"  }")
+	fmt.Fprintln(g.out, ws+"  if "+iVar+" > 0 {")
+	fmt.Fprintln(g.out, ws+"    out.RawByte(',')")
+	fmt.Fprintln(g.out, ws+"  }")
+	fmt.Fprintln(g.out, ws+"  out.RawByte('[')")
+	tags.format = ""
+	if err := g.genTypeEncoder(t.Elem(), "("+in+")["+iVar+"]", tags, indent+1); err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, ws+"  out.RawByte(',')")
+	fmt.Fprintln(g.out, ws+"  out.Int("+jVar+" - "+iVar+")")
+	fmt.Fprintln(g.out, ws+"  out.RawByte(']')")
+	fmt.Fprintln(g.out, ws+"  "+iVar+" = "+jVar)
+	fmt.Fprintln(g.out, ws+"}")
+	fmt.Fprintln(g.out, ws+"out.RawByte(']')")
+	return nil
+}
+
+// hexFormat is the format of integers output as a string with a 0x-prefixed hex number, e.g.
+// "0xff" or "-0x1f".
+const hexFormat = "hex"
+
+// groupedFormat is the format of integers output as a string with the digits grouped by
+// thousands with commas, e.g. "1,234,567".
+const groupedFormat = "grouped"
+
+// padFormatPrefix is the prefix of the format of integers output as a string zero-padded to the
+// width following it, e.g. "00000042" for format=pad:8, as expected by fixed-width consumers.
+const padFormatPrefix = "pad:"
+
+// padWidth returns the width of a format=pad:<width> tag, or 0 if the format is another one.
+func padWidth(format string) (int, error) {
+	if !strings.HasPrefix(format, padFormatPrefix) {
+		return 0, nil
+	}
+	width, err := strconv.Atoi(strings.TrimPrefix(format, padFormatPrefix))
+	if err != nil || width <= 0 {
+		return 0, fmt.Errorf("bad format=%v: expected a positive width", format)
+	}
+	return width, nil
+}
+
+// jsonStringFormat is the format of values encoded as a string containing their JSON document,
+// for APIs double-encoding nested objects. A nil pointer is still encoded as null.
+const jsonStringFormat = "jsonstring"
+
+// genJSONStringEncoder generates code that encodes in into a separate writer, outputting the
+// resulting document as a string.
+func (g *Generator) genJSONStringEncoder(t reflect.Type, in string, tags fieldTags, indent int) error {
+	ws := strings.Repeat("  ", indent)
+	outerVar := g.uniqueVarName()
+
+	fmt.Fprintln(g.out, ws+"{")
+	fmt.Fprintln(g.out, ws+"  "+outerVar+" := out")
+	fmt.Fprintln(g.out, ws+"  out := &jwriter.Writer{ASCIIOnly: "+outerVar+".ASCIIOnly, Canonical: "+outerVar+".Canonical}")
+	tags.format = ""
+	if err := g.genTypeEncoder(t, in, tags, indent+1); err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, ws+"  "+outerVar+".StringDocument(out)")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// timeMethods are methods of time.Time returning a timestamp for the supported formats.
+var timeMethods = map[string]string{
+	"unix":      "Unix",
+	"unixmilli": "UnixMilli",
+	"unixnano":  "UnixNano",
+}
+
+// genTimeEncoder generates code that outputs a time.Time as an integer timestamp. The zero time
+// is output as 0, since it is out of range for unixnano.
+func (g *Generator) genTimeEncoder(in, format string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	method, ok := timeMethods[format]
+	if !ok {
+		return fmt.Errorf("unknown time format %q: only unix, unixmilli and unixnano are supported", format)
+	}
+	fmt.Fprintln(g.out, ws+"if ("+in+").IsZero() {")
+	fmt.Fprintln(g.out, ws+"  out.Int64(0)")
+	fmt.Fprintln(g.out, ws+"} else {")
+	fmt.Fprintln(g.out, ws+"  out.Int64(("+in+")."+method+"())")
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// genInt128Encoder generates code that encodes a 128-bit integer type with Hi and Lo words as a
+// string with a decimal number.
+func (g *Generator) genInt128Encoder(t reflect.Type, in, codec string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	if err := checkInt128Type(t, codec); err != nil {
+		return err
+	}
+
+	method := "Uint128Str"
+	if codec == "int128" {
+		method = "Int128Str"
+	}
+	fmt.Fprintln(g.out, ws+"out."+method+"(("+in+").Hi, ("+in+").Lo)")
+	return nil
+}
+
+// genTextCodecEncoder generates code that encodes in using its encoding.TextMarshaler
+// implementation, outputting the text as a string or as a number literal depending on the codec
+// registered for the type.
+func (g *Generator) genTextCodecEncoder(t reflect.Type, in, codec string, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	var write string
+	switch codec {
+	case "string":
+		write = "out.String(string(data))"
+	case "number":
+		write = "out.RawNumber(data)"
+	default:
+		return fmt.Errorf("unknown codec %q for type %v: supported codecs are string, number, uint128 and int128", codec, t)
+	}
+
+	marshalerIface := reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	if !reflect.PtrTo(t).Implements(marshalerIface) {
+		return fmt.Errorf("type %v mapped to %v codec does not implement encoding.TextMarshaler", t, codec)
+	}
+
+	in = g.addressableValue(t, marshalerIface, in, indent)
+	fmt.Fprintln(g.out, ws+"if data, err := ("+in+").MarshalText(); err != nil {")
+	fmt.Fprintln(g.out, ws+"  out.Raw(nil, err)")
+	fmt.Fprintln(g.out, ws+"} else {")
+	fmt.Fprintln(g.out, ws+"  "+write)
+	fmt.Fprintln(g.out, ws+"}")
+	g.closeAddressableValue(t, marshalerIface, indent)
+	return nil
+}
+
+// textMarshalerType is a type of encoding.TextMarshaler interface.
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// checkMapKey returns an error if maps with keys of type t can't be en-/decoded. Like in
+// encoding/json, keys are either strings, or are represented by the text they marshal to, e.g.
+// RFC 3339 strings for time.Time.
+func checkMapKey(t reflect.Type) error {
+	if t.Kind() == reflect.String ||
+		t.Implements(textMarshalerType) && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return nil
+	}
+	return fmt.Errorf("map type %v not supported: only string keys and keys implementing encoding.TextMarshaler and encoding.TextUnmarshaler are allowed", t)
+}
+
+// genMapKeyText generates code that marshals the key of a map entry iterated over with the
+// <tmpVar>Name variable, returning the expression of the key string.
+func (g *Generator) genMapKeyText(t reflect.Type, tmpVar string, indent int) string {
+	if t.Kind() == reflect.String {
+		return "string(" + tmpVar + "Name)"
+	}
+	ws := strings.Repeat("  ", indent)
+	fmt.Fprintln(g.out, ws+tmpVar+"Text, "+tmpVar+"Err := "+tmpVar+"Name.MarshalText()")
+	fmt.Fprintln(g.out, ws+"if "+tmpVar+"Err != nil {")
+	fmt.Fprintln(g.out, ws+"  out.Raw(nil, "+tmpVar+"Err)")
+	fmt.Fprintln(g.out, ws+"}")
+	return "string(" + tmpVar + "Text)"
+}
+
+// addressableValue copies in into a local variable if the marshaler method of t has a pointer
+// receiver, since in may be not addressable (e.g. a map value). The variable lives in a block
+// that has to be closed with closeAddressableValue.
+func (g *Generator) addressableValue(t, iface reflect.Type, in string, indent int) string {
+	if t.Implements(iface) {
+		return in
+	}
+	ws := strings.Repeat("  ", indent)
+	tmpVar := g.uniqueVarName()
+	fmt.Fprintln(g.out, ws+"{")
+	fmt.Fprintln(g.out, ws+"  "+tmpVar+" := "+in)
+	return tmpVar
+}
+
+// closeAddressableValue closes the block opened by addressableValue.
+func (g *Generator) closeAddressableValue(t, iface reflect.Type, indent int) {
+	if !t.Implements(iface) {
+		fmt.Fprintln(g.out, strings.Repeat("  ", indent)+"}")
+	}
+}
+
 // genTypeEncoderNoCheck generates code that encodes in of type t into the writer.
 func (g *Generator) genTypeEncoderNoCheck(t reflect.Type, in string, tags fieldTags, indent int) error {
 	ws := strings.Repeat("  ", indent)
 
 	// Check whether type is primitive, needs to be done after interface check.
+	if tags.format == hexFormat && isInteger(t) {
+		if isUnsigned(t) {
+			fmt.Fprintln(g.out, ws+"out.UintHexStr(uint64("+in+"))")
+		} else {
+			fmt.Fprintln(g.out, ws+"out.IntHexStr(int64("+in+"))")
+		}
+		return nil
+	}
+	if tags.format == groupedFormat && isInteger(t) {
+		if isUnsigned(t) {
+			fmt.Fprintln(g.out, ws+"out.UintGroupedStr(uint64("+in+"))")
+		} else {
+			fmt.Fprintln(g.out, ws+"out.IntGroupedStr(int64("+in+"))")
+		}
+		return nil
+	}
+	if width, err := padWidth(tags.format); err != nil {
+		return err
+	} else if width > 0 && isInteger(t) {
+		if isUnsigned(t) {
+			fmt.Fprintf(g.out, ws+"out.UintPaddedStr(uint64(%v), %d)\n", in, width)
+		} else {
+			fmt.Fprintf(g.out, ws+"out.IntPaddedStr(int64(%v), %d)\n", in, width)
+		}
+		return nil
+	}
+	if tags.maxLen != "" && t.Kind() == reflect.String {
+		max, err := strconv.Atoi(tags.maxLen)
+		if err != nil || max < 0 {
+			return fmt.Errorf("bad maxlen=%v: expected a number of runes", tags.maxLen)
+		}
+		fmt.Fprintf(g.out, ws+"out.StringTruncated(string(%v), %d)\n", in, max)
+		return nil
+	}
+	if tags.format == "intbool" && t.Kind() == reflect.Bool {
+		fmt.Fprintln(g.out, ws+"out.IntBool(bool("+in+"))")
+		return nil
+	}
 	if enc := primitiveStringEncoders[t.Kind()]; enc != "" && tags.asString {
 		fmt.Fprintf(g.out, ws+enc+"\n", in)
 		return nil
@@ -146,33 +646,66 @@ func (g *Generator) genTypeEncoderNoCheck(t reflect.Type, in string, tags fieldT
 
 	case reflect.Map:
 		key := t.Key()
-		if key.Kind() != reflect.String {
-			return fmt.Errorf("map type %v not supported: only string keys are allowed", key)
+		if err := checkMapKey(key); err != nil {
+			return err
 		}
 		tmpVar := g.uniqueVarName()
 
-		fmt.Fprintln(g.out, ws+"if "+in+" == nil {")
-		fmt.Fprintln(g.out, ws+"  out.RawString(`null`)")
-		fmt.Fprintln(g.out, ws+"} else {")
+		var skip string
+		if tags.valueOmitEmpty {
+			if check := g.notEmptyCheck(t.Elem(), tmpVar+"Value"); check != "true" {
+				skip = "if !(" + check + ") { continue }"
+			}
+			tags.valueOmitEmpty = false
+		}
+
+		if g.nilAsEmpty {
+			fmt.Fprintln(g.out, ws+"{")
+		} else {
+			fmt.Fprintln(g.out, ws+"if "+in+" == nil {")
+			fmt.Fprintln(g.out, ws+"  out.RawString(`null`)")
+			fmt.Fprintln(g.out, ws+"} else {")
+		}
+		if g.canonical {
+			fmt.Fprintln(g.out, ws+"  "+tmpVar+"Map := out.SortedMapStartFunc(jwriter.CanonicalLess)")
+			fmt.Fprintln(g.out, ws+"  for "+tmpVar+"Name, "+tmpVar+"Value := range "+in+" {")
+			if skip != "" {
+				fmt.Fprintln(g.out, ws+"    "+skip)
+			}
+			name := g.genMapKeyText(key, tmpVar, indent+2)
+			fmt.Fprintln(g.out, ws+"    "+tmpVar+"Map.Key("+name+")")
+
+			if err := g.genTypeEncoder(t.Elem(), tmpVar+"Value", tags, indent+2); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(g.out, ws+"  }")
+			fmt.Fprintln(g.out, ws+"  "+tmpVar+"Map.End()")
+			fmt.Fprintln(g.out, ws+"}")
+			return nil
+		}
 		fmt.Fprintln(g.out, ws+"  out.RawByte('{')")
 		fmt.Fprintln(g.out, ws+"  "+tmpVar+"First := true")
 		fmt.Fprintln(g.out, ws+"  for "+tmpVar+"Name, "+tmpVar+"Value := range "+in+" {")
+		if skip != "" {
+			fmt.Fprintln(g.out, ws+"    "+skip)
+		}
 		fmt.Fprintln(g.out, ws+"    if !"+tmpVar+"First { out.RawByte(',') }")
 		fmt.Fprintln(g.out, ws+"    "+tmpVar+"First = false")
-		fmt.Fprintln(g.out, ws+"    out.String(string("+tmpVar+"Name))")
+		name := g.genMapKeyText(key, tmpVar, indent+2)
+		fmt.Fprintln(g.out, ws+"    out.String("+name+")")
 		fmt.Fprintln(g.out, ws+"    out.RawByte(':')")
 
-		g.genTypeEncoder(t.Elem(), tmpVar+"Value", tags, indent+2)
+		if err := g.genTypeEncoder(t.Elem(), tmpVar+"Value", tags, indent+2); err != nil {
+			return err
+		}
 
 		fmt.Fprintln(g.out, ws+"  }")
 		fmt.Fprintln(g.out, ws+"  out.RawByte('}')")
 		fmt.Fprintln(g.out, ws+"}")
 
 	case reflect.Interface:
-		if t.NumMethod() != 0 {
-			return fmt.Errorf("interface type %v not supported: only interface{} is allowed", t)
-		}
-		fmt.Fprintln(g.out, ws+"out.Raw(json.Marshal("+in+"))")
+		fmt.Fprintln(g.out, ws+"out.Interface("+in+")")
 
 	default:
 		return fmt.Errorf("don't know how to encode %v", t)
@@ -206,6 +739,42 @@ func (g *Generator) notEmptyCheck(t reflect.Type, v string) string {
 	}
 }
 
+// notNullCheck returns a condition that v is not encoded as null, or an empty string if values
+// of type t are never null, or are output by a marshaler or a codec that the generator can't tell
+// about.
+func (g *Generator) notNullCheck(t reflect.Type, v string) string {
+	if t == rawMessageType {
+		return "len(" + v + ") != 0"
+	}
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface {
+		if _, ok := g.typeCodecs[fullTypeName(t)]; ok ||
+			reflect.PtrTo(t).Implements(reflect.TypeOf((*easyjson.Marshaler)(nil)).Elem()) ||
+			reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) ||
+			g.isBinaryMarshaler(t) {
+			return ""
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return v + " != nil"
+	case reflect.Map:
+		if !g.nilAsEmpty {
+			return v + " != nil"
+		}
+	}
+	return ""
+}
+
+// jsonKey returns a JSON object key for the field name followed by a colon. Escaping is done at
+// generation time, so that the key can be output as a raw string constant.
+func jsonKey(name string, canonical bool) string {
+	w := jwriter.Writer{Canonical: canonical}
+	w.String(name)
+	w.RawByte(':')
+	return string(w.Buffer.BuildBytes())
+}
+
 func (g *Generator) genStructFieldEncoder(t reflect.Type, f reflect.StructField) error {
 	jsonName := g.fieldNamer.GetJSONFieldName(t, f)
 	tags := parseFieldTags(f)
@@ -213,21 +782,152 @@ func (g *Generator) genStructFieldEncoder(t reflect.Type, f reflect.StructField)
 	if tags.omit {
 		return nil
 	}
-	if !tags.omitEmpty && !g.omitEmpty || tags.noOmitEmpty {
+	if tags.inline {
+		return g.genInlineMapEncoder(f, false)
+	}
+	omitEmpty := (tags.omitEmpty || g.omitEmpty) && !tags.noOmitEmpty
+	keyExpr := fieldKeyExpr(t, f, "in")
+	if g.flattenDotted && g.isFlattenable(f.Type) {
+		g.genFlatFieldEncoder(f, jsonName, keyExpr, omitEmpty || g.omitNull)
+		return nil
+	}
+
+	var check string
+	if omitEmpty || isGenericOptional(f.Type) {
+		check = g.notEmptyCheck(f.Type, "in."+f.Name)
+	} else if g.omitNull {
+		check = g.notNullCheck(f.Type, "in."+f.Name)
+	}
+	if check == "" {
 		fmt.Fprintln(g.out, "  if !first { out.RawByte(',') }")
 		fmt.Fprintln(g.out, "  first = false")
-		fmt.Fprintf(g.out, "  out.RawString(%q)\n", strconv.Quote(jsonName)+":")
+		g.genFieldKey(jsonName, keyExpr, 1)
 		return g.genTypeEncoder(f.Type, "in."+f.Name, tags, 1)
 	}
 
-	fmt.Fprintln(g.out, "  if", g.notEmptyCheck(f.Type, "in."+f.Name), "{")
+	fmt.Fprintln(g.out, "  if", check, "{")
 	fmt.Fprintln(g.out, "    if !first { out.RawByte(',') }")
 	fmt.Fprintln(g.out, "    first = false")
 
-	fmt.Fprintf(g.out, "    out.RawString(%q)\n", strconv.Quote(jsonName)+":")
+	g.genFieldKey(jsonName, keyExpr, 2)
 	if err := g.genTypeEncoder(f.Type, "in."+f.Name, tags, 2); err != nil {
 		return err
 	}
+	fmt.Fprintln(g.out, "  } else if out.Debug {")
+	fmt.Fprintf(g.out, "    out.SkipField(%q)\n", jsonName)
+	fmt.Fprintln(g.out, "  }")
+	return nil
+}
+
+// genFieldKey generates code that outputs an object key, prefixed with the path of the parent
+// fields if the output is flattened. The key is given by keyExpr if it is not empty, and it is
+// the static jsonName otherwise.
+func (g *Generator) genFieldKey(jsonName, keyExpr string, indent int) {
+	ws := strings.Repeat("  ", indent)
+	switch {
+	case keyExpr != "" && g.flattenDotted:
+		fmt.Fprintln(g.out, ws+"out.ObjectKey(prefix, "+keyExpr+")")
+	case keyExpr != "":
+		fmt.Fprintln(g.out, ws+"out.String("+keyExpr+")")
+		fmt.Fprintln(g.out, ws+"out.RawByte(':')")
+	case g.flattenDotted:
+		fmt.Fprintf(g.out, ws+"out.ObjectKey(prefix, %q)\n", jsonName)
+	default:
+		fmt.Fprintf(g.out, ws+"out.RawString(%q)\n", jsonKey(jsonName, g.canonical))
+	}
+}
+
+// fieldKeyExpr returns an expression computing the JSON key of the field at runtime with the
+// FieldJSONKey method of recv, if the struct type implements easyjson.FieldKeyer, or an empty
+// string if the key is static.
+func fieldKeyExpr(t reflect.Type, f reflect.StructField, recv string) string {
+	if !reflect.PtrTo(t).Implements(reflect.TypeOf((*easyjson.FieldKeyer)(nil)).Elem()) {
+		return ""
+	}
+	return fmt.Sprintf("%v.FieldJSONKey(%q)", recv, f.Name)
+}
+
+// isFlattenable returns true if fields of a struct (or a pointer to struct) type can be output
+// with dotted keys instead of a nested object, i.e. the type has no custom marshalers. Marshalers
+// generated for the type by this generator do not count.
+func (g *Generator) isFlattenable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || isGenericOptional(t) {
+		return false
+	}
+	return g.marshallers[t] ||
+		!reflect.PtrTo(t).Implements(reflect.TypeOf((*easyjson.Marshaler)(nil)).Elem()) &&
+			!reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) &&
+			!g.isBinaryMarshaler(t)
+}
+
+// isBinaryMarshaler returns true if values of t are encoded as base64 strings with the
+// encoding.BinaryMarshaler implementation of the type, which is the case with BinaryMarshalers
+// unless the type implements encoding.TextMarshaler as well, e.g. url.URL or netip.Addr.
+func (g *Generator) isBinaryMarshaler(t reflect.Type) bool {
+	return g.binaryMarshalers &&
+		reflect.PtrTo(t).Implements(reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()) &&
+		!reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+// genFlatFieldEncoder generates code that outputs the fields of a nested struct with keys
+// prefixed by the field name and a dot. A nil pointer is output as null.
+func (g *Generator) genFlatFieldEncoder(f reflect.StructField, jsonName, keyExpr string, omitEmpty bool) {
+	in := "in." + f.Name
+	t := f.Type
+	indent := "  "
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		fmt.Fprintln(g.out, "  if "+in+" == nil {")
+		if !omitEmpty {
+			fmt.Fprintln(g.out, "    if !first { out.RawByte(',') }")
+			fmt.Fprintln(g.out, "    first = false")
+			g.genFieldKey(jsonName, keyExpr, 2)
+			fmt.Fprintln(g.out, `    out.RawString("null")`)
+		}
+		fmt.Fprintln(g.out, "  } else {")
+		in, indent = "*"+in, "    "
+	}
+
+	g.addType(t)
+	prefix := strconv.Quote(jsonName + ".")
+	if keyExpr != "" {
+		prefix = keyExpr + `+"."`
+	}
+	fmt.Fprintf(g.out, indent+"first = %v(out, %v, prefix+%v, first)\n", g.functionName("flatEncode", t), in, prefix)
+
+	if f.Type.Kind() == reflect.Ptr {
+		fmt.Fprintln(g.out, "  }")
+	}
+}
+
+// genInlineMapEncoder generates code that outputs entries of a map field tagged with 'inline' as
+// fields of the parent object. If filtered, only the keys from the include set are output.
+func (g *Generator) genInlineMapEncoder(f reflect.StructField, filtered bool) error {
+	if f.Type.Kind() != reflect.Map || f.Type.Key().Kind() != reflect.String {
+		return fmt.Errorf("inline field %v must be a map with string keys", f.Name)
+	}
+	tmpVar := g.uniqueVarName()
+
+	fmt.Fprintln(g.out, "  for "+tmpVar+"Name, "+tmpVar+"Value := range in."+f.Name+" {")
+	if filtered {
+		fmt.Fprintln(g.out, "    if include != nil && !include[string("+tmpVar+"Name)] { continue }")
+	}
+	fmt.Fprintln(g.out, "    if !first { out.RawByte(',') }")
+	fmt.Fprintln(g.out, "    first = false")
+	if g.flattenDotted {
+		fmt.Fprintln(g.out, "    out.ObjectKey(prefix, string("+tmpVar+"Name))")
+	} else {
+		fmt.Fprintln(g.out, "    out.String(string("+tmpVar+"Name))")
+		fmt.Fprintln(g.out, "    out.RawByte(':')")
+	}
+
+	if err := g.genTypeEncoder(f.Type.Elem(), tmpVar+"Value", fieldTags{}, 2); err != nil {
+		return err
+	}
 	fmt.Fprintln(g.out, "  }")
 	return nil
 }
@@ -236,9 +936,34 @@ func (g *Generator) genEncoder(t reflect.Type) error {
 	switch t.Kind() {
 	case reflect.Slice:
 		return g.genSliceEncoder(t)
+	case reflect.Struct:
+		if err := g.genStructEncoder(t); err != nil {
+			return err
+		}
+		if g.mergePatches {
+			return g.genStructMergePatchEncoder(t)
+		}
+		return nil
 	default:
-		return g.genStructEncoder(t)
+		return g.genPrimitiveEncoder(t)
+	}
+}
+
+func (g *Generator) genPrimitiveEncoder(t reflect.Type) error {
+	if primitiveEncoders[t.Kind()] == "" {
+		return fmt.Errorf("cannot generate encoder/decoder for %v, not a primitive type", t)
 	}
+
+	fname := g.getEncoderName(t)
+	typ := g.getType(t)
+
+	fmt.Fprintln(g.out, "func "+fname+"(out *jwriter.Writer, in "+typ+") {")
+	err := g.genTypeEncoderNoCheck(t, "in", fieldTags{}, 1)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, "}")
+	return nil
 }
 
 func (g *Generator) genSliceEncoder(t reflect.Type) error {
@@ -260,54 +985,520 @@ func (g *Generator) genSliceEncoder(t reflect.Type) error {
 
 func (g *Generator) genStructEncoder(t reflect.Type) error {
 	if t.Kind() != reflect.Struct {
-		return fmt.Errorf("cannot generate encoder/decoder for %v, not a struct type")
+		return fmt.Errorf("cannot generate encoder/decoder for %v, not a struct type", t)
 	}
 
 	fname := g.getEncoderName(t)
 	typ := g.getType(t)
 
+	if g.flattenDotted {
+		flatName := g.functionName("flatEncode", t)
+
+		fmt.Fprintln(g.out, "func "+fname+"(out *jwriter.Writer, in "+typ+") {")
+		fmt.Fprintln(g.out, "  out.RawByte('{')")
+		fmt.Fprintln(g.out, "  "+flatName+"(out, in, \"\", true)")
+		fmt.Fprintln(g.out, "  out.RawByte('}')")
+		fmt.Fprintln(g.out, "}")
+
+		// The flat encoder outputs the fields with keys prefixed by the path without braces,
+		// returning whether no fields were output yet.
+		fmt.Fprintln(g.out, "func "+flatName+"(out *jwriter.Writer, in "+typ+", prefix string, first bool) bool {")
+		if err := g.genStructFieldsEncoder(t, false); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, "  return first")
+		fmt.Fprintln(g.out, "}")
+		return nil
+	}
+
 	fmt.Fprintln(g.out, "func "+fname+"(out *jwriter.Writer, in "+typ+") {")
+	if err := g.genStructEncoderBody(t, false); err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, "}")
+
+	return nil
+}
+
+// genStructFilteredEncoder generates an encoder that only outputs the top-level fields with JSON
+// names from the include set, or all fields if the set is nil.
+func (g *Generator) genStructFilteredEncoder(t reflect.Type) error {
+	fname := g.functionName("encodeFiltered", t)
+	typ := g.getType(t)
+
+	fmt.Fprintln(g.out, "func "+fname+"(out *jwriter.Writer, in "+typ+", include map[string]bool) {")
+	if err := g.genStructEncoderBody(t, true); err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, "}")
+
+	return nil
+}
+
+// genStructEncoderBody generates code that encodes the fields of a struct, optionally checking
+// each field against the include set.
+func (g *Generator) genStructEncoderBody(t reflect.Type, filtered bool) error {
 	fmt.Fprintln(g.out, "  out.RawByte('{')")
 	fmt.Fprintln(g.out, "  first := true")
 	fmt.Fprintln(g.out, "  _ = first")
+	if g.flattenDotted {
+		fmt.Fprintln(g.out, "  prefix := \"\"")
+		fmt.Fprintln(g.out, "  _ = prefix")
+	}
 
-	fs, err := getStructFields(t)
+	if err := g.genStructFieldsEncoder(t, filtered); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(g.out, "  out.RawByte('}')")
+	return nil
+}
+
+// genStructFieldsEncoder generates code that encodes the fields of a struct without the braces.
+func (g *Generator) genStructFieldsEncoder(t reflect.Type, filtered bool) error {
+	fs, _, err := getStructFields(t)
 	if err != nil {
 		return fmt.Errorf("cannot generate encoder for %v: %v", t, err)
 	}
+	if g.canonical {
+		if reflect.PtrTo(t).Implements(reflect.TypeOf((*easyjson.FieldKeyer)(nil)).Elem()) {
+			return fmt.Errorf("cannot generate encoder for %v: FieldJSONKey method is not supported in canonical mode", t)
+		}
+		if f, _ := getInlineField(fs); f != nil {
+			return fmt.Errorf("cannot generate encoder for %v: inline field %v is not supported in canonical mode", t, f.Name)
+		}
+		fs = append([]reflect.StructField(nil), fs...)
+		sort.SliceStable(fs, func(i, j int) bool {
+			return jwriter.CanonicalLess(g.fieldNamer.GetJSONFieldName(t, fs[i]), g.fieldNamer.GetJSONFieldName(t, fs[j]))
+		})
+	}
 	for _, f := range fs {
-		if err := g.genStructFieldEncoder(t, f); err != nil {
+		tags := parseFieldTags(f)
+		gated := tags.buildTag != "" && !tags.omit
+		if gated {
+			fmt.Fprintf(g.out, "  if %v.BuildTag(%q) {\n", g.pkgAlias(pkgEasyJSON), tags.buildTag)
+		}
+		// Fields promoted through nil embedded pointers are omitted, as in encoding/json.
+		var nilChecks []string
+		for _, p := range embeddedPtrs(t, f, "in") {
+			nilChecks = append(nilChecks, p.selector+" != nil")
+		}
+		if len(nilChecks) > 0 {
+			fmt.Fprintln(g.out, "  if "+strings.Join(nilChecks, " && ")+" {")
+		}
+		if err := g.genStructFieldFilteredEncoder(t, f, tags, filtered); err != nil {
 			return err
 		}
+		if len(nilChecks) > 0 {
+			fmt.Fprintln(g.out, "  }")
+		}
+		if gated {
+			fmt.Fprintln(g.out, "  }")
+		}
 	}
+	return g.genVirtualFieldsEncoder(t, filtered)
+}
 
-	fmt.Fprintln(g.out, "  out.RawByte('}')")
-	fmt.Fprintln(g.out, "}")
+// genVirtualFieldsEncoder generates code that outputs the virtual fields of a struct after the
+// regular ones, with the values returned by their methods.
+func (g *Generator) genVirtualFieldsEncoder(t reflect.Type, filtered bool) error {
+	fields := g.virtualFields[t]
+	if len(fields) > 0 && g.canonical {
+		return fmt.Errorf("cannot generate encoder for %v: virtual fields are not supported in canonical mode", t)
+	}
+
+	for _, f := range fields {
+		m, ok := reflect.PtrTo(t).MethodByName(f.method)
+		if !ok || m.Type.NumIn() != 1 || m.Type.NumOut() != 1 {
+			return fmt.Errorf("cannot generate encoder for %v: virtual field %v needs a %v method with no arguments returning a single value", t, f.key, f.method)
+		}
 
+		ws := "  "
+		if filtered {
+			fmt.Fprintf(g.out, "  if include == nil || include[%q] {\n", f.key)
+			ws = "    "
+		}
+		fmt.Fprintln(g.out, ws+"if !first { out.RawByte(',') }")
+		fmt.Fprintln(g.out, ws+"first = false")
+		g.genFieldKey(f.key, "", len(ws)/2)
+		if err := g.genTypeEncoder(m.Type.Out(0), "in."+f.method+"()", fieldTags{}, len(ws)/2); err != nil {
+			return err
+		}
+		if filtered {
+			fmt.Fprintln(g.out, "  }")
+		}
+	}
 	return nil
 }
 
+// genStructFieldFilteredEncoder generates code that encodes a field, if filtered is set for
+// the MarshalEasyJSONFiltered method, only when the field is in the include set.
+func (g *Generator) genStructFieldFilteredEncoder(t reflect.Type, f reflect.StructField, tags fieldTags, filtered bool) error {
+	if !filtered || tags.omit {
+		return g.genStructFieldEncoder(t, f)
+	}
+
+	if tags.inline {
+		return g.genInlineMapEncoder(f, true)
+	}
+	fmt.Fprintf(g.out, "  if include == nil || include[%q] {\n", g.fieldNamer.GetJSONFieldName(t, f))
+	if err := g.genStructFieldEncoder(t, f); err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, "  }")
+	return nil
+}
+
+// writerOptions returns the fields of the writers created by the generated methods.
+func (g *Generator) writerOptions() string {
+	if g.canonical {
+		return "Canonical: true"
+	}
+	return ""
+}
+
 func (g *Generator) genStructMarshaller(t reflect.Type) error {
-	if t.Kind() != reflect.Struct && t.Kind() != reflect.Slice {
-		return fmt.Errorf("cannot generate encoder/decoder for %v, not a struct/slice type", t)
+	if t.Kind() != reflect.Struct && t.Kind() != reflect.Slice && primitiveEncoders[t.Kind()] == "" {
+		return fmt.Errorf("cannot generate encoder/decoder for %v, not a struct/slice/primitive type", t)
 	}
 
 	fname := g.getEncoderName(t)
 	typ := g.getType(t)
 
-	if !g.noStdMarshalers {
-		fmt.Fprintln(g.out, "// MarshalJSON supports json.Marshaler interface")
-		fmt.Fprintln(g.out, "func (v "+typ+") MarshalJSON() ([]byte, error) {")
-		fmt.Fprintln(g.out, "  w := jwriter.Writer{}")
-		fmt.Fprintln(g.out, "  "+fname+"(&w, v)")
+	// encode returns a statement encoding v with the writer pointer w, which calls the hand-written
+	// MarshalJSON method of the type if it is kept.
+	encode := func(w string) string {
+		if g.keepMarshalJSON[t] {
+			return strings.TrimPrefix(w, "&") + ".Raw(v.MarshalJSON())"
+		}
+		return fname + "(" + w + ", v)"
+	}
+
+	if !g.noStdMarshalers && !g.keepMarshalJSON[t] {
+		if names, ok := g.methodNames[t]; ok {
+			fmt.Fprintln(g.out, "// "+names[0]+" marshals the value to JSON bytes")
+			fmt.Fprintln(g.out, "func (v "+typ+") "+names[0]+"() ([]byte, error) {")
+		} else {
+			fmt.Fprintln(g.out, "// MarshalJSON supports json.Marshaler interface")
+			fmt.Fprintln(g.out, "func (v "+typ+") MarshalJSON() ([]byte, error) {")
+		}
+		fmt.Fprintln(g.out, "  w := jwriter.Writer{"+g.writerOptions()+"}")
+		fmt.Fprintln(g.out, "  "+encode("&w"))
+		fmt.Fprintln(g.out, "  return w.Buffer.BuildBytes(), w.Error")
+		fmt.Fprintln(g.out, "}")
+	}
+
+	if g.ioInterfaces {
+		g.imports["io"] = "io"
+
+		fmt.Fprintln(g.out, "// WriteTo supports io.WriterTo interface")
+		fmt.Fprintln(g.out, "func (v "+typ+") WriteTo(w io.Writer) (int64, error) {")
+		fmt.Fprintln(g.out, "  jw := jwriter.Writer{"+g.writerOptions()+"}")
+		fmt.Fprintln(g.out, "  "+encode("&jw"))
+		fmt.Fprintln(g.out, "  if jw.Error != nil {")
+		fmt.Fprintln(g.out, "    return 0, jw.Error")
+		fmt.Fprintln(g.out, "  }")
+		fmt.Fprintln(g.out, "  n, err := jw.DumpTo(w)")
+		fmt.Fprintln(g.out, "  return int64(n), err")
+		fmt.Fprintln(g.out, "}")
+	}
+
+	if g.sliceMarshalers {
+		name := "Marshal" + t.Name() + "Slice"
+		fmt.Fprintln(g.out, "// "+name+" marshals items to a JSON array, writing all of them into a single writer")
+		fmt.Fprintln(g.out, "// instead of allocating the output of each element separately.")
+		fmt.Fprintln(g.out, "func "+name+"(items []"+typ+") ([]byte, error) {")
+		fmt.Fprintln(g.out, "  w := jwriter.Writer{"+g.writerOptions()+"}")
+		fmt.Fprintln(g.out, "  w.RawByte('[')")
+		fmt.Fprintln(g.out, "  for i, v := range items {")
+		fmt.Fprintln(g.out, "    if i > 0 {")
+		fmt.Fprintln(g.out, "      w.RawByte(',')")
+		fmt.Fprintln(g.out, "    }")
+		fmt.Fprintln(g.out, "    "+encode("&w"))
+		fmt.Fprintln(g.out, "  }")
+		fmt.Fprintln(g.out, "  w.RawByte(']')")
+		fmt.Fprintln(g.out, "  return w.Buffer.BuildBytes(), w.Error")
+		fmt.Fprintln(g.out, "}")
+	}
+
+	if g.mergePatches && t.Kind() == reflect.Struct && !g.keepMarshalJSON[t] {
+		name := "MergePatch" + t.Name()
+		fmt.Fprintln(g.out, "// "+name+" outputs a JSON merge patch (RFC 7386) turning the encoded from value into the")
+		fmt.Fprintln(g.out, "// encoded to value: changed fields are output, and removed ones are set to null.")
+		fmt.Fprintln(g.out, "func "+name+"(from, to "+typ+") ([]byte, error) {")
+		fmt.Fprintln(g.out, "  w := jwriter.Writer{"+g.writerOptions()+"}")
+		fmt.Fprintln(g.out, "  "+g.functionName("mergePatch", t)+"(&w, from, to)")
 		fmt.Fprintln(g.out, "  return w.Buffer.BuildBytes(), w.Error")
 		fmt.Fprintln(g.out, "}")
 	}
 
 	fmt.Fprintln(g.out, "// MarshalEasyJSON supports easyjson.Marshaler interface")
 	fmt.Fprintln(g.out, "func (v "+typ+") MarshalEasyJSON(w *jwriter.Writer) {")
-	fmt.Fprintln(g.out, "  "+fname+"(w, v)")
+	fmt.Fprintln(g.out, "  "+encode("w"))
+	fmt.Fprintln(g.out, "}")
+
+	// The output of a hand-written MarshalJSON can't be filtered by fields.
+	if !g.filteredMarshalers || t.Kind() != reflect.Struct || g.keepMarshalJSON[t] {
+		return nil
+	}
+	if err := g.genStructFilteredEncoder(t); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(g.out, "// MarshalEasyJSONFiltered marshals only the top-level fields with JSON names from the")
+	fmt.Fprintln(g.out, "// include set, nested values are output in full. A nil set includes all fields.")
+	fmt.Fprintln(g.out, "func (v "+typ+") MarshalEasyJSONFiltered(w *jwriter.Writer, include map[string]bool) {")
+	fmt.Fprintln(g.out, "  "+g.functionName("encodeFiltered", t)+"(w, v, include)")
+	fmt.Fprintln(g.out, "}")
+
+	return nil
+}
+
+// genStructMergePatchEncoder generates a function outputting a JSON merge patch of the fields of
+// a struct that differ between the from and the to values. Nested structs without custom
+// marshalers and maps are patched recursively, other values are output in full.
+func (g *Generator) genStructMergePatchEncoder(t reflect.Type) error {
+	fs, _, err := getStructFields(t)
+	if err != nil {
+		return fmt.Errorf("cannot generate merge patch for %v: %v", t, err)
+	}
+
+	fname := g.functionName("mergePatch", t)
+	typ := g.getType(t)
+
+	fmt.Fprintln(g.out, "func "+fname+"(out *jwriter.Writer, from, to "+typ+") {")
+	fmt.Fprintln(g.out, "  out.RawByte('{')")
+	fmt.Fprintln(g.out, "  first := true")
+	fmt.Fprintln(g.out, "  _ = first")
+	for _, f := range fs {
+		tags := parseFieldTags(f)
+		if tags.omit {
+			continue
+		}
+		if tags.inline {
+			return fmt.Errorf("cannot generate merge patch for %v: inline field %v is not supported", t, f.Name)
+		}
+		if tags.buildTag != "" {
+			fmt.Fprintf(g.out, "  if %v.BuildTag(%q) {\n", g.pkgAlias(pkgEasyJSON), tags.buildTag)
+		}
+
+		// Fields promoted through nil embedded pointers are not output, so the field is removed
+		// if the pointer becomes nil, and it is output in full if the pointer is set.
+		var fromChecks, toChecks []string
+		for _, p := range embeddedPtrs(t, f, "") {
+			fromChecks = append(fromChecks, "from"+p.selector+" != nil")
+			toChecks = append(toChecks, "to"+p.selector+" != nil")
+		}
+		if len(fromChecks) == 0 {
+			if err := g.genMergePatchField(t, f, tags, 1); err != nil {
+				return err
+			}
+		} else {
+			fromSet, toSet := strings.Join(fromChecks, " && "), strings.Join(toChecks, " && ")
+			fmt.Fprintln(g.out, "  if ("+fromSet+") && ("+toSet+") {")
+			if err := g.genMergePatchField(t, f, tags, 2); err != nil {
+				return err
+			}
+			fmt.Fprintln(g.out, "  } else if "+toSet+" {")
+			g.genMergePatchKey(t, f, 2)
+			if err := g.genTypeEncoder(f.Type, "to."+f.Name, tags, 2); err != nil {
+				return err
+			}
+			fmt.Fprintln(g.out, "  } else if "+fromSet+" {")
+			g.genMergePatchKey(t, f, 2)
+			fmt.Fprintln(g.out, "    out.RawString(`null`)")
+			fmt.Fprintln(g.out, "  }")
+		}
+
+		if tags.buildTag != "" {
+			fmt.Fprintln(g.out, "  }")
+		}
+	}
+	fmt.Fprintln(g.out, "  out.RawByte('}')")
 	fmt.Fprintln(g.out, "}")
+	return nil
+}
+
+// genMergePatchField generates code that outputs the patch of a struct field if it changed. A
+// field that is no longer output due to omitempty is removed.
+func (g *Generator) genMergePatchField(t reflect.Type, f reflect.StructField, tags fieldTags, indent int) error {
+	ws := strings.Repeat("  ", indent)
+	from, to := "from."+f.Name, "to."+f.Name
+
+	var fromCheck, toCheck string
+	if (tags.omitEmpty || g.omitEmpty) && !tags.noOmitEmpty || isGenericOptional(f.Type) {
+		fromCheck, toCheck = g.notEmptyCheck(f.Type, from), g.notEmptyCheck(f.Type, to)
+	} else if g.omitNull {
+		fromCheck, toCheck = g.notNullCheck(f.Type, from), g.notNullCheck(f.Type, to)
+	}
+
+	fmt.Fprintln(g.out, ws+"if "+g.changedCheck(f.Type, from, to)+" {")
+	if toCheck == "" || toCheck == "true" {
+		g.genMergePatchKey(t, f, indent+1)
+		if err := g.genMergePatchValue(f.Type, from, to, tags, indent+1); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(g.out, ws+"  if "+toCheck+" {")
+		g.genMergePatchKey(t, f, indent+2)
+		if err := g.genMergePatchValue(f.Type, from, to, tags, indent+2); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, ws+"  } else if "+fromCheck+" {")
+		g.genMergePatchKey(t, f, indent+2)
+		fmt.Fprintln(g.out, ws+"    out.RawString(`null`)")
+		fmt.Fprintln(g.out, ws+"  }")
+	}
+	fmt.Fprintln(g.out, ws+"}")
+	return nil
+}
+
+// genMergePatchKey generates code that outputs the key of a struct field in a merge patch.
+func (g *Generator) genMergePatchKey(t reflect.Type, f reflect.StructField, indent int) {
+	ws := strings.Repeat("  ", indent)
+	fmt.Fprintln(g.out, ws+"if !first { out.RawByte(',') }")
+	fmt.Fprintln(g.out, ws+"first = false")
+	if keyExpr := fieldKeyExpr(t, f, "to"); keyExpr != "" {
+		fmt.Fprintln(g.out, ws+"out.String("+keyExpr+")")
+		fmt.Fprintln(g.out, ws+"out.RawByte(':')")
+	} else {
+		fmt.Fprintf(g.out, ws+"out.RawString(%q)\n", jsonKey(g.fieldNamer.GetJSONFieldName(t, f), g.canonical))
+	}
+}
+
+// changedCheck returns an expression that is true if the values of type t differ, comparing the
+// values pointers point to rather than the pointers.
+func (g *Generator) changedCheck(t reflect.Type, from, to string) string {
+	if isSafelyComparable(t) {
+		return from + " != " + to
+	}
+	return "!" + g.pkgAlias("reflect") + ".DeepEqual(" + from + ", " + to + ")"
+}
+
+// isSafelyComparable returns true if values of type t can be compared by value with == without
+// a panic, which is not the case for interfaces holding uncomparable values.
+func isSafelyComparable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return isSafelyComparable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isSafelyComparable(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Map, reflect.Func:
+		return false
+	}
+	return t.Comparable()
+}
+
+// isMergeable returns true if the values of a struct type t are patched field by field.
+func (g *Generator) isMergeable(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !g.keepMarshalJSON[t] && g.isFlattenable(t)
+}
+
+// isPatchedByKeys returns true if the values of type t are patched key by key rather than
+// output in full.
+func (g *Generator) isPatchedByKeys(t reflect.Type, tags fieldTags) bool {
+	if tags.format != "" || tags.oneOf != "" {
+		return false
+	}
+	return g.isMergeable(t) || t.Kind() == reflect.Ptr && g.isMergeable(t.Elem()) || t.Kind() == reflect.Map
+}
+
+// genMergePatchValue generates code that outputs the patch turning from into to, values of type
+// t known to differ.
+func (g *Generator) genMergePatchValue(t reflect.Type, from, to string, tags fieldTags, indent int) error {
+	ws := strings.Repeat("  ", indent)
+
+	switch {
+	case !g.isPatchedByKeys(t, tags):
+		return g.genTypeEncoder(t, to, tags, indent)
+
+	case g.isMergeable(t):
+		g.addType(t)
+		fmt.Fprintln(g.out, ws+g.functionName("mergePatch", t)+"(out, "+from+", "+to+")")
+
+	case t.Kind() == reflect.Ptr && g.isMergeable(t.Elem()):
+		g.addType(t.Elem())
+		fmt.Fprintln(g.out, ws+"switch {")
+		fmt.Fprintln(g.out, ws+"case "+to+" == nil:")
+		fmt.Fprintln(g.out, ws+"  out.RawString(`null`)")
+		fmt.Fprintln(g.out, ws+"case "+from+" == nil:")
+		if err := g.genTypeEncoder(t, to, tags, indent+1); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, ws+"default:")
+		fmt.Fprintln(g.out, ws+"  "+g.functionName("mergePatch", t.Elem())+"(out, *"+from+", *"+to+")")
+		fmt.Fprintln(g.out, ws+"}")
+
+	case t.Kind() == reflect.Map:
+		if err := checkMapKey(t.Key()); err != nil {
+			return err
+		}
+		tmpVar := g.uniqueVarName()
+		oldVar, newVar := tmpVar+"Old", tmpVar+"Value"
 
+		// Entries with empty values are not output with value_omitempty, so they are removed.
+		removed, keep := "ok", "ok && !("+g.changedCheck(t.Elem(), oldVar, newVar)+")"
+		if tags.valueOmitEmpty {
+			oldSet, newSet := g.notEmptyCheck(t.Elem(), oldVar), g.notEmptyCheck(t.Elem(), newVar)
+			removed = "ok && (" + newSet + ") || !(" + oldSet + ")"
+			keep = "!(" + newSet + ") || ok && (" + oldSet + ") && !(" + g.changedCheck(t.Elem(), oldVar, newVar) + ")"
+		}
+
+		fmt.Fprintln(g.out, ws+"switch {")
+		fmt.Fprintln(g.out, ws+"case "+to+" == nil:")
+		fmt.Fprintln(g.out, ws+"  out.RawString(`null`)")
+		fmt.Fprintln(g.out, ws+"case "+from+" == nil:")
+		if err := g.genTypeEncoder(t, to, tags, indent+1); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, ws+"default:")
+		fmt.Fprintln(g.out, ws+"  out.RawByte('{')")
+		fmt.Fprintln(g.out, ws+"  "+tmpVar+"First := true")
+		if tags.valueOmitEmpty {
+			fmt.Fprintln(g.out, ws+"  for "+tmpVar+"Name, "+oldVar+" := range "+from+" {")
+			fmt.Fprintln(g.out, ws+"    "+newVar+", ok := "+to+"["+tmpVar+"Name]")
+		} else {
+			fmt.Fprintln(g.out, ws+"  for "+tmpVar+"Name := range "+from+" {")
+			fmt.Fprintln(g.out, ws+"    _, ok := "+to+"["+tmpVar+"Name]")
+		}
+		fmt.Fprintln(g.out, ws+"    if "+removed+" {")
+		fmt.Fprintln(g.out, ws+"      continue")
+		fmt.Fprintln(g.out, ws+"    }")
+		fmt.Fprintln(g.out, ws+"    if !"+tmpVar+"First { out.RawByte(',') }")
+		fmt.Fprintln(g.out, ws+"    "+tmpVar+"First = false")
+		fmt.Fprintln(g.out, ws+"    out.String("+g.genMapKeyText(t.Key(), tmpVar, indent+2)+")")
+		fmt.Fprintln(g.out, ws+"    out.RawString(`:null`)")
+		fmt.Fprintln(g.out, ws+"  }")
+		fmt.Fprintln(g.out, ws+"  for "+tmpVar+"Name, "+newVar+" := range "+to+" {")
+		fmt.Fprintln(g.out, ws+"    "+oldVar+", ok := "+from+"["+tmpVar+"Name]")
+		fmt.Fprintln(g.out, ws+"    if "+keep+" {")
+		fmt.Fprintln(g.out, ws+"      continue")
+		fmt.Fprintln(g.out, ws+"    }")
+		fmt.Fprintln(g.out, ws+"    if !"+tmpVar+"First { out.RawByte(',') }")
+		fmt.Fprintln(g.out, ws+"    "+tmpVar+"First = false")
+		fmt.Fprintln(g.out, ws+"    out.String("+g.genMapKeyText(t.Key(), tmpVar, indent+2)+")")
+		fmt.Fprintln(g.out, ws+"    out.RawByte(':')")
+		if g.isPatchedByKeys(t.Elem(), fieldTags{}) {
+			fmt.Fprintln(g.out, ws+"    if ok {")
+			if err := g.genMergePatchValue(t.Elem(), oldVar, newVar, fieldTags{}, indent+3); err != nil {
+				return err
+			}
+			fmt.Fprintln(g.out, ws+"    } else {")
+			if err := g.genTypeEncoder(t.Elem(), newVar, fieldTags{}, indent+3); err != nil {
+				return err
+			}
+			fmt.Fprintln(g.out, ws+"    }")
+		} else if err := g.genTypeEncoder(t.Elem(), newVar, fieldTags{}, indent+2); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, ws+"  }")
+		fmt.Fprintln(g.out, ws+"  out.RawByte('}')")
+		fmt.Fprintln(g.out, ws+"}")
+	}
 	return nil
 }
@@ -3,6 +3,8 @@ package gen
 import (
 	"bytes"
 	"fmt"
+	"go/scanner"
+	"go/token"
 	"hash/fnv"
 	"io"
 	"path"
@@ -14,6 +16,11 @@ import (
 
 const pkgWriter = "github.com/mailru/easyjson/jwriter"
 const pkgLexer = "github.com/mailru/easyjson/jlexer"
+const pkgEasyJSON = "github.com/mailru/easyjson"
+const pkgOpt = "github.com/mailru/easyjson/opt"
+
+// defaultHeader is a header comment of the generated file used unless another one is set.
+const defaultHeader = "AUTOGENERATED FILE: easyjson marshaller/unmarshallers."
 
 // FieldNamer defines a policy for generating names for struct fields.
 type FieldNamer interface {
@@ -24,16 +31,34 @@ type FieldNamer interface {
 type Generator struct {
 	out *bytes.Buffer
 
-	pkgName    string
-	pkgPath    string
-	buildTags  string
-	hashString string
+	pkgName         string
+	pkgPath         string
+	buildTags       string
+	buildConstraint string
+	header          string
+	hashString      string
 
 	varCounter int
 
-	noStdMarshalers bool
-	omitEmpty       bool
-	fieldNamer      FieldNamer
+	noStdMarshalers    bool
+	ioInterfaces       bool
+	sliceMarshalers    bool
+	filteredMarshalers bool
+	binaryMarshalers   bool
+	mergePatches       bool
+	quotedNumbers      bool
+	emptyAsZero        bool
+	nilAsEmpty         bool
+	flattenDotted      bool
+	canonical          bool
+	omitEmpty          bool
+	omitNull           bool
+	trackPresence      bool
+	rejectUnsupported  bool
+	fieldNamer         FieldNamer
+
+	// codecs of external types by the full type name, see SetTypeCodec
+	typeCodecs map[string]string
 
 	// package path to local alias map for tracking imports
 	imports map[string]string
@@ -41,6 +66,18 @@ type Generator struct {
 	// types that marshallers were requested for by user
 	marshallers map[reflect.Type]bool
 
+	// custom names of MarshalJSON/UnmarshalJSON methods for the types
+	methodNames map[reflect.Type][2]string
+
+	// types with hand-written MarshalJSON methods used by the generated marshalers
+	keepMarshalJSON map[reflect.Type]bool
+
+	// virtual fields of the types output with the values returned by methods
+	virtualFields map[reflect.Type][]virtualField
+
+	// positions of the struct fields in the source by field name, see SetFieldPosition
+	fieldPositions map[reflect.Type]map[string]string
+
 	// types that encoders were already generated for
 	typesSeen map[reflect.Type]bool
 
@@ -60,10 +97,14 @@ func NewGenerator(filename string) *Generator {
 			pkgLexer:        "jlexer",
 			"encoding/json": "json",
 		},
-		fieldNamer:    DefaultFieldNamer{},
-		marshallers:   make(map[reflect.Type]bool),
-		typesSeen:     make(map[reflect.Type]bool),
-		functionNames: make(map[string]reflect.Type),
+		fieldNamer:      DefaultFieldNamer{},
+		marshallers:     make(map[reflect.Type]bool),
+		methodNames:     make(map[reflect.Type][2]string),
+		keepMarshalJSON: make(map[reflect.Type]bool),
+		virtualFields:   make(map[reflect.Type][]virtualField),
+		fieldPositions:  make(map[reflect.Type]map[string]string),
+		typesSeen:       make(map[reflect.Type]bool),
+		functionNames:   make(map[string]reflect.Type),
 	}
 
 	// Use a file-unique prefix on all auxiliary functions to avoid
@@ -86,6 +127,17 @@ func (g *Generator) SetBuildTags(tags string) {
 	g.buildTags = tags
 }
 
+// SetBuildConstraint sets a //go:build constraint expression to add to the generated file.
+func (g *Generator) SetBuildConstraint(expr string) {
+	g.buildConstraint = expr
+}
+
+// SetHeader sets a header comment of the generated file, each line of the text is output as a
+// separate comment line.
+func (g *Generator) SetHeader(text string) {
+	g.header = text
+}
+
 // SetFieldNamer sets field naming strategy.
 func (g *Generator) SetFieldNamer(n FieldNamer) {
 	g.fieldNamer = n
@@ -102,11 +154,101 @@ func (g *Generator) NoStdMarshalers() {
 	g.noStdMarshalers = true
 }
 
+// IOInterfaces instructs to generate WriteTo/ReadFrom methods implementing io.WriterTo and
+// io.ReaderFrom interfaces.
+func (g *Generator) IOInterfaces() {
+	g.ioInterfaces = true
+}
+
+// SliceMarshalers instructs to generate Marshal<Type>Slice functions marshaling a slice of the
+// type to a JSON array with a single writer.
+func (g *Generator) SliceMarshalers() {
+	g.sliceMarshalers = true
+}
+
+// FilteredMarshalers instructs to generate MarshalEasyJSONFiltered methods of struct types
+// outputting only the top-level fields from a given set.
+func (g *Generator) FilteredMarshalers() {
+	g.filteredMarshalers = true
+}
+
+// BinaryMarshalers instructs to encode values of types implementing encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler, but not the text interfaces, as base64 strings of their binary form.
+func (g *Generator) BinaryMarshalers() {
+	g.binaryMarshalers = true
+}
+
+// MergePatches instructs to generate MergePatch<Type> functions outputting a JSON merge patch
+// (RFC 7386) between two values of a struct type.
+func (g *Generator) MergePatches() {
+	g.mergePatches = true
+}
+
+// AcceptQuotedNumbers instructs to generate decoders accepting numbers enclosed in quotes as well
+// as regular number literals.
+func (g *Generator) AcceptQuotedNumbers() {
+	g.quotedNumbers = true
+}
+
+// EmptyStringAsZero instructs to generate decoders treating an empty string as the zero value
+// for number and bool fields.
+func (g *Generator) EmptyStringAsZero() {
+	g.emptyAsZero = true
+}
+
+// SetTypeCodec registers a codec for the type with the given name qualified with the package
+// path, e.g. "github.com/google/uuid.UUID". The type is encoded with its MarshalText method
+// either as a string ("string" codec) or as a number literal ("number" codec), and decoded with
+// UnmarshalText. The codec takes precedence over the marshaler interfaces of the type.
+func (g *Generator) SetTypeCodec(typeName, codec string) {
+	if g.typeCodecs == nil {
+		g.typeCodecs = make(map[string]string)
+	}
+	g.typeCodecs[typeName] = codec
+}
+
+// Canonical instructs to output JSON canonicalized per RFC 8785 (JCS): struct fields and map
+// entries are ordered by key, and the writers created by the generated methods format numbers
+// and strings canonically (see jwriter.Writer.Canonical).
+func (g *Generator) Canonical() {
+	g.canonical = true
+}
+
+// FlattenDotted instructs to output fields of nested structs in the parent object with dotted
+// keys, e.g. {"user.address.city":"x"} instead of nested objects. Decoding is not affected.
+func (g *Generator) FlattenDotted() {
+	g.flattenDotted = true
+}
+
+// NilAsEmpty instructs to output nil maps as empty objects instead of null. Nil slices are always
+// output as empty arrays.
+func (g *Generator) NilAsEmpty() {
+	g.nilAsEmpty = true
+}
+
 // OmitEmpty triggers `json=",omitempty"` behaviour by default.
 func (g *Generator) OmitEmpty() {
 	g.omitEmpty = true
 }
 
+// OmitNull instructs to skip fields that would be encoded as null, i.e. nil pointers, interfaces
+// and maps, while fields with other zero values are still output.
+func (g *Generator) OmitNull() {
+	g.omitNull = true
+}
+
+// TrackPresence instructs to record the keys of the fields present in the decoded objects to the
+// easyjson.Presence field of the structs, and to generate PresentFields methods returning it.
+func (g *Generator) TrackPresence() {
+	g.trackPresence = true
+}
+
+// RejectUnsupported instructs to fail on fields of types that have no JSON representation
+// (channels, funcs) unless they are tagged with json:"-", instead of skipping them with a warning.
+func (g *Generator) RejectUnsupported() {
+	g.rejectUnsupported = true
+}
+
 // addTypes requests to generate en-/decoding functions for the given type.
 func (g *Generator) addType(t reflect.Type) {
 	if g.typesSeen[t] {
@@ -130,40 +272,127 @@ func (g *Generator) Add(obj interface{}) {
 	g.marshallers[t] = true
 }
 
-// printHeader prints package declaration and imports.
-func (g *Generator) printHeader() {
+// SetMethodNames sets custom names of the generated MarshalJSON/UnmarshalJSON methods for the
+// type of given object, e.g. to avoid a clash with existing methods of the type.
+func (g *Generator) SetMethodNames(obj interface{}, marshal, unmarshal string) {
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	g.methodNames[t] = [2]string{marshal, unmarshal}
+}
+
+// KeepMarshalJSON instructs to use the hand-written MarshalJSON method of the type of given object
+// for marshaling instead of generating one: MarshalEasyJSON outputs its result as is, and no
+// MarshalEasyJSONFiltered method is generated. Unmarshalers are generated as usual.
+func (g *Generator) KeepMarshalJSON(obj interface{}) {
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	g.keepMarshalJSON[t] = true
+}
+
+// virtualField is a key output by the marshalers of a struct with the value returned by a method.
+type virtualField struct {
+	key    string
+	method string
+}
+
+// AddVirtualField adds a virtual field to the type of given object: the marshalers output the
+// value returned by the method with no arguments with the key, e.g. for derived data, and the
+// unmarshalers skip the key.
+func (g *Generator) AddVirtualField(obj interface{}, key, method string) {
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	g.virtualFields[t] = append(g.virtualFields[t], virtualField{key: key, method: method})
+}
+
+// SetFieldPosition sets the position of the declaration of a field of the type of given object,
+// e.g. "file.go:12", reported in the errors about the field.
+func (g *Generator) SetFieldPosition(obj interface{}, field, pos string) {
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if g.fieldPositions[t] == nil {
+		g.fieldPositions[t] = make(map[string]string)
+	}
+	g.fieldPositions[t][field] = pos
+}
+
+// printHeader prints build constraints, package declaration and the imports of the packages
+// with aliases in used.
+func (g *Generator) printHeader(out io.Writer, used map[string]bool) {
+	if g.buildConstraint != "" {
+		fmt.Fprintln(out, "//go:build", g.buildConstraint)
+	}
 	if g.buildTags != "" {
-		fmt.Println("// +build ", g.buildTags)
-		fmt.Println()
+		fmt.Fprintln(out, "// +build ", g.buildTags)
+	}
+	if g.buildConstraint != "" || g.buildTags != "" {
+		fmt.Fprintln(out)
+	}
+
+	header := g.header
+	if header == "" {
+		header = defaultHeader
+	}
+	for _, line := range strings.Split(header, "\n") {
+		fmt.Fprintln(out, strings.TrimRight("// "+line, " "))
 	}
-	fmt.Println("// AUTOGENERATED FILE: easyjson marshaller/unmarshallers.")
-	fmt.Println()
-	fmt.Println("package ", g.pkgName)
-	fmt.Println()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "package ", g.pkgName)
+	fmt.Fprintln(out)
 
 	byAlias := map[string]string{}
 	var aliases []string
 	for path, alias := range g.imports {
+		// Aliases are also taken by packages that end up unused, e.g. ones of types named only
+		// in the checks of the generator, so the imports are filtered by the generated code.
+		if !used[alias] {
+			continue
+		}
 		aliases = append(aliases, alias)
 		byAlias[alias] = path
 	}
 
 	sort.Strings(aliases)
-	fmt.Println("import (")
-	for _, alias := range g.imports {
-		fmt.Printf("  %s %q\n", alias, byAlias[alias])
+	fmt.Fprintln(out, "import (")
+	for _, alias := range aliases {
+		fmt.Fprintf(out, "  %s %q\n", alias, byAlias[alias])
 	}
+	fmt.Fprintln(out, ")")
 
-	fmt.Println(")")
-	fmt.Println("")
-	fmt.Println("// suppress unused package warning")
-	fmt.Println("var (")
-	fmt.Println("   _ = json.RawMessage{}")
-	fmt.Println("   _ = jlexer.Lexer{}")
-	fmt.Println("   _ = jwriter.Writer{}")
-	fmt.Println(")")
+	fmt.Fprintln(out)
+}
 
-	fmt.Println()
+// usedPackages returns the identifiers of the generated code src that are qualifiers, i.e. are
+// followed by a dot without being selected from something else themselves.
+func usedPackages(src []byte) map[string]bool {
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", fset.Base(), len(src)), src, nil, 0)
+
+	used := map[string]bool{}
+	prev, ident := token.ILLEGAL, ""
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.PERIOD && ident != "" {
+			used[ident] = true
+		}
+		ident = ""
+		if tok == token.IDENT && prev != token.PERIOD {
+			ident = lit
+		}
+		prev = tok
+	}
+	return used
 }
 
 // Run runs the generator and outputs generated code to out.
@@ -178,8 +407,11 @@ func (g *Generator) Run(out io.Writer) error {
 		if err := g.genDecoder(t); err != nil {
 			return err
 		}
-		if err := g.genEncoder(t); err != nil {
-			return err
+		// Values of the types with hand-written MarshalJSON are encoded with MarshalEasyJSON.
+		if !g.keepMarshalJSON[t] {
+			if err := g.genEncoder(t); err != nil {
+				return err
+			}
 		}
 
 		if !g.marshallers[t] {
@@ -193,7 +425,7 @@ func (g *Generator) Run(out io.Writer) error {
 			return err
 		}
 	}
-	g.printHeader()
+	g.printHeader(out, usedPackages(g.out.Bytes()))
 	_, err := out.Write(g.out.Bytes())
 	return err
 }
@@ -238,6 +470,10 @@ func (g *Generator) getType(t reflect.Type) string {
 		}
 	}
 
+	if isGenericOptional(t) {
+		// The name of an instantiated type holds the package paths of the type arguments.
+		return g.pkgAlias(t.PkgPath()) + ".Optional[" + g.getType(t.Field(0).Type) + "]"
+	}
 	if t.Name() == "" || t.PkgPath() == "" {
 		return t.String()
 	} else if t.PkgPath() == g.pkgPath {
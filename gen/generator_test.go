@@ -1,7 +1,18 @@
 package gen
 
 import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCamelToSnake(t *testing.T) {
@@ -47,3 +58,330 @@ func TestJoinFunctionNameParts(t *testing.T) {
 		}
 	}
 }
+
+func TestHeader(t *testing.T) {
+	for i, test := range []struct {
+		constraint string
+		tags       string
+		header     string
+		wantLines  []string
+	}{
+		{"", "", "", []string{"// " + defaultHeader, "", "package  test"}},
+		{"linux && !appengine", "", "", []string{"//go:build linux && !appengine", "", "// " + defaultHeader}},
+		{"use_easyjson", "use_easyjson", "", []string{"//go:build use_easyjson", "// +build  use_easyjson", ""}},
+		{"", "", "Code generated by easyjson. DO NOT EDIT.", []string{"// Code generated by easyjson. DO NOT EDIT.", ""}},
+		{"", "", "line one\n\nline three", []string{"// line one", "//", "// line three", ""}},
+	} {
+		g := NewGenerator("test.go")
+		g.SetPkg("test", "example.com/test")
+		g.SetBuildConstraint(test.constraint)
+		g.SetBuildTags(test.tags)
+		g.SetHeader(test.header)
+
+		var buf bytes.Buffer
+		if err := g.Run(&buf); err != nil {
+			t.Errorf("[%d] Run() error: %v", i, err)
+			continue
+		}
+		src := buf.String()
+
+		if !strings.HasPrefix(src, strings.Join(test.wantLines, "\n")+"\n") {
+			t.Errorf("[%d] Run() output starts with:\n%s\nwant:\n%s", i, src, strings.Join(test.wantLines, "\n"))
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), "test_easyjson.go", src, parser.ParseComments)
+		if err != nil {
+			t.Errorf("[%d] parser.ParseFile() error: %v", i, err)
+			continue
+		}
+		if f.Name.Name != "test" {
+			t.Errorf("[%d] package = %v; want test", i, f.Name.Name)
+		}
+		if test.constraint == "" {
+			continue
+		}
+		line := f.Comments[0].List[0].Text
+		if _, err := constraint.Parse(line); err != nil || !constraint.IsGoBuild(line) {
+			t.Errorf("[%d] first comment %q is not a valid //go:build line: %v", i, line, err)
+		}
+	}
+}
+
+func TestFieldAliasesClash(t *testing.T) {
+	for i, test := range []struct {
+		v       interface{}
+		wantErr bool
+	}{
+		{v: struct {
+			A string `easyjson:"aliases=a;b"`
+			C string
+		}{}},
+		{v: struct {
+			A string `easyjson:"aliases=C"`
+			C string
+		}{}, wantErr: true},
+		{v: struct {
+			A string `easyjson:"aliases=x"`
+			B string `easyjson:"aliases=x"`
+		}{}, wantErr: true},
+		{v: struct {
+			A string `easyjson:"aliases=C"`
+			C string `json:"-"`
+		}{}},
+	} {
+		g := NewGenerator("test.go")
+		g.SetPkg("test", "example.com/test")
+		g.Add(test.v)
+
+		err := g.Run(new(bytes.Buffer))
+		if err != nil && !test.wantErr {
+			t.Errorf("[%d] Run() error: %v", i, err)
+		} else if err == nil && test.wantErr {
+			t.Errorf("[%d] Run() ok; want error", i)
+		}
+	}
+}
+
+func TestFieldKeysClash(t *testing.T) {
+	type embedded struct {
+		Title string `json:"name"`
+	}
+	for i, test := range []struct {
+		v       interface{}
+		namer   FieldNamer
+		wantErr string
+	}{
+		{v: struct {
+			Name  string `json:"name"`
+			Title string `json:"title"`
+		}{}},
+		{v: struct {
+			Name  string
+			Title string `json:"Name"`
+		}{}, wantErr: `fields Name and Title have the same key "Name"`},
+		{v: struct {
+			Name string `json:"name"`
+			embedded
+		}{}, wantErr: `fields Name and Title have the same key "name"`},
+		{v: struct {
+			Name  string `json:"name"`
+			Title string `json:"-"`
+		}{}},
+		{v: struct {
+			UserID string
+			UserId string
+		}{}, namer: SnakeCaseFieldNamer{}, wantErr: `fields UserID and UserId have the same key "user_id"`},
+	} {
+		g := NewGenerator("test.go")
+		g.SetPkg("test", "example.com/test")
+		if test.namer != nil {
+			g.SetFieldNamer(test.namer)
+		}
+		g.Add(test.v)
+
+		err := g.Run(new(bytes.Buffer))
+		if err != nil && (test.wantErr == "" || !strings.Contains(err.Error(), test.wantErr)) {
+			t.Errorf("[%d] Run() error: %v; want %q", i, err, test.wantErr)
+		} else if err == nil && test.wantErr != "" {
+			t.Errorf("[%d] Run() ok; want error %q", i, test.wantErr)
+		}
+	}
+}
+
+type virtualFieldsStruct struct {
+	Name string `json:"name"`
+}
+
+func (virtualFieldsStruct) Upper() string     { return "" }
+func (virtualFieldsStruct) Pair() (int, bool) { return 0, false }
+
+func TestVirtualFields(t *testing.T) {
+	for i, test := range []struct {
+		key, method string
+		wantErr     string
+	}{
+		{key: "upper", method: "Upper"},
+		{key: "name", method: "Upper", wantErr: `virtual field "name" of method Upper clashes with field Name`},
+		{key: "lower", method: "Lower", wantErr: "virtual field lower needs a Lower method"},
+		{key: "pair", method: "Pair", wantErr: "virtual field pair needs a Pair method"},
+	} {
+		g := NewGenerator("test.go")
+		g.SetPkg("test", "example.com/test")
+		g.Add(virtualFieldsStruct{})
+		g.AddVirtualField(virtualFieldsStruct{}, test.key, test.method)
+
+		err := g.Run(new(bytes.Buffer))
+		if err != nil && (test.wantErr == "" || !strings.Contains(err.Error(), test.wantErr)) {
+			t.Errorf("[%d] Run() error: %v; want %q", i, err, test.wantErr)
+		} else if err == nil && test.wantErr != "" {
+			t.Errorf("[%d] Run() ok; want error %q", i, test.wantErr)
+		}
+	}
+}
+
+type textKeyStruct struct {
+	Times map[time.Time]int
+}
+
+type intKeyStruct struct {
+	Counts map[int]int
+}
+
+func TestMapKeyTypes(t *testing.T) {
+	for i, test := range []struct {
+		v         interface{}
+		canonical bool
+		wantErr   bool
+	}{
+		{v: textKeyStruct{}},
+		{v: textKeyStruct{}, canonical: true},
+		{v: intKeyStruct{}, wantErr: true},
+	} {
+		g := NewGenerator("test.go")
+		g.SetPkg("test", "example.com/test")
+		if test.canonical {
+			g.Canonical()
+		}
+		g.Add(test.v)
+
+		var out bytes.Buffer
+		err := g.Run(&out)
+		if err != nil && !test.wantErr {
+			t.Errorf("[%d, %T] Run() error: %v", i, test.v, err)
+		} else if err == nil && test.wantErr {
+			t.Errorf("[%d, %T] Run() ok; want error", i, test.v)
+		}
+		if err == nil {
+			if _, err := parser.ParseFile(token.NewFileSet(), "test_easyjson.go", out.Bytes(), 0); err != nil {
+				t.Errorf("[%d, %T] parser.ParseFile() error: %v", i, test.v, err)
+			}
+		}
+	}
+}
+
+type chanStruct struct {
+	Name   string
+	Events chan int
+}
+
+type omittedChanStruct struct {
+	Name   string
+	Events chan int `json:"-"`
+}
+
+func TestRejectUnsupported(t *testing.T) {
+	for i, test := range []struct {
+		v       interface{}
+		reject  bool
+		pos     string
+		wantErr string
+	}{
+		{v: chanStruct{}},
+		{v: chanStruct{}, reject: true, wantErr: `field gen.chanStruct.Events of unsupported type chan int: tag it with json:"-"`},
+		{v: chanStruct{}, reject: true, pos: "types.go:12", wantErr: "types.go:12: field gen.chanStruct.Events"},
+		{v: omittedChanStruct{}, reject: true},
+	} {
+		g := NewGenerator("test.go")
+		g.SetPkg("test", "example.com/test")
+		if test.reject {
+			g.RejectUnsupported()
+		}
+		if test.pos != "" {
+			g.SetFieldPosition(test.v, "Events", test.pos)
+		}
+		g.Add(test.v)
+
+		err := g.Run(new(bytes.Buffer))
+		if err != nil && (test.wantErr == "" || !strings.HasPrefix(err.Error(), test.wantErr)) {
+			t.Errorf("[%d, %T] Run() error: %v; want %q", i, test.v, err, test.wantErr)
+		} else if err == nil && test.wantErr != "" {
+			t.Errorf("[%d, %T] Run() ok; want error %q", i, test.v, test.wantErr)
+		}
+	}
+}
+
+type plainStruct struct {
+	Name  string
+	Count int
+}
+
+type timeStruct struct {
+	Created time.Time `easyjson:"format=unix"`
+}
+
+type requiredStruct struct {
+	Name string `json:",required"`
+}
+
+type trimmedStruct struct {
+	Name string `easyjson:"trim"`
+}
+
+type rawStruct struct {
+	Data json.RawMessage
+}
+
+type errorStruct struct {
+	Err error
+}
+
+func TestImports(t *testing.T) {
+	for i, test := range []struct {
+		v    interface{}
+		io   bool
+		want []string
+	}{
+		{v: plainStruct{}, want: []string{"jlexer", "jwriter"}},
+		{v: timeStruct{}, want: []string{"jlexer", "jwriter", "time"}},
+		{v: requiredStruct{}, want: []string{"fmt", "jlexer", "jwriter"}},
+		{v: trimmedStruct{}, want: []string{"jlexer", "jwriter", "strings"}},
+		{v: rawStruct{}, want: []string{"jlexer", "jwriter"}},
+		{v: errorStruct{}, want: []string{"errors", "jlexer", "jwriter"}},
+		{v: plainStruct{}, io: true, want: []string{"io", "ioutil", "jlexer", "jwriter"}},
+	} {
+		g := NewGenerator("test.go")
+		g.SetPkg("gen", "github.com/mailru/easyjson/gen")
+		if test.io {
+			g.IOInterfaces()
+		}
+		g.Add(test.v)
+
+		var out bytes.Buffer
+		if err := g.Run(&out); err != nil {
+			t.Errorf("[%d, %T] Run() error: %v", i, test.v, err)
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), "test_easyjson.go", out.Bytes(), 0)
+		if err != nil {
+			t.Errorf("[%d, %T] parser.ParseFile() error: %v", i, test.v, err)
+			continue
+		}
+
+		// All the imports must be used, with code referring to them only by the aliases.
+		used := map[string]bool{}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if x, ok := sel.X.(*ast.Ident); ok {
+					used[x.Name] = true
+				}
+			}
+			return true
+		})
+		var got []string
+		for _, spec := range f.Imports {
+			alias := spec.Name.Name
+			if !used[alias] {
+				path, _ := strconv.Unquote(spec.Path.Value)
+				t.Errorf("[%d, %T] import %v %q is unused", i, test.v, alias, path)
+			}
+			got = append(got, alias)
+		}
+		if !sort.StringsAreSorted(got) {
+			t.Errorf("[%d, %T] imports %v are not sorted", i, test.v, got)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %T] imports = %v; want %v", i, test.v, got, test.want)
+		}
+	}
+}
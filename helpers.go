@@ -2,10 +2,16 @@
 package easyjson
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/mailru/easyjson/jlexer"
 	"github.com/mailru/easyjson/jwriter"
@@ -26,6 +32,21 @@ type Optional interface {
 	IsDefined() bool
 }
 
+// FieldKeyer is implemented by struct types with JSON keys of the fields depending on runtime
+// state: generated marshalers and unmarshalers use FieldJSONKey with the Go name of each field
+// instead of the static key derived from the struct tags.
+type FieldKeyer interface {
+	FieldJSONKey(fieldName string) string
+}
+
+// Enum is implemented by enum types represented in JSON with string labels: EnumLabel returns the
+// label of the value, and SetEnumLabel sets the value for a label, reporting whether the label is
+// known. The label passed to SetEnumLabel may point to the input buffer and must not be retained.
+type Enum interface {
+	EnumLabel() string
+	SetEnumLabel(label string) bool
+}
+
 // Marshal returns data as a single byte slice. Method is suboptimal as the data is likely to be copied
 // from a chain of smaller chunks.
 func Marshal(v Marshaler) ([]byte, error) {
@@ -34,6 +55,39 @@ func Marshal(v Marshaler) ([]byte, error) {
 	return w.BuildBytes()
 }
 
+// MarshalToString returns data as a string, e.g. for logging. The string is a copy, so it stays
+// valid after the buffer chunks are returned to the pool.
+func MarshalToString(v Marshaler) (string, error) {
+	w := jwriter.Writer{}
+	v.MarshalEasyJSON(&w)
+	if w.Error != nil {
+		return "", w.Error
+	}
+
+	var sb strings.Builder
+	sb.Grow(w.Size())
+	w.DumpTo(&sb)
+	return sb.String(), nil
+}
+
+// MarshalIndent is like Marshal, but the output is indented the same as by json.MarshalIndent,
+// byte for byte: each element of an object or an array begins on a new line starting with prefix
+// followed by one or more copies of indent by nesting, with a single space after colons, and
+// empty objects and arrays are output as {} and [].
+func MarshalIndent(v Marshaler, prefix, indent string) ([]byte, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(2 * len(data))
+	if err := json.Indent(&buf, data, prefix, indent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // MarshalToWriter marshals the data to an io.Writer.
 func MarshalToWriter(v Marshaler, w io.Writer) (written int, err error) {
 	jw := jwriter.Writer{}
@@ -41,6 +95,34 @@ func MarshalToWriter(v Marshaler, w io.Writer) (written int, err error) {
 	return jw.DumpTo(w)
 }
 
+// ErrFrameTooLarge is returned by WriteFramed if the data does not fit in a frame.
+var ErrFrameTooLarge = errors.New("easyjson: data is too large for a frame")
+
+// WriteFramed marshals the data and writes it to an io.Writer as a frame for binary protocols: the
+// length of the data as a 4-byte big-endian prefix followed by the data. Nothing is written if
+// marshaling fails.
+func WriteFramed(out io.Writer, v Marshaler) error {
+	jw := jwriter.Writer{}
+	v.MarshalEasyJSON(&jw)
+	if jw.Error != nil {
+		return jw.Error
+	}
+
+	size := jw.Size()
+	if uint64(size) > math.MaxUint32 {
+		jw.DumpTo(ioutil.Discard)
+		return ErrFrameTooLarge
+	}
+	var prefix [4]byte
+	binary.BigEndian.PutUint32(prefix[:], uint32(size))
+	if _, err := out.Write(prefix[:]); err != nil {
+		jw.DumpTo(ioutil.Discard)
+		return err
+	}
+	_, err := jw.DumpTo(out)
+	return err
+}
+
 // MarshalToHTTPResponseWriter sets Content-Length and Content-Type headers for the
 // http.ResponseWriter, and send the data to the writer. started will be equal to
 // false if an error occurred before any http.ResponseWriter methods were actually
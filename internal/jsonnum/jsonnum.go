@@ -0,0 +1,95 @@
+// Package jsonnum implements the JSON number grammar shared by the lexer, the validator and the
+// writer, so that they agree on which literals are numbers.
+package jsonnum
+
+// State is a state of a scanner reading a number literal a char at a time.
+type State byte
+
+const (
+	Start     State = iota // Before the first char of a number.
+	Minus                  // After the leading minus.
+	Zero                   // After the leading zero.
+	Int                    // Inside the integer part.
+	Dot                    // After the decimal point.
+	Frac                   // Inside the fractional part.
+	Exp                    // After the exponent char.
+	ExpSign                // After the exponent sign.
+	ExpDigits              // Inside the exponent.
+	Invalid                // The chars read are not a prefix of a number.
+)
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Next returns the state after reading c, which is Invalid if c does not continue the number,
+// e.g. a char following a complete number.
+func (s State) Next(c byte) State {
+	switch s {
+	case Start:
+		switch {
+		case c == '-':
+			return Minus
+		case c == '0':
+			return Zero
+		case isDigit(c):
+			return Int
+		}
+	case Minus:
+		switch {
+		case c == '0':
+			return Zero
+		case isDigit(c):
+			return Int
+		}
+	case Zero, Int:
+		switch {
+		case isDigit(c) && s == Int:
+			return Int
+		case c == '.':
+			return Dot
+		case c == 'e' || c == 'E':
+			return Exp
+		}
+	case Frac:
+		switch {
+		case isDigit(c):
+			return Frac
+		case c == 'e' || c == 'E':
+			return Exp
+		}
+	case Dot:
+		if isDigit(c) {
+			return Frac
+		}
+	case Exp:
+		switch {
+		case c == '+' || c == '-':
+			return ExpSign
+		case isDigit(c):
+			return ExpDigits
+		}
+	case ExpSign, ExpDigits:
+		if isDigit(c) {
+			return ExpDigits
+		}
+	}
+	return Invalid
+}
+
+// Complete reports whether the chars read in state s form a complete number.
+func (s State) Complete() bool {
+	return s == Zero || s == Int || s == Frac || s == ExpDigits
+}
+
+// Valid reports whether data is a number literal: an optional minus, an integer part without
+// leading zeroes, an optional fraction and an optional exponent, e.g. -12.5e+3.
+func Valid(data []byte) bool {
+	s := Start
+	for _, c := range data {
+		if s = s.Next(c); s == Invalid {
+			return false
+		}
+	}
+	return s.Complete()
+}
@@ -0,0 +1,34 @@
+package jsonnum
+
+import "testing"
+
+func TestValid(t *testing.T) {
+	for i, test := range []struct {
+		data  string
+		valid bool
+	}{
+		{data: "0", valid: true},
+		{data: "-0", valid: true},
+		{data: "123", valid: true},
+		{data: "-12.5e+3", valid: true},
+		{data: "1E-07", valid: true},
+		{data: "0.5", valid: true},
+
+		{data: ""},
+		{data: "-"},
+		{data: "01"},
+		{data: "+1"},
+		{data: ".5"},
+		{data: "1."},
+		{data: "1.2.3"},
+		{data: "1e"},
+		{data: "1e+"},
+		{data: "1e5.0"},
+		{data: "0x10"},
+		{data: "1 "},
+	} {
+		if got := Valid([]byte(test.data)); got != test.valid {
+			t.Errorf("[%d, %q] Valid() = %v; want %v", i, test.data, got, test.valid)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package jlexer
+
+// StripComments returns a copy of JSON with comments (JSONC) removed, e.g. to preprocess
+// configuration files for parsers that do not support comments. Both line (//) and block (/* */)
+// comments are removed, a block comment is replaced with a space so that the tokens around it
+// stay separate. Comment-like sequences in string literals are kept as is. The data is not
+// validated otherwise.
+func StripComments(data []byte) []byte {
+	ret := make([]byte, 0, len(data))
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			end := stringEnd(data, i+1)
+			ret = append(ret, data[i:end]...)
+			i = end - 1
+
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			// The newline ending the comment is kept.
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i < len(data) && !(data[i] == '*' && i+1 < len(data) && data[i+1] == '/') {
+				i++
+			}
+			i++
+			ret = append(ret, ' ')
+
+		default:
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// stringEnd returns the offset right after the closing quote of the string literal that starts
+// at the given offset (after the opening quote), or the length of data if it is unterminated.
+func stringEnd(data []byte, i int) int {
+	for ; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
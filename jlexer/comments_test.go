@@ -0,0 +1,57 @@
+package jlexer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStripComments(t *testing.T) {
+	for i, test := range []struct {
+		data string
+		want string
+	}{
+		{data: `{"a":1}`, want: `{"a":1}`},
+		{data: "{\"a\":1} // comment", want: `{"a":1} `},
+		{data: "{\n  // line\n  \"a\": 1, // trailing\n  \"b\": 2\n}", want: "{\n  \n  \"a\": 1, \n  \"b\": 2\n}"},
+		{data: `{/* block */"a":/**/1}`, want: `{ "a": 1}`},
+		{data: "[1/* multi\nline */,2]", want: `[1 ,2]`},
+		{data: `[1/**/2]`, want: `[1 2]`},
+		{data: `{"url":"http://example.com/*x*/"}`, want: `{"url":"http://example.com/*x*/"}`},
+		{data: `{"a\"//":"\\"} // c`, want: `{"a\"//":"\\"} `},
+		{data: `["/* not", "a comment */"]`, want: `["/* not", "a comment */"]`},
+		{data: `1 /* unterminated`, want: `1  `},
+		{data: `"unterminated // string`, want: `"unterminated // string`},
+		{data: `1 / 2`, want: `1 / 2`},
+		{data: ``, want: ``},
+	} {
+		got := StripComments([]byte(test.data))
+		if string(got) != test.want {
+			t.Errorf("[%d, %q] StripComments() = %q; want %q", i, test.data, got, test.want)
+		}
+	}
+}
+
+func TestStripCommentsConfig(t *testing.T) {
+	config := `{
+  // Server settings.
+  "listen": "0.0.0.0:80", /* all interfaces */
+  "paths": ["/api/*", "//static"], // not comments
+  /*
+   * Limits.
+   */
+  "limits": {"rps": 100}
+}`
+	data := StripComments([]byte(config))
+
+	var got struct {
+		Listen string
+		Paths  []string
+		Limits struct{ RPS int }
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal(%s) error: %v", data, err)
+	}
+	if got.Listen != "0.0.0.0:80" || len(got.Paths) != 2 || got.Paths[0] != "/api/*" || got.Paths[1] != "//static" || got.Limits.RPS != 100 {
+		t.Errorf("StripComments() decoded = %+v", got)
+	}
+}
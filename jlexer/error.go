@@ -3,13 +3,50 @@ package jlexer
 import "fmt"
 
 // LexerError implements the error interface and represents all possible errors that can be
-// generated during parsing the JSON data.
+// generated during parsing the JSON data. Errors of malformed input and of unexpected value
+// types wrap a *SyntaxError or a *TypeMismatchError, which can be retrieved with errors.As.
 type LexerError struct {
 	Reason string
 	Offset int
 	Data   string
+
+	cause error
 }
 
 func (l *LexerError) Error() string {
 	return fmt.Sprintf("parse error: %s near offset %d of '%s'", l.Reason, l.Offset, l.Data)
 }
+
+// Unwrap returns the typed error the lexer error is caused by, if any.
+func (l *LexerError) Unwrap() error {
+	return l.cause
+}
+
+// SyntaxError is an error of malformed JSON input.
+type SyntaxError struct {
+	Reason string
+	Offset int // Offset of the input data the error occurred at.
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error: %s at offset %d", e.Reason, e.Offset)
+}
+
+// TypeMismatchError is an error of a well-formed JSON value that is not of the type expected by
+// the decoder, e.g. a string given for a number field.
+type TypeMismatchError struct {
+	// Field is the key of the object field being decoded, i.e. the last key read by the lexer in
+	// the innermost enclosing object. It is empty for values outside of objects, including the
+	// elements of arrays.
+	Field    string
+	Offset   int    // Offset of the input data after the value.
+	Expected string // Expected value, e.g. "string" or "number".
+	Got      string // Kind of the value found: string, number, bool, null, object, array or a delimiter.
+}
+
+func (e *TypeMismatchError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("expected %s, got %s at offset %d", e.Expected, e.Got, e.Offset)
+	}
+	return fmt.Sprintf("expected %s, got %s for field %q at offset %d", e.Expected, e.Got, e.Field, e.Offset)
+}
@@ -0,0 +1,161 @@
+package jlexer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSyntaxError(t *testing.T) {
+	for i, test := range []struct {
+		data string
+		want SyntaxError
+	}{
+		{data: `{"a":tru}`, want: SyntaxError{Reason: "syntax error", Offset: 5}},
+		{data: `{"a":-}`, want: SyntaxError{Reason: "syntax error", Offset: 6}},
+		{data: `{"a":"\x"}`, want: SyntaxError{Reason: `syntax error`, Offset: 6}},
+	} {
+		l := Lexer{Data: []byte(test.data)}
+		l.Delim('{')
+		l.UnsafeString()
+		l.WantColon()
+		l.Skip()
+
+		var got *SyntaxError
+		if !errors.As(l.Error(), &got) {
+			t.Errorf("[%d, %q] Error() = %v; want *SyntaxError", i, test.data, l.Error())
+			continue
+		}
+		if *got != test.want {
+			t.Errorf("[%d, %q] SyntaxError = %+v; want %+v", i, test.data, *got, test.want)
+		}
+	}
+}
+
+func TestTypeMismatchError(t *testing.T) {
+	for i, test := range []struct {
+		data string
+		want TypeMismatchError
+	}{
+		{data: `"x"`, want: TypeMismatchError{Offset: 3, Expected: "number", Got: "string"}},
+		{data: `{"count":true}`, want: TypeMismatchError{Field: "count", Offset: 13, Expected: "number", Got: "bool"}},
+		{data: `{"ab":[1]}`, want: TypeMismatchError{Field: "ab", Offset: 7, Expected: "number", Got: "array"}},
+		{data: `{"a":{"b":1},"c":null}`, want: TypeMismatchError{Field: "c", Offset: 21, Expected: "number", Got: "null"}},
+	} {
+		l := Lexer{Data: []byte(test.data)}
+		for depth := 0; l.IsDelim('{'); depth++ {
+			l.Delim('{')
+			if depth > 0 {
+				l.UnsafeString()
+				l.WantColon()
+				l.Int()
+				l.WantComma()
+				l.Delim('}')
+				l.WantComma()
+			}
+			l.UnsafeString()
+			l.WantColon()
+		}
+		l.Int()
+
+		var got *TypeMismatchError
+		if !errors.As(l.Error(), &got) {
+			t.Errorf("[%d, %q] Error() = %v; want *TypeMismatchError", i, test.data, l.Error())
+			continue
+		}
+		if !reflect.DeepEqual(*got, test.want) {
+			t.Errorf("[%d, %q] TypeMismatchError = %+v; want %+v", i, test.data, *got, test.want)
+		}
+	}
+}
+
+func TestTypeMismatchErrorNesting(t *testing.T) {
+	// decodeObject reads an object with a single field that is an object or a number.
+	var decodeObject func(l *Lexer)
+	decodeObject = func(l *Lexer) {
+		l.Delim('{')
+		l.UnsafeString()
+		l.WantColon()
+		if l.IsDelim('{') {
+			decodeObject(l)
+		} else {
+			l.Int()
+		}
+		l.WantComma()
+		l.Delim('}')
+	}
+
+	for i, test := range []struct {
+		data   string
+		decode func(l *Lexer)
+		want   string
+	}{
+		{data: `[{"a":1},"x"]`, want: "", decode: func(l *Lexer) {
+			l.Delim('[')
+			decodeObject(l)
+			l.WantComma()
+			l.Int()
+		}},
+		{data: `[{"a":{"b":1}},"x"]`, want: "", decode: func(l *Lexer) {
+			l.Delim('[')
+			decodeObject(l)
+			l.WantComma()
+			l.Int()
+		}},
+		{data: `{"list":[{"a":1},"x"]}`, want: "", decode: func(l *Lexer) {
+			l.Delim('{')
+			l.UnsafeString()
+			l.WantColon()
+			l.Delim('[')
+			decodeObject(l)
+			l.WantComma()
+			l.Int()
+		}},
+		{data: `{"a":{"b":{"c":1}},"x"}`, want: "a", decode: func(l *Lexer) {
+			l.Delim('{')
+			l.UnsafeString()
+			l.WantColon()
+			l.Delim('{')
+			l.UnsafeString()
+			l.WantColon()
+			decodeObject(l)
+			l.WantComma()
+			l.Delim('}')
+			l.WantComma()
+			l.Int()
+		}},
+		{data: `[[[[[[[[[[{"deep":"x"}]]]]]]]]]]`, want: "deep", decode: func(l *Lexer) {
+			for j := 0; j < 10; j++ {
+				l.Delim('[')
+			}
+			decodeObject(l)
+		}},
+	} {
+		l := Lexer{Data: []byte(test.data)}
+		test.decode(&l)
+
+		var got *TypeMismatchError
+		if !errors.As(l.Error(), &got) {
+			t.Errorf("[%d, %q] Error() = %v; want *TypeMismatchError", i, test.data, l.Error())
+			continue
+		}
+		if got.Field != test.want {
+			t.Errorf("[%d, %q] TypeMismatchError.Field = %q; want %q", i, test.data, got.Field, test.want)
+		}
+	}
+}
+
+func TestLexerErrorUntyped(t *testing.T) {
+	l := Lexer{Data: []byte(`300`)}
+	l.Uint8()
+
+	err := l.Error()
+	if _, ok := err.(*LexerError); !ok {
+		t.Fatalf("Error() = %v; want *LexerError", err)
+	}
+	var syntaxErr *SyntaxError
+	var typeErr *TypeMismatchError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		t.Errorf("Error() = %v; want no typed cause for an out of range number", err)
+	}
+}
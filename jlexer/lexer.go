@@ -5,12 +5,20 @@
 package jlexer
 
 import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
-	"reflect"
+	"math/bits"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
 	"unicode/utf8"
 	"unsafe"
+
+	"github.com/mailru/easyjson/internal/jsonnum"
 )
 
 // tokenKind determines type of a token.
@@ -32,8 +40,24 @@ type token struct {
 	boolValue  bool   // Value if a boolean literal token.
 	byteValue  []byte // Raw value of a token.
 	delimValue byte
+
+	scratch bool // Whether byteValue is in the unescaping buffer of the lexer, see fetchString.
 }
 
+// Default limits for the token lengths, used if the corresponding Lexer fields are not set.
+const (
+	DefaultMaxStringLen = 64 << 20
+	DefaultMaxNumberLen = 4096
+)
+
+// maxScratchLen is the length of the longest string literal with escapes that is unescaped into
+// a buffer reused by the lexer, longer ones are unescaped into a buffer of their own.
+const maxScratchLen = 1024
+
+// DefaultMaxRunLength is the limit of the length of run-length encoded data, used if
+// Lexer.MaxRunLength is not set.
+const DefaultMaxRunLength = 64 << 10
+
 // Lexer is a JSON lexer: it iterates over JSON tokens in a byte slice.
 type Lexer struct {
 	Data []byte // Input data given to the lexer.
@@ -46,17 +70,146 @@ type Lexer struct {
 	wantSep      byte // A comma or a colon character, which need to occur before a token.
 
 	err error // Error encountered during lexing, if any.
+
+	// Keys of the fields being decoded in the enclosing objects, the innermost last, reported in
+	// type mismatch errors. Arrays have nil keys. The first levels are kept in keys to avoid
+	// allocations, the deeper ones in moreKeys.
+	keys     [8][]byte
+	moreKeys [][]byte
+	depth    int
+
+	scratch []byte // Buffer reused for unescaping string literals, see fetchString.
+
+	// AllowUnderscoreInNumbers enables digit separators in number literals, e.g. 1_000_000.
+	// An underscore is only accepted between two digits; it is stripped before parsing.
+	AllowUnderscoreInNumbers bool
+
+	// StrictNumbers reports a number followed by whitespace and a digit, e.g. 1 000, as a single
+	// malformed number rather than a number followed by garbage. It is off by default, since
+	// top-level values may be separated by whitespace, e.g. when read one by one with Remaining.
+	StrictNumbers bool
+
+	// MaxStringLen and MaxNumberLen limit the length of a single string or number literal in
+	// bytes to guard against untrusted input. Defaults are used if not set.
+	MaxStringLen int
+	MaxNumberLen int
+
+	// MaxRunLength limits the number of elements run-length encoded data is expanded to, as a few
+	// bytes of input may stand for a huge slice. A default is used if not set.
+	MaxRunLength int
+
+	// Budget limits the total input consumed by a single decode, e.g. per tenant of a service.
+	Budget Budget
+
+	// Stats, if set, collects counters of the scanned input, e.g. to diagnose slow payloads.
+	Stats *Stats
+
+	tokens int // Number of tokens scanned so far, accounted against Budget.MaxTokens.
+
+	// The lexer of the string literal containing Data, for a document read with StringDocument.
+	// The document input is accounted to the outermost lexer, see account.
+	parent *Lexer
+
+	docBytes  int // Bytes of the documents read with StringDocument that are accounted to r.
+	accounted int // Bytes of Data accounted to the outermost lexer, if r has a parent.
 }
 
-// fetchToken scans the input for the next token.
+// Budget limits the total resources a lexer may spend on the input, unlike MaxStringLen and
+// MaxNumberLen that limit single tokens. Zero fields mean no limit.
+type Budget struct {
+	// MaxBytes is the maximum number of bytes of Data that may be consumed.
+	MaxBytes int
+	// MaxTokens is the maximum number of tokens that may be scanned. Values skipped with
+	// SkipRecursive are not split into tokens, so they only count towards MaxBytes, while each
+	// element expanded from run-length encoded data counts as a token, see RunLength.
+	MaxTokens int
+}
+
+// fetchToken scans the input for the next token and accounts it against the budget.
 func (r *Lexer) fetchToken() {
+	r.fetchNextToken()
+	if r.err == nil {
+		root := r.root()
+		root.tokens++
+		r.account()
+		if root.Stats != nil {
+			root.Stats.count(r)
+		}
+	}
+}
+
+// root returns the lexer that the input is accounted to: r, or the outermost lexer if Data is a
+// document read with StringDocument.
+func (r *Lexer) root() *Lexer {
+	for r.parent != nil {
+		r = r.parent
+	}
+	return r
+}
+
+// account accounts the input consumed so far against the budget and the stats of the outermost
+// lexer, setting an error if the budget is exceeded. The documents read with StringDocument are
+// accounted in addition to the string literals containing them.
+func (r *Lexer) account() {
+	root := r.root()
+	if root != r {
+		root.docBytes += r.pos - r.accounted
+		r.accounted = r.pos
+	}
+
+	consumed := root.pos + root.docBytes
+	if root.Stats != nil {
+		root.Stats.Bytes = consumed
+	}
+	if root.Budget.MaxTokens > 0 && root.tokens > root.Budget.MaxTokens {
+		r.errParse("token budget exceeded")
+	} else if root.Budget.MaxBytes > 0 && consumed > root.Budget.MaxBytes {
+		r.errParse("byte budget exceeded")
+	}
+}
+
+// Stats are counters of the input scanned by a lexer. Values skipped with SkipRecursive are not
+// split into tokens, so they only count towards Bytes.
+type Stats struct {
+	Strings int // String literals, including object keys.
+	Numbers int // Number literals.
+	Bools   int // Boolean literals.
+	Nulls   int // null keywords.
+	Objects int // Objects started.
+	Arrays  int // Arrays started.
+	Bytes   int // Bytes of Data consumed.
+}
+
+// count accounts the last scanned token of r.
+func (s *Stats) count(r *Lexer) {
+	switch r.token.kind {
+	case tokenString:
+		s.Strings++
+	case tokenNumber:
+		s.Numbers++
+	case tokenBool:
+		s.Bools++
+	case tokenNull:
+		s.Nulls++
+	case tokenDelim:
+		switch r.token.delimValue {
+		case '{':
+			s.Objects++
+		case '[':
+			s.Arrays++
+		}
+	}
+}
+
+// fetchNextToken scans the input for the next token.
+func (r *Lexer) fetchNextToken() {
 	r.token.kind = tokenUndef
 	r.start = r.pos
 
 	// Check if r.Data has r.pos element
 	// If it doesn't, it mean corrupted input data
 	if len(r.Data) < r.pos {
-		r.errParse("Unexpected end of data")
+		r.errMalformed("Unexpected end of data")
 		return
 	}
 	// Determine the type of a token by skipping whitespace and reading the
@@ -205,9 +358,7 @@ func (r *Lexer) fetchFalse() {
 // chunk may be either blocked from being freed by GC because of a single string or the buffer.Data
 // may be garbage-collected even when the string exists.
 func bytesToStr(data []byte) string {
-	h := (*reflect.SliceHeader)(unsafe.Pointer(&data))
-	shdr := reflect.StringHeader{h.Data, h.Len}
-	return *(*string)(unsafe.Pointer(&shdr))
+	return *(*string)(unsafe.Pointer(&data))
 }
 
 // fetchNumber scans a number literal token.
@@ -215,12 +366,18 @@ func (r *Lexer) fetchNumber() {
 	hasE := false
 	afterE := false
 	hasDot := false
+	hasUnderscore := false
 
 	r.pos++
-	for i, c := range r.Data[r.pos:] {
+	data := r.Data[r.pos:]
+	for i, c := range data {
 		switch {
 		case c >= '0' && c <= '9':
 			afterE = false
+		case c == '_' && r.AllowUnderscoreInNumbers &&
+			isDigit(r.Data[r.pos+i-1]) && i+1 < len(data) && isDigit(data[i+1]):
+
+			hasUnderscore = true
 		case c == '.' && !hasDot:
 			hasDot = true
 		case (c == 'e' || c == 'E') && !hasE:
@@ -233,15 +390,64 @@ func (r *Lexer) fetchNumber() {
 			r.pos += i
 			if !isTokenEnd(c) {
 				r.errSyntax()
+			} else if r.StrictNumbers && r.digitAfterSpace() {
+				r.errMalformed("whitespace inside a number")
 			} else {
-				r.token.byteValue = r.Data[r.start:r.pos]
+				r.setNumberValue(r.Data[r.start:r.pos], hasUnderscore)
 			}
 			return
 		}
 	}
 
 	r.pos = len(r.Data)
-	r.token.byteValue = r.Data[r.start:]
+	r.setNumberValue(r.Data[r.start:], hasUnderscore)
+}
+
+// digitAfterSpace returns true if the whitespace at the current position is followed by a digit,
+// i.e. the number literal just scanned was split by whitespace, like 1 000. A digit can't follow a
+// number anyway, so such input is reported as a single malformed number rather than as garbage
+// after a valid one.
+func (r *Lexer) digitAfterSpace() bool {
+	for _, c := range r.Data[r.pos:] {
+		if !isSpace(c) {
+			return isDigit(c)
+		}
+	}
+	return false
+}
+
+// isDigit returns true if the char is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// setNumberValue stores a scanned number literal as the token value, stripping digit separators
+// into a copy if there are any.
+func (r *Lexer) setNumberValue(data []byte, hasUnderscore bool) {
+	max := r.MaxNumberLen
+	if max <= 0 {
+		max = DefaultMaxNumberLen
+	}
+	if len(data) > max {
+		r.errParse("number literal is too long")
+		return
+	}
+
+	if hasUnderscore {
+		stripped := make([]byte, 0, len(data))
+		for _, c := range data {
+			if c != '_' {
+				stripped = append(stripped, c)
+			}
+		}
+		data = stripped
+	}
+
+	if !jsonnum.Valid(data) {
+		r.errSyntax()
+		return
+	}
+	r.token.byteValue = data
 }
 
 // findStringLen tries to scan into the string literal for ending quote char to determine required size.
@@ -296,11 +502,35 @@ func (r *Lexer) processEscape(data []byte) (int, error) {
 		return 0, fmt.Errorf("syntax error")
 	}
 
-	var val rune
+	val := getu4(data)
+	if val < 0 {
+		return 0, fmt.Errorf("syntax error")
+	}
 
-	for i := 2; i < len(data) && i < 6; i++ {
+	// A surrogate pair is decoded to a single rune, lone surrogates are replaced with U+FFFD
+	// as encoding/json does.
+	n := 6
+	if utf16.IsSurrogate(val) {
+		if val2 := getu4(data[6:]); val2 >= 0 && utf16.DecodeRune(val, val2) != unicode.ReplacementChar {
+			val, n = utf16.DecodeRune(val, val2), 12
+		} else {
+			val = unicode.ReplacementChar
+		}
+	}
+
+	r.token.byteValue = utf8.AppendRune(r.token.byteValue, val)
+	return n, nil
+}
+
+// getu4 decodes a \uXXXX escape at the start of data, returning -1 if it is malformed.
+func getu4(data []byte) rune {
+	if len(data) < 6 || data[0] != '\\' || data[1] != 'u' {
+		return -1
+	}
+
+	var val rune
+	for _, c := range data[2:6] {
 		var v byte
-		c = data[i]
 		switch c {
 		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 			v = c - '0'
@@ -309,22 +539,13 @@ func (r *Lexer) processEscape(data []byte) (int, error) {
 		case 'A', 'B', 'C', 'D', 'E', 'F':
 			v = c - 'A' + 10
 		default:
-			return 0, fmt.Errorf("syntax error")
+			return -1
 		}
 
 		val <<= 4
 		val |= rune(v)
 	}
-
-	l := utf8.RuneLen(val)
-	if l == -1 {
-		return 0, fmt.Errorf("invalid unicode escape")
-	}
-
-	var d [4]byte
-	utf8.EncodeRune(d[:], val)
-	r.token.byteValue = append(r.token.byteValue, d[:l]...)
-	return 6, nil
+	return val
 }
 
 // fetchString scans a string literal token.
@@ -333,13 +554,35 @@ func (r *Lexer) fetchString() {
 	data := r.Data[r.pos:]
 
 	hasEscapes, length := findStringLen(data)
+
+	max := r.MaxStringLen
+	if max <= 0 {
+		max = DefaultMaxStringLen
+	}
+	if length > max {
+		r.errParse("string literal is too long")
+		return
+	}
+
+	r.token.scratch = false
 	if !hasEscapes {
 		r.token.byteValue = data[:length]
 		r.pos += length + 1
 		return
 	}
 
-	r.token.byteValue = make([]byte, 0, length)
+	// The unescaped value is never longer than the literal, so it does not outgrow the buffer.
+	// Short values are unescaped into a buffer reused by the lexer, as most of them are copied
+	// to a string right away; they are moved out of it by the readers that return them as is.
+	if length <= maxScratchLen {
+		if cap(r.scratch) < length {
+			r.scratch = make([]byte, 0, maxScratchLen)
+		}
+		r.token.byteValue = r.scratch[:0]
+		r.token.scratch = true
+	} else {
+		r.token.byteValue = make([]byte, 0, length)
+	}
 	p := 0
 	for i := 0; i < len(data); {
 		switch data[i] {
@@ -353,7 +596,7 @@ func (r *Lexer) fetchString() {
 			r.token.byteValue = append(r.token.byteValue, data[p:i]...)
 			off, err := r.processEscape(data[i:])
 			if err != nil {
-				r.errParse(err.Error())
+				r.errMalformed(err.Error())
 				return
 			}
 			i += off
@@ -363,7 +606,7 @@ func (r *Lexer) fetchString() {
 			i++
 		}
 	}
-	r.errParse("unterminated string literal")
+	r.errMalformed("unterminated string literal")
 }
 
 // scanToken scans the next token if no token is currently available in the lexer.
@@ -381,6 +624,15 @@ func (r *Lexer) consume() {
 	r.token.delimValue = 0
 }
 
+// detachScratch moves the value of the last string token out of the unescaping buffer, so that it
+// is not overwritten by the next string read, for the readers that return it without a copy.
+func (r *Lexer) detachScratch() {
+	if r.token.scratch {
+		r.token.byteValue = append([]byte(nil), r.token.byteValue...)
+		r.token.scratch = false
+	}
+}
+
 // Ok returns true if no error (including io.EOF) was encountered during scanning.
 func (r *Lexer) Ok() bool {
 	return r.err == nil
@@ -404,12 +656,24 @@ func (r *Lexer) errParse(what string) {
 	}
 }
 
+// errMalformed reports an error of malformed input caused by a *SyntaxError.
+func (r *Lexer) errMalformed(what string) {
+	if r.err == nil {
+		r.errParse(what)
+		r.err.(*LexerError).cause = &SyntaxError{Reason: what, Offset: r.pos}
+	}
+}
+
 func (r *Lexer) errSyntax() {
-	r.errParse("syntax error")
+	r.errMalformed("syntax error")
 }
 
 func (r *Lexer) errInvalidToken(expected string) {
 	if r.err == nil {
+		var field string
+		if key := r.innermostKey(); key != nil {
+			field = string(*key)
+		}
 		var str string
 		if len(r.token.byteValue) <= maxErrorContextLen {
 			str = string(r.token.byteValue)
@@ -420,10 +684,39 @@ func (r *Lexer) errInvalidToken(expected string) {
 			Reason: fmt.Sprintf("expected %s", expected),
 			Offset: r.pos,
 			Data:   str,
+			cause: &TypeMismatchError{
+				Field:    field,
+				Offset:   r.pos,
+				Expected: expected,
+				Got:      r.token.describe(),
+			},
 		}
 	}
 }
 
+// describe returns the kind of the token for error messages.
+func (t *token) describe() string {
+	switch t.kind {
+	case tokenString:
+		return "string"
+	case tokenNumber:
+		return "number"
+	case tokenBool:
+		return "bool"
+	case tokenNull:
+		return "null"
+	case tokenDelim:
+		switch t.delimValue {
+		case '{':
+			return "object"
+		case '[':
+			return "array"
+		}
+		return string([]byte{t.delimValue})
+	}
+	return "no value"
+}
+
 // Delim consumes a token and verifies that it is the given delimiter.
 func (r *Lexer) Delim(c byte) {
 	if r.token.kind == tokenUndef && r.Ok() {
@@ -433,6 +726,47 @@ func (r *Lexer) Delim(c byte) {
 		r.errInvalidToken(string([]byte{c}))
 	}
 	r.consume()
+
+	switch {
+	case !r.Ok():
+	case c == '{' || c == '[':
+		r.pushKey()
+	case c == '}' || c == ']':
+		r.popKey()
+	}
+}
+
+// pushKey enters an object or an array, with no field key read yet.
+func (r *Lexer) pushKey() {
+	if r.depth < len(r.keys) {
+		r.keys[r.depth] = nil
+	} else {
+		r.moreKeys = append(r.moreKeys, nil)
+	}
+	r.depth++
+}
+
+// popKey leaves the innermost object or array.
+func (r *Lexer) popKey() {
+	if r.depth == 0 {
+		return
+	}
+	r.depth--
+	if r.depth >= len(r.keys) {
+		r.moreKeys = r.moreKeys[:r.depth-len(r.keys)]
+	}
+}
+
+// innermostKey returns the key of the field being decoded in the innermost object or array, nil
+// outside of them.
+func (r *Lexer) innermostKey() *[]byte {
+	switch {
+	case r.depth == 0:
+		return nil
+	case r.depth <= len(r.keys):
+		return &r.keys[r.depth-1]
+	}
+	return &r.moreKeys[r.depth-1-len(r.keys)]
 }
 
 // IsDelim returns true if there was no scanning error and next token is the given delimiter.
@@ -462,6 +796,25 @@ func (r *Lexer) IsNull() bool {
 	return r.Ok() && r.token.kind == tokenNull
 }
 
+// UnquoteNumber makes the next token to be read as a number if it is a string literal, e.g. "123",
+// for interoperability with producers quoting numbers inconsistently.
+func (r *Lexer) UnquoteNumber() {
+	if r.token.kind == tokenUndef && r.Ok() {
+		r.fetchToken()
+	}
+	if r.Ok() && r.token.kind == tokenString && jsonnum.Valid(r.token.byteValue) {
+		r.token.kind = tokenNumber
+	}
+}
+
+// IsEmptyString returns true if the next token is an empty string literal.
+func (r *Lexer) IsEmptyString() bool {
+	if r.token.kind == tokenUndef && r.Ok() {
+		r.fetchToken()
+	}
+	return r.Ok() && r.token.kind == tokenString && len(r.token.byteValue) == 0
+}
+
 // Skip skips a single token.
 func (r *Lexer) Skip() {
 	if r.token.kind == tokenUndef && r.Ok() {
@@ -502,6 +855,7 @@ func (r *Lexer) SkipRecursive() {
 			level--
 			if level == 0 {
 				r.pos += i + 1
+				r.account()
 				return
 			}
 		case c == '\\' && inQuotes:
@@ -518,6 +872,51 @@ func (r *Lexer) SkipRecursive() {
 	r.err = io.EOF
 }
 
+// ValueStart scans the next value if needed and returns its offset in Data. Together with
+// ValueEnd it allows to capture the raw bytes of a value while decoding it:
+//
+//	start := in.ValueStart()
+//	... decode the value ...
+//	raw := in.Data[start:in.ValueEnd()]
+func (r *Lexer) ValueStart() int {
+	r.scanToken()
+	return r.start
+}
+
+// ValueEnd returns the offset in Data right after the last scanned value.
+func (r *Lexer) ValueEnd() int {
+	return r.pos
+}
+
+// Remaining returns the part of Data after the last consumed token, e.g. to start decoding the
+// next one of several JSON values concatenated without delimiters, like {}{}. A token that was
+// scanned but not consumed yet (e.g. by IsDelim or IsNull) is included.
+func (r *Lexer) Remaining() []byte {
+	if r.token.kind != tokenUndef {
+		return r.Data[r.start:]
+	}
+	if r.pos > len(r.Data) {
+		return nil
+	}
+	return r.Data[r.pos:]
+}
+
+// Consumed checks that only whitespace follows the last consumed token, e.g. after a document
+// that is to hold a single value, and sets a syntax error otherwise.
+func (r *Lexer) Consumed() {
+	if !r.Ok() {
+		return
+	}
+	rest := r.Remaining()
+	for i, c := range rest {
+		if !isSpace(c) {
+			r.pos = len(r.Data) - len(rest) + i
+			r.errMalformed(fmt.Sprintf("invalid character %q after top-level value", c))
+			return
+		}
+	}
+}
+
 // Raw fetches the next item recursively as a data slice
 func (r *Lexer) Raw() []byte {
 	r.SkipRecursive()
@@ -527,6 +926,45 @@ func (r *Lexer) Raw() []byte {
 	return r.Data[r.start:r.pos]
 }
 
+// PeekObjectField looks ahead into the object that is the next value and returns the raw value
+// of its field with the given name, e.g. to read a type discriminator before choosing the
+// concrete type to decode the object into. The lexer state is restored afterwards, so the
+// object can then be decoded as usual. false is returned if the next value is not an object,
+// it is malformed or it does not have the field.
+func (r *Lexer) PeekObjectField(name string) ([]byte, bool) {
+	// The token that may have been scanned already is restored, while the buffer is reused.
+	r.detachScratch()
+	// The input is accounted when it is actually read, so the budget and the stats are restored.
+	root := r.root()
+	saved, savedTokens, savedDocBytes := *r, root.tokens, root.docBytes
+	var savedStats Stats
+	if root.Stats != nil {
+		savedStats = *root.Stats
+	}
+	defer func() {
+		*r = saved
+		root.tokens, root.docBytes = savedTokens, savedDocBytes
+		if root.Stats != nil {
+			*root.Stats = savedStats
+		}
+	}()
+
+	r.Delim('{')
+	for r.Ok() && !r.IsDelim('}') {
+		key := r.UnsafeString()
+		r.WantColon()
+		if key == name {
+			if raw := r.Raw(); r.Ok() {
+				return raw, true
+			}
+			return nil, false
+		}
+		r.SkipRecursive()
+		r.WantComma()
+	}
+	return nil, false
+}
+
 // UnsafeString returns the string value if the token is a string literal.
 //
 // Warning: returned string may point to the input buffer, so the string should not outlive
@@ -540,11 +978,34 @@ func (r *Lexer) UnsafeString() string {
 		return ""
 	}
 
+	r.detachScratch()
 	ret := bytesToStr(r.token.byteValue)
 	r.consume()
 	return ret
 }
 
+// FetchKeyBytes reads the key of an object field and the colon following it, returning the key as
+// bytes, e.g. for hand-written decoders matching the keys with bytes.Equal.
+//
+// Warning: returned slice may point to the input buffer, so it should not be modified or
+// outlive the input buffer. Generated decoders use UnsafeString instead, which does not
+// allocate either.
+func (r *Lexer) FetchKeyBytes() []byte {
+	if r.token.kind == tokenUndef && r.Ok() {
+		r.fetchToken()
+	}
+	if !r.Ok() || r.token.kind != tokenString {
+		r.errInvalidToken("string")
+		return nil
+	}
+
+	r.detachScratch()
+	ret := r.token.byteValue
+	r.consume()
+	r.WantColon()
+	return ret
+}
+
 // String reads a string literal.
 func (r *Lexer) String() string {
 	if r.token.kind == tokenUndef && r.Ok() {
@@ -575,6 +1036,155 @@ func (r *Lexer) Bool() bool {
 	return ret
 }
 
+// IntBool reads a bool given either as a 1 or 0 number or as a true or false literal.
+func (r *Lexer) IntBool() bool {
+	if r.token.kind == tokenUndef && r.Ok() {
+		r.fetchToken()
+	}
+	if r.Ok() && r.token.kind == tokenNumber {
+		if v := r.token.byteValue; len(v) == 1 && (v[0] == '0' || v[0] == '1') {
+			r.consume()
+			return v[0] == '1'
+		}
+		r.errInvalidToken("0, 1 or bool")
+		return false
+	}
+	return r.Bool()
+}
+
+// BoolStr reads a boolean keyword enclosed in a string literal, i.e. "true" or "false".
+func (r *Lexer) BoolStr() bool {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return false
+	}
+
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	r.err = &LexerError{
+		Reason: "expected quoted bool",
+		Offset: r.pos,
+		Data:   s,
+	}
+	return false
+}
+
+// StringDocument reads a string literal containing a JSON document, e.g. a double-encoded
+// object, and returns a lexer for the document with the same options as r. The input of the
+// document is accounted against the Budget and the Stats of r, in addition to the string literal.
+// Errors of the returned lexer are to be added to r once the document is decoded.
+func (r *Lexer) StringDocument() *Lexer {
+	return &Lexer{
+		Data:                     []byte(r.String()),
+		AllowUnderscoreInNumbers: r.AllowUnderscoreInNumbers,
+		StrictNumbers:            r.StrictNumbers,
+		MaxStringLen:             r.MaxStringLen,
+		MaxNumberLen:             r.MaxNumberLen,
+		MaxRunLength:             r.MaxRunLength,
+		Budget:                   r.Budget,
+		Stats:                    r.Stats,
+		parent:                   r,
+	}
+}
+
+// RunLength reads the count of a run of equal values in run-length encoded data that extends the
+// data decoded so far from n elements, e.g. of a slice field with format=rle. It is an error if
+// the count is not positive or the data grows beyond MaxRunLength elements. The expanded elements
+// are accounted against Budget.MaxTokens, so that a short input can't cost more than its budget.
+func (r *Lexer) RunLength(n int) int {
+	count := r.Int()
+	if !r.Ok() {
+		return 0
+	}
+
+	max := r.MaxRunLength
+	if max <= 0 {
+		max = DefaultMaxRunLength
+	}
+	if count <= 0 {
+		r.errParse(fmt.Sprintf("run length %d is not positive", count))
+		return 0
+	}
+	if count > max-n {
+		r.errParse("run-length encoded data is too long")
+		return 0
+	}
+
+	r.root().tokens += count
+	r.account()
+	if !r.Ok() {
+		return 0
+	}
+	return count
+}
+
+// Bytes reads a base64 string, e.g. of a binary value. The returned slice does not point to the
+// input data.
+func (r *Lexer) Bytes() []byte {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+			Offset: r.pos,
+			Data:   string([]byte(s)),
+		}
+		return nil
+	}
+	return data
+}
+
+// Uint32sLE reads a base64 string of 32-bit integers packed in little-endian order, e.g. of
+// binary telemetry data. An empty string is read as a nil slice.
+func (r *Lexer) Uint32sLE() []uint32 {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err == nil && len(data)%4 != 0 {
+		err = fmt.Errorf("packed uint32 data of %v bytes is not a multiple of 4", len(data))
+	}
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+			Offset: r.pos,
+			Data:   string([]byte(s)),
+		}
+		return nil
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	ret := make([]uint32, len(data)/4)
+	for i := range ret {
+		ret[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+	return ret
+}
+
+// UnknownEnumLabel reports an error for a string label not recognized by the enum type being
+// decoded.
+func (r *Lexer) UnknownEnumLabel(label string) {
+	if r.err == nil {
+		r.err = &LexerError{
+			Reason: "unknown enum label",
+			Offset: r.pos,
+			Data:   label,
+		}
+	}
+}
+
 func (r *Lexer) number() string {
 	if r.token.kind == tokenUndef && r.Ok() {
 		r.fetchToken()
@@ -589,8 +1199,30 @@ func (r *Lexer) number() string {
 	return ret
 }
 
-func (r *Lexer) Uint8() uint8 {
+// integer fetches a number literal of an integer type. Exponent notation is accepted if the
+// value is an exact integer, e.g. 1e3 or 1.5e1, and converted to plain digits.
+func (r *Lexer) integer() string {
 	s := r.number()
+	if !r.Ok() || strings.IndexAny(s, "eE") < 0 {
+		return s
+	}
+
+	digits, err := shiftDecimal(s, 0)
+	if err == errFraction {
+		err = fmt.Errorf("%v is not an integer", s)
+	}
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+			Data:   s,
+		}
+		return ""
+	}
+	return digits
+}
+
+func (r *Lexer) Uint8() uint8 {
+	s := r.integer()
 	if !r.Ok() {
 		return 0
 	}
@@ -605,7 +1237,7 @@ func (r *Lexer) Uint8() uint8 {
 }
 
 func (r *Lexer) Uint16() uint16 {
-	s := r.number()
+	s := r.integer()
 	if !r.Ok() {
 		return 0
 	}
@@ -620,7 +1252,7 @@ func (r *Lexer) Uint16() uint16 {
 }
 
 func (r *Lexer) Uint32() uint32 {
-	s := r.number()
+	s := r.integer()
 	if !r.Ok() {
 		return 0
 	}
@@ -635,7 +1267,7 @@ func (r *Lexer) Uint32() uint32 {
 }
 
 func (r *Lexer) Uint64() uint64 {
-	s := r.number()
+	s := r.integer()
 	if !r.Ok() {
 		return 0
 	}
@@ -654,7 +1286,7 @@ func (r *Lexer) Uint() uint {
 }
 
 func (r *Lexer) Int8() int8 {
-	s := r.number()
+	s := r.integer()
 	if !r.Ok() {
 		return 0
 	}
@@ -669,7 +1301,7 @@ func (r *Lexer) Int8() int8 {
 }
 
 func (r *Lexer) Int16() int16 {
-	s := r.number()
+	s := r.integer()
 	if !r.Ok() {
 		return 0
 	}
@@ -684,7 +1316,7 @@ func (r *Lexer) Int16() int16 {
 }
 
 func (r *Lexer) Int32() int32 {
-	s := r.number()
+	s := r.integer()
 	if !r.Ok() {
 		return 0
 	}
@@ -699,7 +1331,7 @@ func (r *Lexer) Int32() int32 {
 }
 
 func (r *Lexer) Int64() int64 {
-	s := r.number()
+	s := r.integer()
 	if !r.Ok() {
 		return 0
 	}
@@ -845,6 +1477,283 @@ func (r *Lexer) IntStr() int {
 	return int(r.Int64Str())
 }
 
+// hexDigits strips the optional sign and the mandatory 0x (or 0X) prefix of a hex string.
+func hexDigits(s string) (digits string, neg bool, ok bool) {
+	if strings.HasPrefix(s, "-") {
+		s, neg = s[1:], true
+	}
+	if len(s) < 3 || s[0] != '0' || (s[1] != 'x' && s[1] != 'X') || s[2] == '-' || s[2] == '+' {
+		return "", false, false
+	}
+	return s[2:], neg, true
+}
+
+// IntHexStr reads a string with a 0x-prefixed, optionally negative, hex integer that fits
+// into bitSize bits, e.g. "0xFF" or "-0x1f".
+func (r *Lexer) IntHexStr(bitSize int) int64 {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return 0
+	}
+
+	digits, neg, ok := hexDigits(s)
+	if !ok {
+		r.err = &LexerError{
+			Reason: "invalid hex integer",
+			Data:   s,
+		}
+		return 0
+	}
+	if neg {
+		digits = "-" + digits
+	}
+	n, err := strconv.ParseInt(digits, 16, bitSize)
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+		}
+		return 0
+	}
+	return n
+}
+
+// groupedDigits returns the digits of a decimal integer with the digits grouped by thousands with
+// commas, e.g. "-1,234", or without separators, and whether the number is negative.
+func groupedDigits(s string) (digits string, neg, ok bool) {
+	if strings.HasPrefix(s, "-") {
+		s, neg = s[1:], true
+	}
+	groups := strings.Split(s, ",")
+	for i, g := range groups {
+		if g == "" || len(groups) > 1 && (len(g) > 3 || i > 0 && len(g) != 3) {
+			return "", false, false
+		}
+		for j := 0; j < len(g); j++ {
+			if g[j] < '0' || g[j] > '9' {
+				return "", false, false
+			}
+		}
+	}
+	return strings.Join(groups, ""), neg, true
+}
+
+// IntGroupedStr reads a string with a decimal integer that fits into bitSize bits, with the
+// digits grouped by thousands with commas, e.g. "-1,234,567", or without separators.
+func (r *Lexer) IntGroupedStr(bitSize int) int64 {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return 0
+	}
+
+	digits, neg, ok := groupedDigits(s)
+	if !ok {
+		r.err = &LexerError{
+			Reason: "invalid grouped integer",
+			Data:   s,
+		}
+		return 0
+	}
+	if neg {
+		digits = "-" + digits
+	}
+	n, err := strconv.ParseInt(digits, 10, bitSize)
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+		}
+		return 0
+	}
+	return n
+}
+
+// UintGroupedStr reads a string with a decimal unsigned integer that fits into bitSize bits,
+// with the digits grouped by thousands with commas, e.g. "1,234,567", or without separators.
+func (r *Lexer) UintGroupedStr(bitSize int) uint64 {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return 0
+	}
+
+	digits, neg, ok := groupedDigits(s)
+	if !ok || neg {
+		r.err = &LexerError{
+			Reason: "invalid grouped unsigned integer",
+			Data:   s,
+		}
+		return 0
+	}
+	n, err := strconv.ParseUint(digits, 10, bitSize)
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+		}
+		return 0
+	}
+	return n
+}
+
+// isPaddedNumber returns true if s is a decimal integer with an optional minus sign, possibly with
+// leading zeros.
+func isPaddedNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// IntPaddedStr reads a string with a decimal integer that fits into bitSize bits, possibly
+// zero-padded, e.g. "00000042" or "-0000042".
+func (r *Lexer) IntPaddedStr(bitSize int) int64 {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return 0
+	}
+
+	if !isPaddedNumber(s) {
+		r.err = &LexerError{
+			Reason: "invalid padded integer",
+			Data:   s,
+		}
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, bitSize)
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+		}
+		return 0
+	}
+	return n
+}
+
+// UintPaddedStr reads a string with a decimal unsigned integer that fits into bitSize bits,
+// possibly zero-padded, e.g. "00000042".
+func (r *Lexer) UintPaddedStr(bitSize int) uint64 {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return 0
+	}
+
+	if !isPaddedNumber(s) || s[0] == '-' {
+		r.err = &LexerError{
+			Reason: "invalid padded unsigned integer",
+			Data:   s,
+		}
+		return 0
+	}
+	n, err := strconv.ParseUint(s, 10, bitSize)
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+		}
+		return 0
+	}
+	return n
+}
+
+// Uint128Str reads a string with a decimal unsigned 128-bit integer and returns its high and
+// low 64-bit words.
+func (r *Lexer) Uint128Str() (hi, lo uint64) {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return 0, 0
+	}
+
+	hi, lo, ok := parseUint128(s)
+	if !ok {
+		r.err = &LexerError{
+			Reason: "invalid 128-bit unsigned integer",
+			Data:   s,
+		}
+		return 0, 0
+	}
+	return hi, lo
+}
+
+// Int128Str reads a string with a decimal signed 128-bit integer and returns its high and low
+// 64-bit words in two's complement.
+func (r *Lexer) Int128Str() (hi int64, lo uint64) {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return 0, 0
+	}
+
+	neg := strings.HasPrefix(s, "-")
+	u, lo, ok := parseUint128(strings.TrimPrefix(s, "-"))
+	if ok && neg {
+		ok = u < 1<<63 || u == 1<<63 && lo == 0
+		u, lo = ^u, -lo
+		if lo == 0 {
+			u++
+		}
+	} else if ok {
+		ok = u < 1<<63
+	}
+	if !ok {
+		r.err = &LexerError{
+			Reason: "invalid 128-bit integer",
+			Data:   s,
+		}
+		return 0, 0
+	}
+	return int64(u), lo
+}
+
+// parseUint128 parses a decimal number without a sign into the words of a 128-bit integer,
+// ok is false if s is not a number or it overflows.
+func parseUint128(s string) (hi, lo uint64, ok bool) {
+	if s == "" {
+		return 0, 0, false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return 0, 0, false
+		}
+
+		overflow, h := bits.Mul64(hi, 10)
+		carry, l := bits.Mul64(lo, 10)
+		l, c1 := bits.Add64(l, uint64(c-'0'), 0)
+		h, c2 := bits.Add64(h, carry, c1)
+		if overflow != 0 || c2 != 0 {
+			return 0, 0, false
+		}
+		hi, lo = h, l
+	}
+	return hi, lo, true
+}
+
+// UintHexStr reads a string with a 0x-prefixed hex unsigned integer that fits into bitSize bits.
+func (r *Lexer) UintHexStr(bitSize int) uint64 {
+	s := r.UnsafeString()
+	if !r.Ok() {
+		return 0
+	}
+
+	digits, neg, ok := hexDigits(s)
+	if !ok || neg {
+		r.err = &LexerError{
+			Reason: "invalid hex unsigned integer",
+			Data:   s,
+		}
+		return 0
+	}
+	n, err := strconv.ParseUint(digits, 16, bitSize)
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+		}
+		return 0
+	}
+	return n
+}
+
 func (r *Lexer) Float32() float32 {
 	s := r.number()
 	if !r.Ok() {
@@ -875,6 +1784,99 @@ func (r *Lexer) Float64() float64 {
 	return n
 }
 
+// FixedDecimal reads a number as an integer amount of minor units with scale digits after the
+// decimal point, e.g. 12.34 with scale 2 as 1234. It is an error if the number has more
+// significant fractional digits than the scale allows. No float conversion is done.
+func (r *Lexer) FixedDecimal(scale int) int64 {
+	s := r.number()
+	if !r.Ok() {
+		return 0
+	}
+
+	n, err := parseFixedDecimal(s, scale)
+	if err != nil {
+		r.err = &LexerError{
+			Reason: err.Error(),
+			Data:   s,
+		}
+		return 0
+	}
+	return n
+}
+
+// maxExponentLen limits the exponent of numbers converted by shiftDecimal.
+const maxExponentLen = 9
+
+// parseFixedDecimal converts a number literal to an integer of minor units, see FixedDecimal.
+func parseFixedDecimal(s string, scale int) (int64, error) {
+	digits, err := shiftDecimal(s, scale)
+	if err == errFraction {
+		return 0, fmt.Errorf("more than %d fractional digits", scale)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(digits, 10, 64)
+}
+
+// errFraction is returned by shiftDecimal if the result has a fractional part.
+var errFraction = errors.New("fractional digits left")
+
+// shiftDecimal returns the digits of a number literal multiplied by 10^scale as a plain integer
+// literal, e.g. 1.5e1 with scale 0 as 15. It is an error if the result is not an integer.
+func shiftDecimal(s string, scale int) (string, error) {
+	neg := len(s) > 0 && s[0] == '-'
+	if neg {
+		s = s[1:]
+	}
+
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if len(s)-i-1 > maxExponentLen {
+			return "", fmt.Errorf("exponent is too large")
+		}
+		var err error
+		if exp, err = strconv.Atoi(s[i+1:]); err != nil {
+			return "", err
+		}
+		mantissa = s[:i]
+	}
+
+	digits, frac := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits, frac = mantissa[:i], mantissa[i+1:]
+	}
+	digits = strings.TrimLeft(digits+frac, "0")
+
+	// Number of zeros to append, or of digits to drop if negative.
+	shift := scale + exp - len(frac)
+	switch {
+	case digits == "":
+		return "0", nil
+	case shift < 0:
+		cut := len(digits) + shift
+		if cut < 0 {
+			cut = 0
+		}
+		if strings.Trim(digits[cut:], "0") != "" {
+			return "", errFraction
+		}
+		digits = digits[:cut]
+	case shift+len(digits) > 20:
+		return "", fmt.Errorf("value out of range")
+	default:
+		digits += strings.Repeat("0", shift)
+	}
+
+	if digits == "" {
+		return "0", nil
+	}
+	if neg {
+		digits = "-" + digits
+	}
+	return digits, nil
+}
+
 func (r *Lexer) Error() error {
 	return r.err
 }
@@ -926,7 +1928,8 @@ func (r *Lexer) Interface() interface{} {
 	} else if r.token.delimValue == '[' {
 		r.consume()
 
-		var ret []interface{}
+		// An empty array is decoded as an empty slice rather than nil, as in encoding/json.
+		ret := []interface{}{}
 		for !r.IsDelim(']') {
 			ret = append(ret, r.Interface())
 			r.WantComma()
@@ -949,8 +1952,13 @@ func (r *Lexer) WantComma() {
 	r.firstElement = false
 }
 
-// WantColon requires a colon to be present before fetching next token.
+// WantColon requires a colon to be present before fetching next token. The object key that has
+// just been read is kept for error reporting.
 func (r *Lexer) WantColon() {
 	r.wantSep = ':'
 	r.firstElement = false
+	r.detachScratch()
+	if key := r.innermostKey(); key != nil {
+		*key = r.token.byteValue
+	}
 }
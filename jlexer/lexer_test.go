@@ -1,8 +1,14 @@
 package jlexer
 
 import (
+	"bytes"
+	"errors"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/mailru/easyjson/jwriter"
 )
 
 func TestString(t *testing.T) {
@@ -17,12 +23,22 @@ func TestString(t *testing.T) {
 		{toParse: `"\u0020"`, want: " "},
 		{toParse: `"\u0020-\t"`, want: " -\t"},
 		{toParse: `"\ufffd\uFFFD"`, want: "\ufffd\ufffd"},
+		{toParse: `"\uD83D\uDE00"`, want: "\U0001F600"},
+		{toParse: `"a\ud83d\ude00b"`, want: "a\U0001F600b"},
+		{toParse: `"\ud800"`, want: "\ufffd"},
+		{toParse: `"\ud800x"`, want: "\ufffdx"},
+		{toParse: `"\ud800\u0041"`, want: "\ufffdA"},
+		{toParse: `"\ud800\ud800\udc00"`, want: "\ufffd\U00010000"},
+		{toParse: `"\udc00"`, want: "\ufffd"},
+		{toParse: `"\udc00\ud800"`, want: "\ufffd\ufffd"},
 
 		{toParse: `"test"junk`, want: "test"},
 
 		{toParse: `5`, wantError: true},        // not a string
 		{toParse: `"\x"`, wantError: true},     // invalid escape
-		{toParse: `"\ud800"`, wantError: true}, // invalid utf-8 char
+		{toParse: `"\uZZZZ"`, wantError: true}, // invalid hex digits
+		{toParse: `"\u12"`, wantError: true},   // too few hex digits
+		{toParse: `"\ud800\uZZZZ"`, wantError: true},
 	} {
 		l := Lexer{Data: []byte(test.toParse)}
 
@@ -39,6 +55,101 @@ func TestString(t *testing.T) {
 	}
 }
 
+// escapedStrings returns an array of n string literals with escapes.
+func escapedStrings(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"line\tone\nline \"two\" \u00e9"`)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
+
+func TestStringReusedBuffer(t *testing.T) {
+	long := strings.Repeat("x", 2*maxScratchLen)
+	l := Lexer{Data: []byte(`{"k\u0031":"a\tb","k\u0032":"c\td","k3":1,"k\u0034":"` + long + `\n","k5":"e\tf"}`)}
+
+	l.Delim('{')
+	key1 := l.UnsafeString()
+	l.WantColon()
+	v1 := l.String()
+	l.WantComma()
+	key2 := l.FetchKeyBytes()
+	v2 := l.UnsafeString()
+	l.WantComma()
+	key3 := l.String()
+	l.WantColon()
+	v3 := l.Int()
+	l.WantComma()
+	key4 := l.String()
+	l.WantColon()
+	v4 := l.String()
+	l.WantComma()
+	key5 := l.String()
+	l.WantColon()
+	v5 := l.String()
+	l.WantComma()
+	l.Delim('}')
+
+	if err := l.Error(); err != nil {
+		t.Fatalf("Error() = %v", err)
+	}
+	got := []interface{}{key1, v1, string(key2), v2, key3, v3, key4, v4, key5, v5}
+	want := []interface{}{"k1", "a\tb", "k2", "c\td", "k3", 1, "k4", long + "\n", "k5", "e\tf"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded %q; want %q", got, want)
+	}
+
+	// The key kept for the error context is not overwritten by the value.
+	l = Lexer{Data: []byte(`{"k\u0031":"v\ta"}`)}
+	l.Delim('{')
+	_ = l.String()
+	l.WantColon()
+	l.Int()
+	var err *TypeMismatchError
+	if !errors.As(l.Error(), &err) || err.Field != "k1" {
+		t.Errorf("Int() error = %v; want one for field k1", l.Error())
+	}
+}
+
+func TestStringAllocs(t *testing.T) {
+	const n = 100
+	data := escapedStrings(n)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		l := Lexer{Data: data}
+		l.Delim('[')
+		for !l.IsDelim(']') {
+			_ = l.String()
+			l.WantComma()
+		}
+		l.Delim(']')
+	})
+	// A string per literal and the reused unescaping buffer.
+	if allocs > n+1 {
+		t.Errorf("String() of %v literals with escapes: %v allocs; want at most %v", n, allocs, n+1)
+	}
+}
+
+func BenchmarkEscapedStrings(b *testing.B) {
+	data := escapedStrings(1000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := Lexer{Data: data}
+		l.Delim('[')
+		for !l.IsDelim(']') {
+			_ = l.String()
+			l.WantComma()
+		}
+		l.Delim(']')
+	}
+}
+
 func TestNumber(t *testing.T) {
 	for i, test := range []struct {
 		toParse   string
@@ -52,12 +163,31 @@ func TestNumber(t *testing.T) {
 		{toParse: "12.35e-15", want: "12.35e-15"},
 		{toParse: "12.35E-15", want: "12.35E-15"},
 		{toParse: "12.35E15", want: "12.35E15"},
+		{toParse: "0", want: "0"},
+		{toParse: "-0", want: "-0"},
+		{toParse: "0.5", want: "0.5"},
+		{toParse: "1.5e-3", want: "1.5e-3"},
+		{toParse: "1e5", want: "1e5"},
+		{toParse: "-0E+0", want: "-0E+0"},
+		{toParse: "1000 ", want: "1000"},
 
 		{toParse: `"a"`, wantError: true},
 		{toParse: "123junk", wantError: true},
 		{toParse: "1.2.3", wantError: true},
 		{toParse: "1e2e3", wantError: true},
 		{toParse: "1e2.3", wantError: true},
+		{toParse: "01", wantError: true},
+		{toParse: "-01", wantError: true},
+		{toParse: "00", wantError: true},
+		{toParse: ".5", wantError: true},
+		{toParse: "1.", wantError: true},
+		{toParse: "1.e5", wantError: true},
+		{toParse: "1e", wantError: true},
+		{toParse: "1e+", wantError: true},
+		{toParse: "-", wantError: true},
+		{toParse: "-.5", wantError: true},
+		{toParse: "+1", wantError: true},
+		{toParse: "1-2", wantError: true},
 	} {
 		l := Lexer{Data: []byte(test.toParse)}
 
@@ -74,6 +204,286 @@ func TestNumber(t *testing.T) {
 	}
 }
 
+func TestNumberWhitespace(t *testing.T) {
+	for i, test := range []struct {
+		toParse    string
+		want       int
+		wantOffset int
+	}{
+		{toParse: "1000", want: 1000, wantOffset: -1},
+		{toParse: "1 0", wantOffset: 1},
+		{toParse: "1\t0", wantOffset: 1},
+		{toParse: "1 \n 000", wantOffset: 1},
+		{toParse: "  12  34", wantOffset: 4},
+	} {
+		l := Lexer{Data: []byte(test.toParse), StrictNumbers: true}
+
+		got := l.Int()
+		if got != test.want {
+			t.Errorf("[%d, %q] Int() = %v; want %v", i, test.toParse, got, test.want)
+		}
+		err, _ := l.Error().(*LexerError)
+		if test.wantOffset < 0 {
+			if l.Error() != nil {
+				t.Errorf("[%d, %q] Int() error: %v", i, test.toParse, l.Error())
+			}
+			continue
+		}
+		if err == nil || err.Reason != "whitespace inside a number" || err.Offset != test.wantOffset {
+			t.Errorf("[%d, %q] Int() error = %v; want whitespace inside a number at %v", i, test.toParse, l.Error(), test.wantOffset)
+		}
+	}
+}
+
+func TestNumberWhitespaceLenient(t *testing.T) {
+	// Top-level values may be separated by whitespace, other input is still malformed.
+	for i, test := range []struct {
+		toParse   string
+		wantError bool
+	}{
+		{toParse: "1 0"},
+		{toParse: "[1 0]", wantError: true},
+		{toParse: `{"a":1 0}`, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+		l.Interface()
+
+		err := l.Error()
+		if !test.wantError && err != nil {
+			t.Errorf("[%d, %q] Interface() error: %v", i, test.toParse, err)
+		} else if test.wantError && err == nil {
+			t.Errorf("[%d, %q] Interface() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestIntegerExponent(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		want      int64
+		wantError bool
+	}{
+		{toParse: "1000", want: 1000},
+		{toParse: "1e3", want: 1000},
+		{toParse: "1E+3", want: 1000},
+		{toParse: "-2e2", want: -200},
+		{toParse: "150e-1", want: 15},
+		{toParse: "1.5e1", want: 15},
+		{toParse: "0e-5", want: 0},
+		{toParse: "-0e0", want: 0},
+		{toParse: "9.223372036854775807e18", want: math.MaxInt64},
+
+		{toParse: "15e-1", wantError: true},
+		{toParse: "1.25e1", wantError: true},
+		{toParse: "1e-1", wantError: true},
+		{toParse: "1e19", want: math.MaxInt64, wantError: true},
+		{toParse: "1e1000000000000", wantError: true},
+		{toParse: "1.5", wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.Int64()
+		if got != test.want {
+			t.Errorf("[%d, %q] Int64() = %v; want %v", i, test.toParse, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] Int64() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] Int64() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestUintExponent(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		want      uint8
+		wantError bool
+	}{
+		{toParse: "2.55e2", want: 255},
+		{toParse: "1e2", want: 100},
+
+		{toParse: "2.56e2", want: math.MaxUint8, wantError: true},
+		{toParse: "-1e2", wantError: true},
+		{toParse: "25e-1", wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.Uint8()
+		if got != test.want {
+			t.Errorf("[%d, %q] Uint8() = %v; want %v", i, test.toParse, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] Uint8() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] Uint8() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestNumberUnderscores(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		allow     bool
+		want      int64
+		wantError bool
+	}{
+		{toParse: "1_000", allow: true, want: 1000},
+		{toParse: "-1_000_000", allow: true, want: -1000000},
+		{toParse: "1000", allow: true, want: 1000},
+
+		{toParse: "1__0", allow: true, wantError: true},
+		{toParse: "_1", allow: true, wantError: true},
+		{toParse: "1_", allow: true, wantError: true},
+		{toParse: "-_1", allow: true, wantError: true},
+
+		{toParse: "1_000", allow: false, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse), AllowUnderscoreInNumbers: test.allow}
+
+		got := l.Int64()
+		if got != test.want {
+			t.Errorf("[%d, %q] Int64() = %v; want %v", i, test.toParse, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] Int64() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] Int64() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestMaxTokenLen(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		isString  bool
+		wantError bool
+	}{
+		{toParse: `"12345"`, isString: true},
+		{toParse: `"123456"`, isString: true, wantError: true},
+		{toParse: `"\n\n\n\n\n"`, isString: true},
+		{toParse: `"\n\n\n\n\n\n"`, isString: true, wantError: true},
+		{toParse: `"123456`, isString: true, wantError: true},
+
+		{toParse: "12345"},
+		{toParse: "123456", wantError: true},
+		{toParse: "-1.5e1", wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse), MaxStringLen: 5, MaxNumberLen: 5}
+
+		if test.isString {
+			_ = l.String()
+		} else {
+			l.Float64()
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] ok; want error", i, test.toParse)
+		}
+	}
+
+	l := Lexer{Data: []byte("1" + strings.Repeat("0", DefaultMaxNumberLen))}
+	if l.Float64(); l.Error() == nil {
+		t.Errorf("Float64() of a number longer than default limit ok; want error")
+	}
+}
+
+func TestFixedDecimal(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		scale     int
+		want      int64
+		wantError bool
+	}{
+		{toParse: "12.34", scale: 2, want: 1234},
+		{toParse: "12.3400", scale: 2, want: 1234},
+		{toParse: "12.3", scale: 2, want: 1230},
+		{toParse: "12", scale: 2, want: 1200},
+		{toParse: "-0.05", scale: 2, want: -5},
+		{toParse: "0", scale: 2, want: 0},
+		{toParse: "0.000", scale: 2, want: 0},
+		{toParse: "1.5e2", scale: 2, want: 15000},
+		{toParse: "1234E-2", scale: 2, want: 1234},
+		{toParse: "1234e-4", scale: 2, wantError: true},
+		{toParse: "1200e-4", scale: 2, want: 12},
+		{toParse: "5e-10", scale: 2, wantError: true},
+		{toParse: "0e-10", scale: 2, want: 0},
+		{toParse: "-922337203685477.5808", scale: 4, want: math.MinInt64},
+
+		{toParse: "12.345", scale: 2, wantError: true},
+		{toParse: "1e300", scale: 2, wantError: true},
+		{toParse: "92233720368547758.08", scale: 2, wantError: true},
+		{toParse: `"12.34"`, scale: 2, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.FixedDecimal(test.scale)
+		if got != test.want {
+			t.Errorf("[%d, %q] FixedDecimal(%d) = %v; want %v", i, test.toParse, test.scale, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] FixedDecimal(%d) error: %v", i, test.toParse, test.scale, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] FixedDecimal(%d) ok; want error", i, test.toParse, test.scale)
+		}
+	}
+}
+
+func TestFixedDecimalRoundTrip(t *testing.T) {
+	for _, scale := range []int{0, 2, 3, 20} {
+		for _, n := range []int64{0, 5, -5, 1234, -1000, math.MaxInt64, math.MinInt64} {
+			w := jwriter.Writer{}
+			w.FixedDecimal(n, scale)
+			data := w.Buffer.BuildBytes()
+
+			l := Lexer{Data: data}
+			got := l.FixedDecimal(scale)
+			if err := l.Error(); err != nil || got != n {
+				t.Errorf("FixedDecimal(%d) of %s = %v, %v; want %v", scale, data, got, err, n)
+			}
+		}
+	}
+}
+
+func TestUnquoteNumber(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		want      int
+		wantError bool
+	}{
+		{toParse: "123", want: 123},
+		{toParse: `"123"`, want: 123},
+		{toParse: `"-5"`, want: -5},
+
+		{toParse: `""`, wantError: true},
+		{toParse: `"12a"`, wantError: true},
+		{toParse: `"1.5"`, wantError: true},
+		{toParse: `"01"`, wantError: true},
+		{toParse: `" 1"`, wantError: true},
+		{toParse: "true", wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		l.UnquoteNumber()
+		got := l.Int()
+		if got != test.want {
+			t.Errorf("[%d, %q] Int() = %v; want %v", i, test.toParse, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] Int() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] Int() ok; want error", i, test.toParse)
+		}
+	}
+}
+
 func TestBool(t *testing.T) {
 	for i, test := range []struct {
 		toParse   string
@@ -104,6 +514,455 @@ func TestBool(t *testing.T) {
 	}
 }
 
+func TestBoolStr(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		want      bool
+		wantError bool
+	}{
+		{toParse: `"true"`, want: true},
+		{toParse: `"false"`, want: false},
+
+		{toParse: "true", wantError: true},
+		{toParse: `"yes"`, wantError: true},
+		{toParse: `"True"`, wantError: true},
+		{toParse: `"1"`, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.BoolStr()
+		if got != test.want {
+			t.Errorf("[%d, %q] BoolStr() = %v; want %v", i, test.toParse, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] BoolStr() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] BoolStr() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestIntBool(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		want      bool
+		wantError bool
+	}{
+		{toParse: `1`, want: true},
+		{toParse: `0`, want: false},
+		{toParse: `true`, want: true},
+		{toParse: ` false`, want: false},
+
+		{toParse: `2`, wantError: true},
+		{toParse: `-1`, wantError: true},
+		{toParse: `01`, wantError: true},
+		{toParse: `1.0`, wantError: true},
+		{toParse: `"1"`, wantError: true},
+		{toParse: `null`, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.IntBool()
+		if got != test.want {
+			t.Errorf("[%d, %q] IntBool() = %v; want %v", i, test.toParse, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] IntBool() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] IntBool() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestRunLength(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		n, max    int
+		maxTokens int
+		want      int
+		wantError bool
+	}{
+		{toParse: "1", want: 1},
+		{toParse: "1000", n: 5, want: 1000},
+		{toParse: "10", n: 90, max: 100, want: 10},
+		{toParse: "3", maxTokens: 5, want: 3},
+
+		{toParse: "0", wantError: true},
+		{toParse: "-1", wantError: true},
+		{toParse: "11", n: 90, max: 100, wantError: true},
+		{toParse: "99999999999", wantError: true},
+		{toParse: "100000", wantError: true},
+		{toParse: "10", maxTokens: 5, wantError: true},
+		{toParse: `"1"`, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse), MaxRunLength: test.max, Budget: Budget{MaxTokens: test.maxTokens}}
+
+		got := l.RunLength(test.n)
+		if got != test.want {
+			t.Errorf("[%d, %q] RunLength(%v) = %v; want %v", i, test.toParse, test.n, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] RunLength(%v) error: %v", i, test.toParse, test.n, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] RunLength(%v) ok; want error", i, test.toParse, test.n)
+		}
+	}
+}
+
+func TestUint32sLE(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		want      []uint32
+		wantError bool
+	}{
+		{toParse: `""`},
+		{toParse: `"AQAAAA=="`, want: []uint32{1}},
+		{toParse: `"AQAAAP////8EAwIB"`, want: []uint32{1, 0xffffffff, 0x01020304}},
+
+		{toParse: `"AQAAAAI="`, wantError: true},
+		{toParse: `"AQ=="`, wantError: true},
+		{toParse: `"not base64!"`, wantError: true},
+		{toParse: `[1]`, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.Uint32sLE()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %q] Uint32sLE() = %v; want %v", i, test.toParse, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] Uint32sLE() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] Uint32sLE() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		want      []byte
+		wantError bool
+	}{
+		{toParse: `""`, want: []byte{}},
+		{toParse: `"AQID"`, want: []byte{1, 2, 3}},
+		{toParse: `"/w=="`, want: []byte{0xff}},
+		{toParse: `"\/w=="`, want: []byte{0xff}},
+
+		{toParse: `"/w"`, wantError: true},
+		{toParse: `"not base64!"`, wantError: true},
+		{toParse: `[1]`, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.Bytes()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %q] Bytes() = %v; want %v", i, test.toParse, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] Bytes() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] Bytes() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestStringDocument(t *testing.T) {
+	l := Lexer{Data: []byte(`"[1_000, \"x\"]"`), AllowUnderscoreInNumbers: true}
+
+	doc := l.StringDocument()
+	doc.Delim('[')
+	n := doc.Int()
+	doc.WantComma()
+	s := doc.String()
+	doc.WantComma()
+	doc.Delim(']')
+
+	if err := doc.Error(); err != nil {
+		t.Errorf("StringDocument() error: %v", err)
+	}
+	if n != 1000 || s != "x" {
+		t.Errorf("StringDocument() = [%v, %q]; want [1000, \"x\"]", n, s)
+	}
+	if len(l.Remaining()) != 0 {
+		t.Errorf("Remaining() after StringDocument() = %q; want empty", l.Remaining())
+	}
+}
+
+func TestUint128Str(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		hi, lo    uint64
+		wantError bool
+	}{
+		{toParse: `"0"`},
+		{toParse: `"0018446744073709551615"`, lo: math.MaxUint64},
+		{toParse: `"18446744073709551616"`, hi: 1},
+		{toParse: `"184467440737095516160000000000000000005"`, hi: 0x8ac7230489e80000, lo: 5},
+		{toParse: `"340282366920938463463374607431768211455"`, hi: math.MaxUint64, lo: math.MaxUint64},
+
+		{toParse: `"340282366920938463463374607431768211456"`, wantError: true},
+		{toParse: `"3402823669209384634633746074317682114550"`, wantError: true},
+		{toParse: `""`, wantError: true},
+		{toParse: `"-1"`, wantError: true},
+		{toParse: `"+1"`, wantError: true},
+		{toParse: `"1e3"`, wantError: true},
+		{toParse: `12`, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		hi, lo := l.Uint128Str()
+		if hi != test.hi || lo != test.lo {
+			t.Errorf("[%d, %q] Uint128Str() = %#x, %#x; want %#x, %#x", i, test.toParse, hi, lo, test.hi, test.lo)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] Uint128Str() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] Uint128Str() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestInt128Str(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		hi        int64
+		lo        uint64
+		wantError bool
+	}{
+		{toParse: `"0"`},
+		{toParse: `"-0"`},
+		{toParse: `"-1"`, hi: -1, lo: math.MaxUint64},
+		{toParse: `"-18446744073709551616"`, hi: -1},
+		{toParse: `"-100000000000000000001"`, hi: -6, lo: 0x9438a1d29cefffff},
+		{toParse: `"170141183460469231731687303715884105727"`, hi: math.MaxInt64, lo: math.MaxUint64},
+		{toParse: `"-170141183460469231731687303715884105728"`, hi: math.MinInt64},
+
+		{toParse: `"170141183460469231731687303715884105728"`, wantError: true},
+		{toParse: `"-170141183460469231731687303715884105729"`, wantError: true},
+		{toParse: `"-"`, wantError: true},
+		{toParse: `"--1"`, wantError: true},
+		{toParse: `-1`, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		hi, lo := l.Int128Str()
+		if hi != test.hi || lo != test.lo {
+			t.Errorf("[%d, %q] Int128Str() = %d, %#x; want %d, %#x", i, test.toParse, hi, lo, test.hi, test.lo)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] Int128Str() error: %v", i, test.toParse, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] Int128Str() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestIntHexStr(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		bitSize   int
+		want      int64
+		wantError bool
+	}{
+		{toParse: `"0x0"`, bitSize: 64, want: 0},
+		{toParse: `"0xff"`, bitSize: 64, want: 255},
+		{toParse: `"0XFF"`, bitSize: 64, want: 255},
+		{toParse: `"0xaBc"`, bitSize: 64, want: 0xabc},
+		{toParse: `"-0x1f"`, bitSize: 64, want: -31},
+		{toParse: `"-0x80"`, bitSize: 8, want: -128},
+		{toParse: `"-0x8000000000000000"`, bitSize: 64, want: math.MinInt64},
+
+		{toParse: `"0x80"`, bitSize: 8, wantError: true},
+		{toParse: `"ff"`, bitSize: 64, wantError: true},
+		{toParse: `"0x"`, bitSize: 64, wantError: true},
+		{toParse: `"0x-1"`, bitSize: 64, wantError: true},
+		{toParse: `"0x+1"`, bitSize: 64, wantError: true},
+		{toParse: `"0xfg"`, bitSize: 64, wantError: true},
+		{toParse: `255`, bitSize: 64, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.IntHexStr(test.bitSize)
+		if got != test.want {
+			t.Errorf("[%d, %q] IntHexStr(%d) = %v; want %v", i, test.toParse, test.bitSize, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] IntHexStr(%d) error: %v", i, test.toParse, test.bitSize, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] IntHexStr(%d) ok; want error", i, test.toParse, test.bitSize)
+		}
+	}
+}
+
+func TestUintHexStr(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		bitSize   int
+		want      uint64
+		wantError bool
+	}{
+		{toParse: `"0x0"`, bitSize: 64, want: 0},
+		{toParse: `"0xFf"`, bitSize: 8, want: 255},
+		{toParse: `"0xffffffffffffffff"`, bitSize: 64, want: math.MaxUint64},
+
+		{toParse: `"0x100"`, bitSize: 8, wantError: true},
+		{toParse: `"-0x1"`, bitSize: 64, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.UintHexStr(test.bitSize)
+		if got != test.want {
+			t.Errorf("[%d, %q] UintHexStr(%d) = %v; want %v", i, test.toParse, test.bitSize, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] UintHexStr(%d) error: %v", i, test.toParse, test.bitSize, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] UintHexStr(%d) ok; want error", i, test.toParse, test.bitSize)
+		}
+	}
+}
+
+func TestIntGroupedStr(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		bitSize   int
+		want      int64
+		wantError bool
+	}{
+		{toParse: `"0"`, bitSize: 64, want: 0},
+		{toParse: `"999"`, bitSize: 64, want: 999},
+		{toParse: `"1,234,567"`, bitSize: 64, want: 1234567},
+		{toParse: `"-12,345"`, bitSize: 64, want: -12345},
+		{toParse: `"1234567"`, bitSize: 64, want: 1234567},
+		{toParse: `"-9,223,372,036,854,775,808"`, bitSize: 64, want: math.MinInt64},
+
+		{toParse: `"32,768"`, bitSize: 16, wantError: true},
+		{toParse: `"1,23"`, bitSize: 64, wantError: true},
+		{toParse: `"1234,567"`, bitSize: 64, wantError: true},
+		{toParse: `",123"`, bitSize: 64, wantError: true},
+		{toParse: `"1,,234"`, bitSize: 64, wantError: true},
+		{toParse: `"1.234"`, bitSize: 64, wantError: true},
+		{toParse: `""`, bitSize: 64, wantError: true},
+		{toParse: `1234`, bitSize: 64, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.IntGroupedStr(test.bitSize)
+		if got != test.want {
+			t.Errorf("[%d, %q] IntGroupedStr(%d) = %v; want %v", i, test.toParse, test.bitSize, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] IntGroupedStr(%d) error: %v", i, test.toParse, test.bitSize, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] IntGroupedStr(%d) ok; want error", i, test.toParse, test.bitSize)
+		}
+	}
+}
+
+func TestUintGroupedStr(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		bitSize   int
+		want      uint64
+		wantError bool
+	}{
+		{toParse: `"7"`, bitSize: 8, want: 7},
+		{toParse: `"123,456"`, bitSize: 32, want: 123456},
+		{toParse: `"18,446,744,073,709,551,615"`, bitSize: 64, want: math.MaxUint64},
+
+		{toParse: `"1,000"`, bitSize: 8, wantError: true},
+		{toParse: `"-1"`, bitSize: 64, wantError: true},
+		{toParse: `"12,34"`, bitSize: 64, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.UintGroupedStr(test.bitSize)
+		if got != test.want {
+			t.Errorf("[%d, %q] UintGroupedStr(%d) = %v; want %v", i, test.toParse, test.bitSize, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] UintGroupedStr(%d) error: %v", i, test.toParse, test.bitSize, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] UintGroupedStr(%d) ok; want error", i, test.toParse, test.bitSize)
+		}
+	}
+}
+
+func TestIntPaddedStr(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		bitSize   int
+		want      int64
+		wantError bool
+	}{
+		{toParse: `"00000042"`, bitSize: 64, want: 42},
+		{toParse: `"-0000042"`, bitSize: 64, want: -42},
+		{toParse: `"000"`, bitSize: 8, want: 0},
+		{toParse: `"123456789"`, bitSize: 32, want: 123456789},
+		{toParse: `"0000000127"`, bitSize: 8, want: 127},
+
+		{toParse: `"00000128"`, bitSize: 8, wantError: true},
+		{toParse: `"+0000042"`, bitSize: 64, wantError: true},
+		{toParse: `" 42"`, bitSize: 64, wantError: true},
+		{toParse: `"-"`, bitSize: 64, wantError: true},
+		{toParse: `""`, bitSize: 64, wantError: true},
+		{toParse: `42`, bitSize: 64, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.IntPaddedStr(test.bitSize)
+		if got != test.want {
+			t.Errorf("[%d, %q] IntPaddedStr(%d) = %v; want %v", i, test.toParse, test.bitSize, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] IntPaddedStr(%d) error: %v", i, test.toParse, test.bitSize, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] IntPaddedStr(%d) ok; want error", i, test.toParse, test.bitSize)
+		}
+	}
+}
+
+func TestUintPaddedStr(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		bitSize   int
+		want      uint64
+		wantError bool
+	}{
+		{toParse: `"0007"`, bitSize: 8, want: 7},
+		{toParse: `"000018446744073709551615"`, bitSize: 64, want: math.MaxUint64},
+
+		{toParse: `"-0000001"`, bitSize: 64, wantError: true},
+		{toParse: `"0256"`, bitSize: 8, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got := l.UintPaddedStr(test.bitSize)
+		if got != test.want {
+			t.Errorf("[%d, %q] UintPaddedStr(%d) = %v; want %v", i, test.toParse, test.bitSize, got, test.want)
+		}
+		err := l.Error()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] UintPaddedStr(%d) error: %v", i, test.toParse, test.bitSize, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] UintPaddedStr(%d) ok; want error", i, test.toParse, test.bitSize)
+		}
+	}
+}
+
 func TestSkipRecursive(t *testing.T) {
 	for i, test := range []struct {
 		toParse   string
@@ -146,6 +1005,351 @@ func TestSkipRecursive(t *testing.T) {
 	}
 }
 
+func TestIsEmptyString(t *testing.T) {
+	for i, test := range []struct {
+		toParse string
+		want    bool
+	}{
+		{toParse: `""`, want: true},
+		{toParse: ` "" `, want: true},
+		{toParse: `" "`, want: false},
+		{toParse: `"0"`, want: false},
+		{toParse: `0`, want: false},
+		{toParse: `null`, want: false},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		if got := l.IsEmptyString(); got != test.want {
+			t.Errorf("[%d, %q] IsEmptyString() = %v; want %v", i, test.toParse, got, test.want)
+		}
+	}
+}
+
+func TestValueOffsets(t *testing.T) {
+	for i, test := range []struct {
+		toParse string
+		want    string
+	}{
+		{toParse: `{"a": [1, {"b":"]"}] , "c":3}`, want: `[1, {"b":"]"}]`},
+		{toParse: `{"a":  "x\"y" }`, want: `"x\"y"`},
+		{toParse: `{"a":12.5}`, want: `12.5`},
+		{toParse: `{"a" : null}`, want: `null`},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		l.Delim('{')
+		l.UnsafeString()
+		l.WantColon()
+		start := l.ValueStart()
+		l.SkipRecursive()
+
+		got := string(l.Data[start:l.ValueEnd()])
+		if got != test.want {
+			t.Errorf("[%d, %q] ValueStart()/ValueEnd() = %q; want %q", i, test.toParse, got, test.want)
+		}
+		if err := l.Error(); err != nil {
+			t.Errorf("[%d, %q] error: %v", i, test.toParse, err)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	tiny := "[" + strings.Repeat("1,", 999) + "1]"
+	for i, test := range []struct {
+		toParse string
+		budget  Budget
+		want    string
+	}{
+		{toParse: tiny},
+		{toParse: tiny, budget: Budget{MaxBytes: len(tiny), MaxTokens: 1002}},
+		{toParse: tiny, budget: Budget{MaxBytes: len(tiny), MaxTokens: 100}, want: "token budget exceeded"},
+		{toParse: tiny, budget: Budget{MaxBytes: 1000, MaxTokens: 1001}, want: "byte budget exceeded"},
+		{toParse: `["` + strings.Repeat("x", 100) + `"]`, budget: Budget{MaxBytes: 50, MaxTokens: 10}, want: "byte budget exceeded"},
+		{toParse: `[{"a":[1,2,3,4,5]}]`, budget: Budget{MaxTokens: 3}},
+		{toParse: `[{"a":[1,2,3,4,5]}]`, budget: Budget{MaxBytes: 10}, want: "byte budget exceeded"},
+	} {
+		l := Lexer{Data: []byte(test.toParse), Budget: test.budget}
+
+		l.Delim('[')
+		for l.Ok() && !l.IsDelim(']') {
+			if l.IsDelim('{') {
+				l.SkipRecursive()
+			} else {
+				l.Interface()
+			}
+			l.WantComma()
+		}
+		l.Delim(']')
+
+		err := l.Error()
+		if test.want == "" && err != nil {
+			t.Errorf("[%d, %v] error: %v", i, test.budget, err)
+		} else if test.want != "" && (err == nil || err.(*LexerError).Reason != test.want) {
+			t.Errorf("[%d, %v] error = %v; want %q", i, test.budget, err, test.want)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	for i, test := range []struct {
+		toParse string
+		skip    bool
+		want    Stats
+	}{
+		{toParse: `1.5`, want: Stats{Numbers: 1, Bytes: 3}},
+		{toParse: ` {"a": [1, "x", true, null], "b": {}} `, want: Stats{Strings: 3, Numbers: 1, Bools: 1, Nulls: 1, Objects: 2, Arrays: 1, Bytes: 37}},
+		{toParse: `[[], [false, 2], {"c": 3}]`, want: Stats{Strings: 1, Numbers: 2, Bools: 1, Objects: 1, Arrays: 3, Bytes: 26}},
+		{toParse: `{"a": [1, 2, 3]}`, skip: true, want: Stats{Objects: 1, Bytes: 16}},
+	} {
+		var stats Stats
+		l := Lexer{Data: []byte(test.toParse), Stats: &stats}
+		if test.skip {
+			l.SkipRecursive()
+		} else {
+			l.Interface()
+		}
+
+		if err := l.Error(); err != nil {
+			t.Errorf("[%d, %q] error: %v", i, test.toParse, err)
+		}
+		if stats != test.want {
+			t.Errorf("[%d, %q] Stats = %+v; want %+v", i, test.toParse, stats, test.want)
+		}
+	}
+}
+
+func TestStringDocumentBudget(t *testing.T) {
+	data := []byte(`["[1, 2, 3]", 4]`)
+	decode := func(l *Lexer) {
+		l.Delim('[')
+		doc := l.StringDocument()
+		doc.Interface()
+		l.AddError(doc.Error())
+		l.WantComma()
+		l.Int()
+		l.WantComma()
+		l.Delim(']')
+	}
+
+	var stats Stats
+	l := Lexer{Data: data, Stats: &stats}
+	decode(&l)
+	if err := l.Error(); err != nil {
+		t.Errorf("error: %v", err)
+	}
+	if want := (Stats{Strings: 1, Numbers: 4, Arrays: 2, Bytes: len(data) + len("[1, 2, 3]")}); stats != want {
+		t.Errorf("Stats = %+v; want %+v", stats, want)
+	}
+
+	for i, test := range []struct {
+		budget Budget
+		want   string
+	}{
+		{budget: Budget{MaxTokens: 9, MaxBytes: 25}},
+		{budget: Budget{MaxTokens: 5}, want: "token budget exceeded"},
+		{budget: Budget{MaxTokens: 8}, want: "token budget exceeded"},
+		{budget: Budget{MaxBytes: 20}, want: "byte budget exceeded"},
+	} {
+		l := Lexer{Data: data, Budget: test.budget}
+		decode(&l)
+
+		err := l.Error()
+		if test.want == "" && err != nil {
+			t.Errorf("[%d, %v] error: %v", i, test.budget, err)
+		} else if test.want != "" && (err == nil || err.(*LexerError).Reason != test.want) {
+			t.Errorf("[%d, %v] error = %v; want %q", i, test.budget, err, test.want)
+		}
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	for i, test := range []struct {
+		toParse string
+		want    []interface{}
+	}{
+		{
+			toParse: `{"a":1}{"b":2}`,
+			want:    []interface{}{map[string]interface{}{"a": 1.0}, map[string]interface{}{"b": 2.0}},
+		},
+		{
+			toParse: ` [1, "x"]  {"b":{}} null "s"`,
+			want:    []interface{}{[]interface{}{1.0, "x"}, map[string]interface{}{"b": map[string]interface{}{}}, nil, "s"},
+		},
+		{
+			toParse: `{"a":1}`,
+			want:    []interface{}{map[string]interface{}{"a": 1.0}},
+		},
+		{
+			toParse: "1\n2\n3",
+			want:    []interface{}{1.0, 2.0, 3.0},
+		},
+	} {
+		var got []interface{}
+		data := []byte(test.toParse)
+		for len(bytes.TrimSpace(data)) > 0 {
+			l := Lexer{Data: data}
+			v := l.Interface()
+			if err := l.Error(); err != nil {
+				t.Errorf("[%d, %q] Interface() error: %v", i, test.toParse, err)
+				break
+			}
+			got = append(got, v)
+			data = l.Remaining()
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %q] values = %v; want %v", i, test.toParse, got, test.want)
+		}
+	}
+}
+
+func TestRemainingUnconsumed(t *testing.T) {
+	l := Lexer{Data: []byte(`[1] null`)}
+	l.Delim('[')
+	l.Int()
+	if got, want := string(l.Remaining()), "] null"; got != want {
+		t.Errorf("Remaining() = %q; want %q", got, want)
+	}
+	l.Delim(']')
+	l.IsNull()
+	if got, want := string(l.Remaining()), "null"; got != want {
+		t.Errorf("Remaining() after IsNull() = %q; want %q", got, want)
+	}
+	l.Null()
+	if got := string(l.Remaining()); got != "" {
+		t.Errorf("Remaining() at the end = %q; want empty", got)
+	}
+}
+
+func TestConsumed(t *testing.T) {
+	for i, test := range []struct {
+		toParse string
+		wantErr bool
+	}{
+		{toParse: `{"a":1}`},
+		{toParse: "[1] \n\t"},
+		{toParse: `{"a":1} x`, wantErr: true},
+		{toParse: `{"a":1}{"a":2}`, wantErr: true},
+		{toParse: `[1],`, wantErr: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+		l.Interface()
+		l.Consumed()
+
+		err := l.Error()
+		if !test.wantErr && err != nil {
+			t.Errorf("[%d, %q] Consumed() error: %v", i, test.toParse, err)
+		} else if test.wantErr && err == nil {
+			t.Errorf("[%d, %q] Consumed() ok; want error", i, test.toParse)
+		}
+	}
+}
+
+func TestPeekObjectField(t *testing.T) {
+	for i, test := range []struct {
+		toParse string
+		want    string
+		wantOk  bool
+	}{
+		{toParse: `{"type":"circle","r":2}`, want: `"circle"`, wantOk: true},
+		{toParse: `{"r":{"type":"inner"}, "s":[1,{"type":2}] , "type" : "square" }`, want: `"square"`, wantOk: true},
+		{toParse: `{"\u0074ype":{"a":[1]}}`, want: `{"a":[1]}`, wantOk: true},
+		{toParse: `{"r":2}`},
+		{toParse: `{}`},
+		{toParse: `[{"type":"circle"}]`},
+		{toParse: `{"r":2,`},
+		{toParse: `{"type":`},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		got, ok := l.PeekObjectField("type")
+		if string(got) != test.want || ok != test.wantOk {
+			t.Errorf("[%d, %q] PeekObjectField() = %q, %v; want %q, %v", i, test.toParse, got, ok, test.want, test.wantOk)
+		}
+
+		// The lexer state is not changed by peeking.
+		want := Lexer{Data: []byte(test.toParse)}
+		if g, w := l.Interface(), want.Interface(); !reflect.DeepEqual(g, w) || !reflect.DeepEqual(l.Error(), want.Error()) {
+			t.Errorf("[%d, %q] Interface() after PeekObjectField() = %v, %v; want %v, %v", i, test.toParse, g, l.Error(), w, want.Error())
+		}
+	}
+}
+
+func TestPeekObjectFieldStats(t *testing.T) {
+	data := []byte(`{"x":[1,2],"type":"a"}`)
+
+	var want Stats
+	l := Lexer{Data: data, Stats: &want}
+	l.Interface()
+
+	var got Stats
+	l = Lexer{Data: data, Stats: &got, Budget: Budget{MaxTokens: 12}}
+	if _, ok := l.PeekObjectField("type"); !ok {
+		t.Errorf("PeekObjectField() not found")
+	}
+	l.Interface()
+	if err := l.Error(); err != nil {
+		t.Errorf("error: %v", err)
+	}
+	if got != want {
+		t.Errorf("Stats after PeekObjectField() = %+v; want %+v", got, want)
+	}
+}
+
+func TestPeekObjectFieldArray(t *testing.T) {
+	l := Lexer{Data: []byte(`[{"type":"a"}, {"x":1,"type":"b"}]`)}
+
+	var got []string
+	l.Delim('[')
+	for !l.IsDelim(']') {
+		typ, _ := l.PeekObjectField("type")
+		got = append(got, string(typ))
+		l.SkipRecursive()
+		l.WantComma()
+	}
+	l.Delim(']')
+
+	if err := l.Error(); err != nil {
+		t.Errorf("error: %v", err)
+	}
+	if want := []string{`"a"`, `"b"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PeekObjectField() = %q; want %q", got, want)
+	}
+}
+
+func TestFetchKeyBytes(t *testing.T) {
+	for i, test := range []struct {
+		toParse   string
+		want      []string
+		wantError bool
+	}{
+		{toParse: `{"a":1,"bc":2}`, want: []string{"a", "bc"}},
+		{toParse: `{ "a" : 1 , "\u0062" : 2 }`, want: []string{"a", "b"}},
+		{toParse: `{}`},
+		{toParse: `{"a" 1}`, want: []string{"a"}, wantError: true},
+		{toParse: `{1:1}`, wantError: true},
+	} {
+		l := Lexer{Data: []byte(test.toParse)}
+
+		var got []string
+		l.Delim('{')
+		for l.Ok() && !l.IsDelim('}') {
+			if key := l.FetchKeyBytes(); len(key) > 0 {
+				got = append(got, string(key))
+			}
+			l.SkipRecursive()
+			l.WantComma()
+		}
+		l.Delim('}')
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %q] FetchKeyBytes() = %q; want %q", i, test.toParse, got, test.want)
+		}
+		if err := l.Error(); (err != nil) != test.wantError {
+			t.Errorf("[%d, %q] FetchKeyBytes() error: %v; want error %v", i, test.toParse, err, test.wantError)
+		}
+	}
+}
+
 func TestInterface(t *testing.T) {
 	for i, test := range []struct {
 		toParse   string
@@ -158,7 +1362,7 @@ func TestInterface(t *testing.T) {
 		{toParse: "5", want: float64(5)},
 
 		{toParse: `{}`, want: map[string]interface{}{}},
-		{toParse: `[]`, want: []interface{}(nil)},
+		{toParse: `[]`, want: []interface{}{}},
 
 		{toParse: `{"a": "b"}`, want: map[string]interface{}{"a": "b"}},
 		{toParse: `[5]`, want: []interface{}{float64(5)}},
@@ -0,0 +1,99 @@
+package jlexer
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrPointerNotFound is returned by DecodeAtPointer if the document has no value at the pointer.
+var ErrPointerNotFound = errors.New("jlexer: no value at the JSON pointer")
+
+// DecodeAtPointer decodes the value at an RFC 6901 JSON Pointer in data, e.g. "/items/2/name",
+// into v, which is usually an easyjson.Unmarshaler. Values before the target are skipped with
+// SkipRecursive and the data after it is not scanned at all, so extracting a single value is
+// much cheaper than decoding the whole document. Pointer tokens match object keys (with ~0 and
+// ~1 escaping '~' and '/') or array indices. An empty pointer refers to the whole document.
+func DecodeAtPointer(data []byte, pointer string, v interface{ UnmarshalEasyJSON(*Lexer) }) error {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+
+	l := Lexer{Data: data}
+	for _, token := range tokens {
+		if !l.seekPointerToken(token) {
+			if err := l.Error(); err != nil {
+				return err
+			}
+			return ErrPointerNotFound
+		}
+	}
+
+	v.UnmarshalEasyJSON(&l)
+	return l.Error()
+}
+
+// parsePointer splits a JSON pointer into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, errors.New("jlexer: JSON pointer " + strconv.Quote(pointer) + " does not start with '/'")
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		if !strings.Contains(t, "~") {
+			continue
+		}
+		for j := 0; j < len(t); j++ {
+			if t[j] == '~' && (j+1 == len(t) || t[j+1] != '0' && t[j+1] != '1') {
+				return nil, errors.New("jlexer: invalid escape in JSON pointer " + strconv.Quote(pointer))
+			}
+		}
+		// ~1 is replaced first, so that ~01 becomes ~1 and not /.
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// seekPointerToken moves the lexer to the value of the object member or the array element
+// referred to by the pointer token in the next value. false is returned if there is none.
+func (r *Lexer) seekPointerToken(token string) bool {
+	switch {
+	case r.IsDelim('{'):
+		r.Delim('{')
+		for r.Ok() && !r.IsDelim('}') {
+			key := r.UnsafeString()
+			r.WantColon()
+			if r.Ok() && key == token {
+				return true
+			}
+			r.SkipRecursive()
+			r.WantComma()
+		}
+
+	case r.IsDelim('['):
+		// Indices are decimal numbers without leading zeros; "-" refers to the (nonexistent)
+		// element after the last one.
+		if token == "" || len(token) > 1 && token[0] == '0' || strings.Trim(token, "0123456789") != "" {
+			return false
+		}
+		index, err := strconv.Atoi(token)
+		if err != nil {
+			return false
+		}
+
+		r.Delim('[')
+		for i := 0; r.Ok() && !r.IsDelim(']'); i++ {
+			if i == index {
+				return true
+			}
+			r.SkipRecursive()
+			r.WantComma()
+		}
+	}
+	return false
+}
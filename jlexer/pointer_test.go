@@ -0,0 +1,123 @@
+package jlexer
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pointerValue decodes any value with Lexer.Interface.
+type pointerValue struct {
+	v interface{}
+}
+
+func (p *pointerValue) UnmarshalEasyJSON(l *Lexer) {
+	p.v = l.Interface()
+}
+
+// largeDocument returns a document with n items after a large skipped member.
+func largeDocument(n int) []byte {
+	var b strings.Builder
+	b.WriteString(`{"meta":{"skip":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`{"a":[1,2,{"b":"]}"}]}`)
+	}
+	b.WriteString(`]},"items":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`{"id":` + strconv.Itoa(i) + `,"name":"item` + strconv.Itoa(i) + `","tags":["x","y"]}`)
+	}
+	b.WriteString(`],"a/b":{"m~n":true,"":"empty"}}`)
+	return []byte(b.String())
+}
+
+func TestDecodeAtPointer(t *testing.T) {
+	doc := largeDocument(1000)
+	for i, test := range []struct {
+		pointer string
+		want    interface{}
+	}{
+		{pointer: "/items/2/name", want: "item2"},
+		{pointer: "/items/999/id", want: 999.0},
+		{pointer: "/items/0/tags/1", want: "y"},
+		{pointer: "/items/1/tags", want: []interface{}{"x", "y"}},
+		{pointer: "/a~1b/m~0n", want: true},
+		{pointer: "/a~1b/", want: "empty"},
+		{pointer: "", want: map[string]interface{}{"x": 1.0}},
+	} {
+		data := doc
+		if test.pointer == "" {
+			data = []byte(`{"x":1}`)
+		}
+
+		var got pointerValue
+		if err := DecodeAtPointer(data, test.pointer, &got); err != nil {
+			t.Errorf("[%d, %q] DecodeAtPointer() error: %v", i, test.pointer, err)
+		}
+		if !reflect.DeepEqual(got.v, test.want) {
+			t.Errorf("[%d, %q] DecodeAtPointer() = %v; want %v", i, test.pointer, got.v, test.want)
+		}
+	}
+}
+
+func TestDecodeAtPointerErrors(t *testing.T) {
+	doc := largeDocument(10)
+	for i, test := range []struct {
+		pointer  string
+		notFound bool
+	}{
+		{pointer: "/items/10/name", notFound: true},
+		{pointer: "/items/-", notFound: true},
+		{pointer: "/items/01", notFound: true},
+		{pointer: "/items/+1", notFound: true},
+		{pointer: "/items/name", notFound: true},
+		{pointer: "/missing", notFound: true},
+		{pointer: "/items/0/id/x", notFound: true},
+		{pointer: "items"},
+		{pointer: "/a~2b"},
+		{pointer: "/a~"},
+	} {
+		var got pointerValue
+		err := DecodeAtPointer(doc, test.pointer, &got)
+		if err == nil {
+			t.Errorf("[%d, %q] DecodeAtPointer() ok; want error", i, test.pointer)
+		} else if (err == ErrPointerNotFound) != test.notFound {
+			t.Errorf("[%d, %q] DecodeAtPointer() error = %v; want not found %v", i, test.pointer, err, test.notFound)
+		}
+	}
+
+	var got pointerValue
+	if err := DecodeAtPointer([]byte(`{"a":[1,}`), "/b", &got); err == nil || err == ErrPointerNotFound {
+		t.Errorf("DecodeAtPointer() on malformed data error = %v; want lexer error", err)
+	}
+}
+
+func BenchmarkDecodeAtPointer(b *testing.B) {
+	doc := largeDocument(1000)
+	b.SetBytes(int64(len(doc)))
+	for i := 0; i < b.N; i++ {
+		var v pointerValue
+		if err := DecodeAtPointer(doc, "/items/2/name", &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeFullDocument(b *testing.B) {
+	doc := largeDocument(1000)
+	b.SetBytes(int64(len(doc)))
+	for i := 0; i < b.N; i++ {
+		l := Lexer{Data: doc}
+		v := l.Interface().(map[string]interface{})
+		_ = v["items"].([]interface{})[2].(map[string]interface{})["name"]
+		if err := l.Error(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
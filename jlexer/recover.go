@@ -0,0 +1,119 @@
+package jlexer
+
+import "errors"
+
+// DecodeArrayRecover decodes the elements of a JSON array with the decode function, same as
+// DecodeArrayTail, but an element failing to decode does not abort the whole array: the error
+// is reported to onError with the index of the element, the element is skipped up to the
+// following ',' or the closing ']' at its nesting level, and decoding resumes with the next
+// element. Only errors of the array itself, e.g. a missing ']', are returned.
+func DecodeArrayRecover[T any](data []byte, decode func(*Lexer) (T, error), onError func(index int, err error)) ([]T, error) {
+	l := Lexer{Data: data}
+	var ret []T
+
+	l.Delim('[')
+	// The nesting depth of the elements, restored after a failed element is skipped.
+	depth := l.depth
+	for i := 0; ; i++ {
+		if l.IsDelim(']') {
+			if l.Ok() || !l.isMalformedElement() {
+				break
+			}
+			// The first token of the element is malformed, so decode is not called at all.
+			onError(i, l.Error())
+			if !l.skipElement(l.start, depth) {
+				break
+			}
+			continue
+		}
+
+		start := l.start
+		v, err := decode(&l)
+		if err == nil {
+			err = l.Error()
+		}
+		if err != nil {
+			onError(i, err)
+			if !l.skipElement(start, depth) {
+				break
+			}
+			continue
+		}
+
+		ret = append(ret, v)
+		l.WantComma()
+	}
+	l.Delim(']')
+
+	l.wantEnd()
+	if err := l.Error(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// isMalformedElement returns true if the error of the lexer is a syntax error of the token at
+// the start of an element, rather than the end of the input or an exceeded budget.
+func (r *Lexer) isMalformedElement() bool {
+	var syntaxErr *SyntaxError
+	return errors.As(r.err, &syntaxErr) && r.start < len(r.Data)
+}
+
+// skipElement skips the array element starting at offset start, which may be malformed, up to
+// the following ',' or the closing ']' at the same nesting level, and resets the lexer state to
+// decode the next element at the given depth. An error is set and false is returned if the array
+// does not end.
+func (r *Lexer) skipElement(start, depth int) bool {
+	level := 0
+	inQuotes, wasEscape := false, false
+
+	for i := start; i < len(r.Data); i++ {
+		c := r.Data[i]
+		switch {
+		case inQuotes:
+			switch {
+			case wasEscape:
+				wasEscape = false
+			case c == '\\':
+				wasEscape = true
+			case c == '"':
+				inQuotes = false
+			}
+		case c == '"':
+			inQuotes = true
+		case c == '{' || c == '[':
+			level++
+		case (c == '}' || c == ']') && level > 0:
+			level--
+		case c == ',' && level == 0:
+			r.resume(i+1, depth)
+			return true
+		case c == ']' && level == 0:
+			r.resume(i, depth)
+			// The skipped element stands for a value before the closing ']'.
+			r.wantSep = ','
+			return true
+		}
+	}
+
+	r.pos = len(r.Data)
+	r.errMalformed("unexpected end of data")
+	return false
+}
+
+// resume clears the error and continues scanning the input from offset pos, with no separator
+// expected before the next token. The objects and arrays entered beyond depth, e.g. by a decoder
+// that failed inside of them, are left, so that their keys are not reported in later errors.
+func (r *Lexer) resume(pos, depth int) {
+	r.err = nil
+	r.pos = pos
+	r.consume()
+	r.wantSep = 0
+
+	r.depth = depth
+	if depth > len(r.keys) {
+		r.moreKeys = r.moreKeys[:depth-len(r.keys)]
+	} else {
+		r.moreKeys = r.moreKeys[:0]
+	}
+}
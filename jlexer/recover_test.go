@@ -0,0 +1,194 @@
+package jlexer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type recoverRecord struct {
+	ID   int
+	Name string
+}
+
+func decodeRecoverRecord(l *Lexer) (recoverRecord, error) {
+	var v recoverRecord
+	l.Delim('{')
+	for !l.IsDelim('}') {
+		key := l.UnsafeString()
+		l.WantColon()
+		switch key {
+		case "id":
+			v.ID = l.Int()
+		case "name":
+			v.Name = l.String()
+		default:
+			l.SkipRecursive()
+		}
+		l.WantComma()
+	}
+	l.Delim('}')
+	return v, nil
+}
+
+func TestDecodeArrayRecover(t *testing.T) {
+	for i, test := range []struct {
+		data       string
+		want       []recoverRecord
+		wantFailed []int
+	}{
+		{
+			data: `[]`,
+		},
+		{
+			data: `[{"id":1,"name":"a"}, {"id":2,"name":"b"}]`,
+			want: []recoverRecord{{1, "a"}, {2, "b"}},
+		},
+		{
+			data:       `[{"id":1}, {"id":"x","name":"b"}, {"id":3}]`,
+			want:       []recoverRecord{{ID: 1}, {ID: 3}},
+			wantFailed: []int{1},
+		},
+		{
+			data:       `[{"id":1}, {"id":2,"name":{"nested":["],", "x"]}}, {"id":3}]`,
+			want:       []recoverRecord{{ID: 1}, {ID: 3}},
+			wantFailed: []int{1},
+		},
+		{
+			data:       `[{"id":1}, 1.2.3, {"id":3}, tru, 01, "a\x", {"id":7}]`,
+			want:       []recoverRecord{{ID: 1}, {ID: 3}, {ID: 7}},
+			wantFailed: []int{1, 3, 4, 5},
+		},
+		{
+			data:       `[{"id":1 "name":"a"}, {"id":2}, 5, {"id":4}]`,
+			want:       []recoverRecord{{ID: 2}, {ID: 4}},
+			wantFailed: []int{0, 2},
+		},
+	} {
+		var failed []int
+		got, err := DecodeArrayRecover([]byte(test.data), decodeRecoverRecord, func(index int, err error) {
+			if err == nil {
+				t.Errorf("[%d, %q] onError(%d) with nil error", i, test.data, index)
+			}
+			failed = append(failed, index)
+		})
+		if err != nil {
+			t.Errorf("[%d, %q] DecodeArrayRecover() error: %v", i, test.data, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %q] DecodeArrayRecover() = %v; want %v", i, test.data, got, test.want)
+		}
+		if !reflect.DeepEqual(failed, test.wantFailed) {
+			t.Errorf("[%d, %q] DecodeArrayRecover() failed elements = %v; want %v", i, test.data, failed, test.wantFailed)
+		}
+	}
+}
+
+func TestDecodeArrayRecoverDecodeError(t *testing.T) {
+	decodeErr := errors.New("decode failed")
+	decode := func(l *Lexer) (int, error) {
+		n := l.Int()
+		if n%2 != 0 {
+			return 0, decodeErr
+		}
+		return n, nil
+	}
+
+	var errs []error
+	got, err := DecodeArrayRecover([]byte(`[1, 2, 3, 4]`), decode, func(index int, err error) {
+		errs = append(errs, err)
+	})
+	if err != nil {
+		t.Errorf("DecodeArrayRecover() error: %v", err)
+	}
+	if want := []int{2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeArrayRecover() = %v; want %v", got, want)
+	}
+	if want := []error{decodeErr, decodeErr}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("DecodeArrayRecover() element errors = %v; want %v", errs, want)
+	}
+}
+
+func TestDecodeArrayRecoverMalformed(t *testing.T) {
+	decode := func(l *Lexer) (int, error) {
+		return l.Int(), nil
+	}
+
+	for i, test := range []struct {
+		data       string
+		want       []int
+		wantFailed []int
+	}{
+		{data: `[1, "x", 3]`, want: []int{1, 3}, wantFailed: []int{1}},
+		{data: `[1, 1.2.3, 3]`, want: []int{1, 3}, wantFailed: []int{1}},
+		{data: `[1, tru, 3]`, want: []int{1, 3}, wantFailed: []int{1}},
+		{data: `[1, 01, 3]`, want: []int{1, 3}, wantFailed: []int{1}},
+		{data: `[1, "a\x", 3]`, want: []int{1, 3}, wantFailed: []int{1}},
+		{data: `[-, 2]`, want: []int{2}, wantFailed: []int{0}},
+		{data: `[1, 2, nul]`, want: []int{1, 2}, wantFailed: []int{2}},
+		{data: `[1, "x"]`, want: []int{1}, wantFailed: []int{1}},
+	} {
+		var failed []int
+		got, err := DecodeArrayRecover([]byte(test.data), decode, func(index int, err error) {
+			if err == nil {
+				t.Errorf("[%d, %q] onError(%d) with nil error", i, test.data, index)
+			}
+			failed = append(failed, index)
+		})
+		if err != nil {
+			t.Errorf("[%d, %q] DecodeArrayRecover() error: %v", i, test.data, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %q] DecodeArrayRecover() = %v; want %v", i, test.data, got, test.want)
+		}
+		if !reflect.DeepEqual(failed, test.wantFailed) {
+			t.Errorf("[%d, %q] failed elements = %v; want %v", i, test.data, failed, test.wantFailed)
+		}
+	}
+}
+
+func TestDecodeArrayRecoverNestedError(t *testing.T) {
+	// An object of objects with an int at each level, or an int.
+	decode := func(l *Lexer) (int, error) {
+		depth := 0
+		for ; l.IsDelim('{'); depth++ {
+			l.Delim('{')
+			l.UnsafeString()
+			l.WantColon()
+		}
+		n := l.Int()
+		for ; depth > 0; depth-- {
+			l.WantComma()
+			l.Delim('}')
+		}
+		return n, nil
+	}
+
+	var fields []string
+	got, err := DecodeArrayRecover([]byte(`[{"a":{"b":"x"}}, "y", {"c":3}, "z"]`), decode, func(index int, err error) {
+		var mismatch *TypeMismatchError
+		if !errors.As(err, &mismatch) {
+			t.Errorf("onError(%d) error = %v; want a TypeMismatchError", index, err)
+			return
+		}
+		fields = append(fields, mismatch.Field)
+	})
+	if err != nil {
+		t.Errorf("DecodeArrayRecover() error: %v", err)
+	}
+	if want := []int{3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeArrayRecover() = %v; want %v", got, want)
+	}
+	if want := []string{"b", "", ""}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("DecodeArrayRecover() error fields = %q; want %q", fields, want)
+	}
+}
+
+func TestDecodeArrayRecoverErrors(t *testing.T) {
+	for i, data := range []string{`{}`, `[1, 2`, `[{"id":1}, {"id":"2"`, `[{"id":1}, {"id":2,]`, `[{"id":1}] 2`} {
+		_, err := DecodeArrayRecover([]byte(data), decodeRecoverRecord, func(int, error) {})
+		if err == nil {
+			t.Errorf("[%d, %q] DecodeArrayRecover() ok; want error", i, data)
+		}
+	}
+}
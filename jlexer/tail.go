@@ -0,0 +1,58 @@
+package jlexer
+
+// DecodeArrayTail decodes all elements of a JSON array with the decode function, but keeps only
+// the last n of them in a ring buffer, so that the memory used is bounded regardless of the
+// array size. The elements are returned in the input order.
+func DecodeArrayTail[T any](data []byte, n int, decode func(*Lexer) (T, error)) ([]T, error) {
+	if n < 0 {
+		n = 0
+	}
+
+	l := Lexer{Data: data}
+	ring := make([]T, 0, n)
+	next := 0 // position of the oldest element once the ring is full
+
+	l.Delim('[')
+	for !l.IsDelim(']') {
+		v, err := decode(&l)
+		if err != nil {
+			return nil, err
+		}
+		if !l.Ok() {
+			break
+		}
+
+		switch {
+		case n == 0:
+		case len(ring) < n:
+			ring = append(ring, v)
+		default:
+			ring[next] = v
+			next = (next + 1) % n
+		}
+		l.WantComma()
+	}
+	l.Delim(']')
+
+	l.wantEnd()
+	if err := l.Error(); err != nil {
+		return nil, err
+	}
+
+	ret := make([]T, 0, len(ring))
+	ret = append(ret, ring[next:]...)
+	return append(ret, ring[:next]...), nil
+}
+
+// wantEnd sets an error if there is data other than whitespace after the last consumed token.
+func (r *Lexer) wantEnd() {
+	if !r.Ok() {
+		return
+	}
+	for _, c := range r.Data[r.pos:] {
+		if !isSpace(c) {
+			r.errMalformed("unexpected data after the array")
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package jlexer
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func decodeInt(l *Lexer) (int, error) {
+	return l.Int(), nil
+}
+
+func TestDecodeArrayTail(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Itoa(i))
+	}
+	sb.WriteString("]\n")
+
+	got, err := DecodeArrayTail([]byte(sb.String()), 10, decodeInt)
+	if err != nil {
+		t.Fatalf("DecodeArrayTail() error: %v", err)
+	}
+	want := []int{9990, 9991, 9992, 9993, 9994, 9995, 9996, 9997, 9998, 9999}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeArrayTail() = %v; want %v", got, want)
+	}
+}
+
+func TestDecodeArrayTailShort(t *testing.T) {
+	for i, test := range []struct {
+		data string
+		n    int
+		want []int
+	}{
+		{data: `[]`, n: 3, want: []int{}},
+		{data: `[1, 2]`, n: 3, want: []int{1, 2}},
+		{data: `[1, 2, 3]`, n: 3, want: []int{1, 2, 3}},
+		{data: `[1, 2, 3, 4]`, n: 3, want: []int{2, 3, 4}},
+		{data: `[1, 2, 3]`, n: 0, want: []int{}},
+	} {
+		got, err := DecodeArrayTail([]byte(test.data), test.n, decodeInt)
+		if err != nil {
+			t.Errorf("[%d, %q] DecodeArrayTail(%d) error: %v", i, test.data, test.n, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %q] DecodeArrayTail(%d) = %v; want %v", i, test.data, test.n, got, test.want)
+		}
+	}
+}
+
+func TestDecodeArrayTailErrors(t *testing.T) {
+	for i, data := range []string{`{}`, `[1, 2`, `[1, "a"]`, `[1,,2]`, `[1] 2`} {
+		if _, err := DecodeArrayTail([]byte(data), 2, decodeInt); err == nil {
+			t.Errorf("[%d, %q] DecodeArrayTail() ok; want error", i, data)
+		}
+	}
+
+	decodeErr := errors.New("decode failed")
+	_, err := DecodeArrayTail([]byte(`[1]`), 2, func(*Lexer) (int, error) { return 0, decodeErr })
+	if err != decodeErr {
+		t.Errorf("DecodeArrayTail() error = %v; want %v", err, decodeErr)
+	}
+}
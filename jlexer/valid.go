@@ -0,0 +1,279 @@
+package jlexer
+
+import (
+	"io"
+
+	"github.com/mailru/easyjson/internal/jsonnum"
+)
+
+// validState is a state of the validating scanner.
+type validState byte
+
+const (
+	validValue      validState = iota // Expecting a value.
+	validValueOrEnd                   // Expecting a value or the end of an array.
+	validKey                          // Expecting an object key.
+	validKeyOrEnd                     // Expecting an object key or the end of an object.
+	validColon                        // Expecting a colon after an object key.
+	validAfterValue                   // Expecting a comma or the end of an array or an object.
+	validEnd                          // Top-level value is complete, only whitespace may follow.
+	validString                       // Inside a string literal.
+	validEscape                       // After a backslash in a string literal.
+	validUnicode                      // Inside a \uXXXX escape.
+	validNumber                       // Inside a number, see validator.num.
+	validLiteral                      // Inside true, false or null keyword.
+)
+
+// validator is a byte-at-a-time JSON scanner that only checks the input for well-formedness,
+// without producing any tokens.
+type validator struct {
+	state   validState
+	key     bool          // Whether the string being scanned is an object key.
+	literal string        // Remaining bytes of a keyword being scanned.
+	num     jsonnum.State // State of a number being scanned.
+	hexLeft int           // Remaining digits of a \uXXXX escape.
+
+	// Opening delimiters of the enclosing arrays and objects. The first levels are kept in an
+	// array to avoid allocations.
+	depth int
+	stack [64]byte
+	deep  []byte
+}
+
+func (v *validator) push(c byte) {
+	if v.depth < len(v.stack) {
+		v.stack[v.depth] = c
+	} else {
+		v.deep = append(v.deep, c)
+	}
+	v.depth++
+}
+
+func (v *validator) pop() {
+	v.depth--
+	if v.depth >= len(v.stack) {
+		v.deep = v.deep[:len(v.deep)-1]
+	}
+}
+
+func (v *validator) top() byte {
+	if v.depth > len(v.stack) {
+		return v.deep[len(v.deep)-1]
+	}
+	return v.stack[v.depth-1]
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// valueEnd switches the state after a complete value.
+func (v *validator) valueEnd() {
+	if v.depth == 0 {
+		v.state = validEnd
+	} else {
+		v.state = validAfterValue
+	}
+}
+
+// numberEnd completes a number at a non-number char and then processes the char.
+func (v *validator) numberEnd(c byte) bool {
+	v.valueEnd()
+	return v.step(c)
+}
+
+// valueStart processes the first char of a value.
+func (v *validator) valueStart(c byte) bool {
+	switch {
+	case c == '{':
+		v.push(c)
+		v.state = validKeyOrEnd
+	case c == '[':
+		v.push(c)
+		v.state = validValueOrEnd
+	case c == '"':
+		v.key = false
+		v.state = validString
+	case c == '-' || isDigit(c):
+		v.num, v.state = jsonnum.Start.Next(c), validNumber
+	case c == 't':
+		v.literal, v.state = "rue", validLiteral
+	case c == 'f':
+		v.literal, v.state = "alse", validLiteral
+	case c == 'n':
+		v.literal, v.state = "ull", validLiteral
+	default:
+		return false
+	}
+	return true
+}
+
+// step processes a single input char, returning false if the input is malformed.
+func (v *validator) step(c byte) bool {
+	switch v.state {
+	case validValue, validValueOrEnd:
+		if isSpace(c) {
+			return true
+		}
+		if c == ']' && v.state == validValueOrEnd {
+			v.pop()
+			v.valueEnd()
+			return true
+		}
+		return v.valueStart(c)
+
+	case validKey, validKeyOrEnd:
+		switch {
+		case isSpace(c):
+		case c == '}' && v.state == validKeyOrEnd:
+			v.pop()
+			v.valueEnd()
+		case c == '"':
+			v.key = true
+			v.state = validString
+		default:
+			return false
+		}
+		return true
+
+	case validColon:
+		switch {
+		case isSpace(c):
+		case c == ':':
+			v.state = validValue
+		default:
+			return false
+		}
+		return true
+
+	case validAfterValue:
+		top := v.top()
+		switch {
+		case isSpace(c):
+		case c == ',' && top == '{':
+			v.state = validKey
+		case c == ',':
+			v.state = validValue
+		case c == '}' && top == '{', c == ']' && top == '[':
+			v.pop()
+			v.valueEnd()
+		default:
+			return false
+		}
+		return true
+
+	case validEnd:
+		return isSpace(c)
+
+	case validString:
+		switch {
+		case c == '"' && v.key:
+			v.key = false
+			v.state = validColon
+		case c == '"':
+			v.valueEnd()
+		case c == '\\':
+			v.state = validEscape
+		case c < 0x20:
+			return false
+		}
+		return true
+
+	case validEscape:
+		switch c {
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			v.state = validString
+		case 'u':
+			v.hexLeft = 4
+			v.state = validUnicode
+		default:
+			return false
+		}
+		return true
+
+	case validUnicode:
+		if !isHex(c) {
+			return false
+		}
+		v.hexLeft--
+		if v.hexLeft == 0 {
+			v.state = validString
+		}
+		return true
+
+	case validNumber:
+		if next := v.num.Next(c); next != jsonnum.Invalid {
+			v.num = next
+			return true
+		}
+		if !v.num.Complete() {
+			return false
+		}
+		return v.numberEnd(c)
+
+	case validLiteral:
+		if c != v.literal[0] {
+			return false
+		}
+		v.literal = v.literal[1:]
+		if v.literal == "" {
+			v.valueEnd()
+		}
+		return true
+	}
+	return false
+}
+
+// done reports whether the input consumed so far is a complete JSON value.
+func (v *validator) done() bool {
+	switch v.state {
+	case validEnd:
+		return true
+	case validNumber:
+		return v.depth == 0 && v.num.Complete()
+	}
+	return false
+}
+
+// Valid reports whether data is a single well-formed JSON value surrounded by optional
+// whitespace. Unlike a full decode it does not allocate memory unless the nesting is deep.
+func Valid(data []byte) bool {
+	var v validator
+
+	for _, c := range data {
+		if !v.step(c) {
+			return false
+		}
+	}
+	return v.done()
+}
+
+// validReaderChunkSize is the size of chunks read by ValidReader.
+const validReaderChunkSize = 4096
+
+// ValidReader is a streaming version of Valid that reads the JSON value from r. It stops reading
+// as soon as the input is known to be malformed. A non-nil error is only returned if reading
+// from r fails.
+func ValidReader(r io.Reader) (bool, error) {
+	var v validator
+
+	buf := make([]byte, validReaderChunkSize)
+	for {
+		n, err := r.Read(buf)
+		for _, c := range buf[:n] {
+			if !v.step(c) {
+				return false, nil
+			}
+		}
+		if err == io.EOF {
+			return v.done(), nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
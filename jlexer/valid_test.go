@@ -0,0 +1,119 @@
+package jlexer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var validTests = []struct {
+	data  string
+	valid bool
+}{
+	{`0`, true},
+	{`-0`, true},
+	{`12.5e-3`, true},
+	{`1E+2`, true},
+	{` "abc" `, true},
+	{`"\"\\\/\b\f\n\r\té"`, true},
+	{`true`, true},
+	{`false`, true},
+	{`null`, true},
+	{`[]`, true},
+	{`{}`, true},
+	{`[1, "a", null, [true], {"b": {}}]`, true},
+	{`{"a": 1, "b": [false, -1.5], "c": {"d": null}}`, true},
+	{"\n\t{ \"a\" : [ 1 , 2 ] }\r\n", true},
+	{strings.Repeat("[", 100) + strings.Repeat("]", 100), true},
+	{strings.Repeat(`{"a":[`, 100) + strings.Repeat("]}", 100), true},
+	{strings.Repeat("[", 100) + strings.Repeat("]", 99) + "}", false},
+
+	{``, false},
+	{` `, false},
+	{`"abc`, false},
+	{`{"a": "b}`, false},
+	{"\"a\nb\"", false},
+	{`"\x"`, false},
+	{`"\u12g4"`, false},
+	{`"\u12"`, false},
+	{`[1, 2,]`, false},
+	{`{"a": 1,}`, false},
+	{`[,1]`, false},
+	{`{,}`, false},
+	{`[1 2]`, false},
+	{`{"a" 1}`, false},
+	{`{"a": }`, false},
+	{`{1: 2}`, false},
+	{`{"a": 1]`, false},
+	{`[1}`, false},
+	{`[1`, false},
+	{`]`, false},
+	{`1 2`, false},
+	{`{} {}`, false},
+	{`01`, false},
+	{`-`, false},
+	{`1.`, false},
+	{`.5`, false},
+	{`1e`, false},
+	{`1e+`, false},
+	{`+1`, false},
+	{`1true`, false},
+	{`tru`, false},
+	{`truex`, false},
+	{`nul`, false},
+	{`True`, false},
+}
+
+func TestValid(t *testing.T) {
+	for i, test := range validTests {
+		if got := Valid([]byte(test.data)); got != test.valid {
+			t.Errorf("[%d, %q] Valid() = %v; want %v", i, test.data, got, test.valid)
+		}
+		if std := json.Valid([]byte(test.data)); std != test.valid {
+			t.Errorf("[%d, %q] json.Valid() = %v; want %v", i, test.data, std, test.valid)
+		}
+	}
+}
+
+func TestValidReader(t *testing.T) {
+	for i, test := range validTests {
+		got, err := ValidReader(iotest.OneByteReader(strings.NewReader(test.data)))
+		if err != nil {
+			t.Errorf("[%d, %q] ValidReader() error: %v", i, test.data, err)
+		}
+		if got != test.valid {
+			t.Errorf("[%d, %q] ValidReader() = %v; want %v", i, test.data, got, test.valid)
+		}
+	}
+}
+
+func TestValidReaderError(t *testing.T) {
+	readErr := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader(`{"a":`), iotest.ErrReader(readErr))
+
+	got, err := ValidReader(r)
+	if got || err != readErr {
+		t.Errorf("ValidReader() = %v, %v; want false, %v", got, err, readErr)
+	}
+}
+
+func TestValidAllocs(t *testing.T) {
+	data := []byte(`{"a": [1, 2.5, "xA"], "b": {"c": null, "d": true}}`)
+	allocs := testing.AllocsPerRun(100, func() {
+		Valid(data)
+	})
+	if allocs != 0 {
+		t.Errorf("Valid() allocs = %v; want 0", allocs)
+	}
+}
+
+func BenchmarkValid(b *testing.B) {
+	data := []byte(`{"a": [1, 2.5, "xA"], "b": {"c": null, "d": true}}`)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		Valid(data)
+	}
+}
@@ -0,0 +1,652 @@
+package jwriter
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// easyjsonMarshaler is the easyjson.Marshaler interface, which cannot be imported here.
+type easyjsonMarshaler interface {
+	MarshalEasyJSON(w *Writer)
+}
+
+var (
+	easyjsonMarshalerType = reflect.TypeOf((*easyjsonMarshaler)(nil)).Elem()
+	jsonMarshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	numberType            = reflect.TypeOf(json.Number(""))
+)
+
+// Interface writes v using reflection, following the rules of encoding/json: marshaler
+// interfaces (including easyjson ones), struct field tags, sorted map keys, null for nil slices
+// and maps, base64 for byte slices. It is a fallback for values of types without generated
+// marshalers, e.g. in interface{} fields. The output of MarshalJSON methods is compacted, and
+// values of the types not covered by the rules above, e.g. maps with bool keys, are marshaled
+// with encoding/json.
+//
+// The encoding plan of each type, including the struct fields resolved from the tags, is built
+// once and cached, so repeated marshaling of the same dynamic type does not walk the type again.
+func (w *Writer) Interface(v interface{}) {
+	if v == nil {
+		w.RawString("null")
+		return
+	}
+	rv := reflect.ValueOf(v)
+	typeEncoder(rv.Type())(w, rv)
+}
+
+// encoderFunc writes a value of the type it was built for.
+type encoderFunc func(w *Writer, v reflect.Value)
+
+// encoderCache holds the encoderFunc of each reflect.Type.
+var encoderCache sync.Map
+
+// typeEncoder returns the cached encoder of the type, building it on the first use.
+func typeEncoder(t reflect.Type) encoderFunc {
+	if f, ok := encoderCache.Load(t); ok {
+		return f.(encoderFunc)
+	}
+
+	// A recursive type refers to its own encoder while it is being built, so an indirect one
+	// waiting for the actual encoder is stored first, as encoding/json does.
+	var (
+		wg sync.WaitGroup
+		f  encoderFunc
+	)
+	wg.Add(1)
+	fi, loaded := encoderCache.LoadOrStore(t, encoderFunc(func(w *Writer, v reflect.Value) {
+		wg.Wait()
+		f(w, v)
+	}))
+	if loaded {
+		return fi.(encoderFunc)
+	}
+
+	f = newTypeEncoder(t, true)
+	wg.Done()
+	encoderCache.Store(t, f)
+	return f
+}
+
+// newTypeEncoder builds the encoder of the type. If allowAddr is set, the marshalers with
+// pointer receivers are used for addressable values.
+func newTypeEncoder(t reflect.Type, allowAddr bool) encoderFunc {
+	if t.Kind() != reflect.Ptr && allowAddr {
+		if reflect.PtrTo(t).Implements(easyjsonMarshalerType) ||
+			reflect.PtrTo(t).Implements(jsonMarshalerType) ||
+			reflect.PtrTo(t).Implements(textMarshalerType) {
+			addrEnc, enc := newTypeEncoder(reflect.PtrTo(t), false), newTypeEncoder(t, false)
+			return func(w *Writer, v reflect.Value) {
+				if v.CanAddr() {
+					addrEnc(w, v.Addr())
+				} else {
+					enc(w, v)
+				}
+			}
+		}
+	}
+
+	switch {
+	case t.Implements(easyjsonMarshalerType):
+		return func(w *Writer, v reflect.Value) {
+			if v.Kind() == reflect.Ptr && v.IsNil() {
+				w.RawString("null")
+				return
+			}
+			if m, ok := v.Interface().(easyjsonMarshaler); ok {
+				m.MarshalEasyJSON(w)
+			} else {
+				w.RawString("null")
+			}
+		}
+	case t.Implements(jsonMarshalerType):
+		return func(w *Writer, v reflect.Value) {
+			if v.Kind() == reflect.Ptr && v.IsNil() {
+				w.RawString("null")
+				return
+			}
+			if m, ok := v.Interface().(json.Marshaler); ok {
+				data, err := m.MarshalJSON()
+				w.marshalerJSON(t, data, err)
+			} else {
+				w.RawString("null")
+			}
+		}
+	case t.Implements(textMarshalerType):
+		return func(w *Writer, v reflect.Value) {
+			if v.Kind() == reflect.Ptr && v.IsNil() {
+				w.RawString("null")
+				return
+			}
+			m, ok := v.Interface().(encoding.TextMarshaler)
+			if !ok {
+				w.RawString("null")
+				return
+			}
+			data, err := m.MarshalText()
+			if err != nil {
+				w.setError(err)
+				return
+			}
+			w.String(string(data))
+		}
+	case t == numberType:
+		return func(w *Writer, v reflect.Value) {
+			if s := v.String(); s != "" {
+				w.RawNumber([]byte(s))
+			} else {
+				w.RawByte('0')
+			}
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(w *Writer, v reflect.Value) { w.Bool(v.Bool()) }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(w *Writer, v reflect.Value) { w.Int64(v.Int()) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(w *Writer, v reflect.Value) { w.Uint64(v.Uint()) }
+	case reflect.Float32, reflect.Float64:
+		bitSize := t.Bits()
+		return func(w *Writer, v reflect.Value) { w.reflectFloat(v, bitSize) }
+	case reflect.String:
+		return func(w *Writer, v reflect.Value) { w.String(v.String()) }
+	case reflect.Interface:
+		return func(w *Writer, v reflect.Value) {
+			if v.IsNil() {
+				w.RawString("null")
+				return
+			}
+			typeEncoder(v.Elem().Type())(w, v.Elem())
+		}
+	case reflect.Ptr:
+		return newPtrEncoder(t)
+	case reflect.Struct:
+		return newStructEncoder(t)
+	case reflect.Map:
+		return newMapEncoder(t)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && !reflect.PtrTo(t.Elem()).Implements(easyjsonMarshalerType) &&
+			!reflect.PtrTo(t.Elem()).Implements(jsonMarshalerType) && !reflect.PtrTo(t.Elem()).Implements(textMarshalerType) {
+			return func(w *Writer, v reflect.Value) {
+				if v.IsNil() {
+					w.RawString("null")
+					return
+				}
+				w.RawByte('"')
+				w.Buffer.AppendString(base64.StdEncoding.EncodeToString(v.Bytes()))
+				w.RawByte('"')
+			}
+		}
+		arrayEnc := newArrayEncoder(t)
+		return func(w *Writer, v reflect.Value) {
+			if v.IsNil() {
+				w.RawString("null")
+				return
+			}
+			// Slices sharing the backing array are only the same value if of the same length.
+			ptr := struct {
+				ptr uintptr
+				len int
+			}{v.Pointer(), v.Len()}
+			if !w.enterPtr(v, ptr) {
+				return
+			}
+			arrayEnc(w, v)
+			w.leavePtr(ptr)
+		}
+	case reflect.Array:
+		return newArrayEncoder(t)
+	}
+	return newFallbackEncoder(t)
+}
+
+// newFallbackEncoder returns an encoder leaving the values of a type not supported by the
+// reflection encoders to encoding/json, so that they are written the same way or fail with the
+// same error.
+func newFallbackEncoder(t reflect.Type) encoderFunc {
+	return func(w *Writer, v reflect.Value) {
+		if !v.CanInterface() {
+			w.setError(&json.UnsupportedTypeError{Type: t})
+			return
+		}
+		w.Raw(json.Marshal(v.Interface()))
+	}
+}
+
+// marshalerJSON writes the output of a MarshalJSON method of a value of type t compacted, as
+// encoding/json does, setting the error if the output is not valid JSON.
+func (w *Writer) marshalerJSON(t reflect.Type, data []byte, err error) {
+	if err == nil {
+		var buf bytes.Buffer
+		if err = json.Compact(&buf, data); err == nil {
+			w.Buffer.AppendBytes(buf.Bytes())
+			return
+		}
+	}
+	w.setError(&json.MarshalerError{Type: t, Err: err})
+}
+
+// setError records the first error of the writer.
+func (w *Writer) setError(err error) {
+	if w.Error == nil {
+		w.Error = err
+	}
+}
+
+// reflectFloat writes a float the same way as encoding/json does: without an exponent unless
+// the value is below 1e-6 or at least 1e21. NaN and infinities are errors.
+func (w *Writer) reflectFloat(v reflect.Value, bitSize int) {
+	f := v.Float()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		w.setError(&json.UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, bitSize)})
+		return
+	}
+	if w.Canonical {
+		w.canonicalFloat(f, bitSize)
+		return
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bitSize == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	w.Buffer.EnsureSpace(24)
+	b := strconv.AppendFloat(w.Buffer.Buf, f, format, -1, bitSize)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	w.Buffer.Buf = b
+}
+
+// startDetectingCyclesAfter is the nesting level of pointers, maps and slices after which they
+// are checked for cycles, as in encoding/json, so that the values nested less deeply are written
+// without the overhead.
+const startDetectingCyclesAfter = 1000
+
+// enterPtr accounts a nested pointer, map or slice v identified by ptr before it is written. It
+// returns false, setting the error, if v is nested in itself, i.e. the value is cyclic, or if
+// there is an error already. leavePtr is to be called after v is written if true is returned.
+func (w *Writer) enterPtr(v reflect.Value, ptr interface{}) bool {
+	if w.Error != nil {
+		return false
+	}
+	if w.ptrLevel++; w.ptrLevel <= startDetectingCyclesAfter {
+		return true
+	}
+	if _, ok := w.ptrSeen[ptr]; ok {
+		w.ptrLevel--
+		w.setError(&json.UnsupportedValueError{Value: v, Str: "encountered a cycle via " + v.Type().String()})
+		return false
+	}
+	if w.ptrSeen == nil {
+		w.ptrSeen = make(map[interface{}]struct{})
+	}
+	w.ptrSeen[ptr] = struct{}{}
+	return true
+}
+
+// leavePtr accounts the end of a nested value entered with enterPtr.
+func (w *Writer) leavePtr(ptr interface{}) {
+	if w.ptrLevel > startDetectingCyclesAfter {
+		delete(w.ptrSeen, ptr)
+	}
+	w.ptrLevel--
+}
+
+func newPtrEncoder(t reflect.Type) encoderFunc {
+	elemEnc := typeEncoder(t.Elem())
+	return func(w *Writer, v reflect.Value) {
+		if v.IsNil() {
+			w.RawString("null")
+			return
+		}
+		ptr := v.Interface()
+		if !w.enterPtr(v, ptr) {
+			return
+		}
+		elemEnc(w, v.Elem())
+		w.leavePtr(ptr)
+	}
+}
+
+func newArrayEncoder(t reflect.Type) encoderFunc {
+	elemEnc := typeEncoder(t.Elem())
+	return func(w *Writer, v reflect.Value) {
+		w.RawByte('[')
+		for i, n := 0, v.Len(); i < n; i++ {
+			if i > 0 {
+				w.RawByte(',')
+			}
+			elemEnc(w, v.Index(i))
+		}
+		w.RawByte(']')
+	}
+}
+
+func newMapEncoder(t reflect.Type) encoderFunc {
+	keyType := t.Key()
+	switch keyType.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !keyType.Implements(textMarshalerType) {
+			return newFallbackEncoder(t)
+		}
+	}
+
+	elemEnc := typeEncoder(t.Elem())
+	return func(w *Writer, v reflect.Value) {
+		if v.IsNil() {
+			w.RawString("null")
+			return
+		}
+		ptr := v.Pointer()
+		if !w.enterPtr(v, ptr) {
+			return
+		}
+		defer w.leavePtr(ptr)
+
+		type entry struct {
+			key   string
+			value reflect.Value
+		}
+		entries := make([]entry, 0, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			key, err := mapKeyString(it.Key())
+			if err != nil {
+				w.setError(err)
+				return
+			}
+			entries = append(entries, entry{key, it.Value()})
+		}
+		if w.Canonical {
+			sort.Slice(entries, func(i, j int) bool { return CanonicalLess(entries[i].key, entries[j].key) })
+		} else {
+			sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+		}
+
+		w.RawByte('{')
+		for i, e := range entries {
+			if i > 0 {
+				w.RawByte(',')
+			}
+			w.String(e.key)
+			w.RawByte(':')
+			elemEnc(w, e.value)
+		}
+		w.RawByte('}')
+	}
+}
+
+// mapKeyString returns the object key for a map key, as encoding/json does.
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		data, err := tm.MarshalText()
+		return string(data), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	default:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+}
+
+// field is a struct field in the encoding plan of a struct type.
+type field struct {
+	name   string
+	key    string // Escaped name with the quotes and the colon.
+	tagged bool
+	index  []int
+	typ    reflect.Type
+
+	omitEmpty bool
+	quoted    bool
+
+	enc encoderFunc
+}
+
+func newStructEncoder(t reflect.Type) encoderFunc {
+	fields := typeFields(t)
+	for i := range fields {
+		fields[i].enc = typeEncoder(fields[i].typ)
+	}
+
+	return func(w *Writer, v reflect.Value) {
+		w.RawByte('{')
+		first := true
+	nextField:
+		for i := range fields {
+			f := &fields[i]
+
+			fv := v
+			for _, j := range f.index {
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						continue nextField
+					}
+					fv = fv.Elem()
+				}
+				fv = fv.Field(j)
+			}
+			if f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+
+			if !first {
+				w.RawByte(',')
+			}
+			first = false
+			w.RawString(f.key)
+			if f.quoted {
+				w.quotedValue(f, fv)
+			} else {
+				f.enc(w, fv)
+			}
+		}
+		w.RawByte('}')
+	}
+}
+
+// quotedValue writes the value of a field with the ",string" option as a JSON string. A nil
+// pointer is written as null, as in encoding/json.
+func (w *Writer) quotedValue(f *field, v reflect.Value) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			w.RawString("null")
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		w.RawByte('"')
+		typeEncoder(v.Type())(w, v)
+		w.RawByte('"')
+		return
+	}
+
+	var tmp Writer
+	tmp.String(v.String())
+	data, _ := tmp.BuildBytes()
+	w.String(string(data))
+}
+
+// isEmptyValue reports whether the value is empty for the omitempty option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// typeFields returns the fields of a struct type to encode, including the ones promoted from
+// embedded structs, with the same visibility rules as encoding/json: a field hides the fields
+// with the same name at deeper levels, and of several fields with the same name at the same
+// level only a single tagged one is kept.
+func typeFields(t reflect.Type) []field {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var fields []field
+	current, next := []embedded{}, []embedded{{typ: t}}
+	count, nextCount := map[reflect.Type]int{}, map[reflect.Type]int{}
+	visited := map[reflect.Type]bool{}
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts := tag, ""
+				if comma := strings.IndexByte(tag, ','); comma >= 0 {
+					name, opts = tag[:comma], tag[comma:]
+				}
+				index := append(append([]int(nil), e.index...), i)
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					nextCount[ft]++
+					if nextCount[ft] == 1 {
+						next = append(next, embedded{typ: ft, index: index})
+					}
+					continue
+				}
+
+				f := field{
+					name:      name,
+					tagged:    name != "",
+					index:     index,
+					typ:       sf.Type,
+					omitEmpty: strings.Contains(opts+",", ",omitempty,"),
+				}
+				if f.name == "" {
+					f.name = sf.Name
+				}
+				if strings.Contains(opts+",", ",string,") {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.String,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						f.quoted = true
+					}
+				}
+
+				var kw Writer
+				kw.String(f.name)
+				kw.RawByte(':')
+				f.key = string(kw.Buffer.BuildBytes())
+
+				fields = append(fields, f)
+				if count[e.typ] > 1 {
+					// The struct is embedded several times at the same level, so its fields
+					// annihilate each other; a copy makes the dominance check drop them.
+					fields = append(fields, f)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		return fields[i].tagged && !fields[j].tagged
+	})
+
+	out := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if f, ok := dominantField(fields[i:j]); ok {
+			out = append(out, f)
+		}
+		i = j
+	}
+	fields = out
+
+	sort.Slice(fields, func(i, j int) bool {
+		x, y := fields[i].index, fields[j].index
+		for k := 0; k < len(x) && k < len(y); k++ {
+			if x[k] != y[k] {
+				return x[k] < y[k]
+			}
+		}
+		return len(x) < len(y)
+	})
+	return fields
+}
+
+// dominantField returns the field hiding the others with the same name, sorted by depth with
+// tagged fields first, or false if there is no single such field.
+func dominantField(fields []field) (field, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tagged == fields[1].tagged {
+		return field{}, false
+	}
+	return fields[0], true
+}
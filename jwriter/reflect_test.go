@@ -0,0 +1,242 @@
+package jwriter
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reflectEmbedded struct {
+	Inner  string
+	Shadow int
+}
+
+type reflectTagged struct {
+	Shadow string `json:"Shadow"`
+}
+
+type reflectRecursive struct {
+	Name     string
+	Children []*reflectRecursive `json:",omitempty"`
+}
+
+type reflectEasyJSON struct{ V int }
+
+func (v reflectEasyJSON) MarshalEasyJSON(w *Writer) {
+	w.RawString(`{"easyjson":`)
+	w.Int(v.V)
+	w.RawByte('}')
+}
+
+type reflectPtrMarshaler struct{ V int }
+
+func (v *reflectPtrMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`"ptr"`), nil
+}
+
+type reflectSpacedMarshaler struct{}
+
+func (reflectSpacedMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(` { "a" : [ 1, 2 ] } `), nil
+}
+
+type reflectBadMarshaler struct{}
+
+func (reflectBadMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{oops`), nil
+}
+
+type reflectSample struct {
+	reflectEmbedded
+	*reflectTagged
+
+	Name       string            `json:"name"`
+	Skip       string            `json:"-"`
+	Empty      string            `json:",omitempty"`
+	Count      int64             `json:"count,string"`
+	Quoted     string            `json:",string"`
+	Ratio      float64           `json:"ratio"`
+	Small      float32           `json:"small"`
+	Bytes      []byte            `json:"bytes"`
+	NilSlice   []int             `json:"nil_slice"`
+	NilMap     map[string]int    `json:"nil_map"`
+	IntKeys    map[int]string    `json:"int_keys"`
+	Nested     map[string][]bool `json:"nested"`
+	Ptr        *int              `json:"ptr"`
+	Time       time.Time         `json:"time"`
+	Any        interface{}       `json:"any"`
+	Raw        json.RawMessage   `json:"raw"`
+	Number     json.Number       `json:"number"`
+	Marshaler  reflectPtrMarshaler
+	Array      [2]uint8
+	Tree       reflectRecursive
+	unexported int
+}
+
+type reflectQuotedPtrs struct {
+	Int *int     `json:",string"`
+	Str *string  `json:",string"`
+	Flt *float64 `json:",string"`
+}
+
+type reflectNode struct {
+	Name string
+	Next *reflectNode
+}
+
+func TestInterface(t *testing.T) {
+	n, s, f := 42, `x"y`, 1.5
+	sample := reflectSample{
+		reflectEmbedded: reflectEmbedded{Inner: "in", Shadow: 1},
+		reflectTagged:   &reflectTagged{Shadow: "tagged"},
+		Name:            "a<b>&",
+		Skip:            "skip",
+		Count:           -7,
+		Quoted:          `x"y`,
+		Ratio:           1e21,
+		Small:           0.1,
+		Bytes:           []byte("hello"),
+		IntKeys:         map[int]string{10: "b", 2: "a"},
+		Nested:          map[string][]bool{"z": {true}, "a": nil},
+		Ptr:             &n,
+		Time:            time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Any:             map[string]interface{}{"k": []interface{}{1.5, "s", nil}},
+		Raw:             json.RawMessage(`{"raw":true}`),
+		Number:          "12.50",
+		Array:           [2]uint8{1, 2},
+		Tree:            reflectRecursive{Name: "root", Children: []*reflectRecursive{{Name: "leaf"}}},
+		unexported:      1,
+	}
+
+	for i, v := range []interface{}{
+		nil,
+		true,
+		int8(-5),
+		uint64(math.MaxUint64),
+		3.0,
+		1e-7,
+		float32(3.14),
+		"tab\t\"q\"",
+		[]string{},
+		[]int(nil),
+		map[string]int{"b": 2, "a": 1},
+		&sample,
+		sample,
+		[]interface{}{reflectEasyJSON{1}, &reflectPtrMarshaler{}, (*reflectPtrMarshaler)(nil)},
+		reflectQuotedPtrs{},
+		reflectQuotedPtrs{Int: &n, Str: &s, Flt: &f},
+		reflectSpacedMarshaler{},
+		map[bool]int(nil),
+		[]map[bool]int{nil},
+	} {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("[%d] json.Marshal() error: %v", i, err)
+		}
+		if i == 13 {
+			want = []byte(`[{"easyjson":1},"ptr",null]`)
+		}
+
+		w := Writer{}
+		w.Interface(v)
+		got, err := w.BuildBytes()
+		if err != nil {
+			t.Errorf("[%d] Interface() error: %v", i, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("[%d] Interface() = %s; want %s", i, got, want)
+		}
+	}
+}
+
+func TestInterfaceErrors(t *testing.T) {
+	for i, v := range []interface{}{
+		math.NaN(),
+		math.Inf(-1),
+		make(chan int),
+		map[[2]int]string{{1, 2}: "x"},
+		struct{ F func() }{func() {}},
+		reflectBadMarshaler{},
+		[]interface{}{reflectBadMarshaler{}},
+		complex(1, 2),
+	} {
+		w := Writer{}
+		w.Interface(v)
+		if _, err := w.BuildBytes(); err == nil {
+			t.Errorf("[%d] Interface(%T) ok; want error", i, v)
+		}
+	}
+}
+
+func TestInterfaceCycle(t *testing.T) {
+	node := &reflectNode{Name: "a"}
+	node.Next = &reflectNode{Name: "b", Next: node}
+	m := map[string]interface{}{}
+	m["self"] = m
+	s := []interface{}{nil}
+	s[0] = s
+
+	for i, v := range []interface{}{node, m, s} {
+		w := Writer{}
+		w.Interface(v)
+		_, err := w.BuildBytes()
+		if err == nil || !strings.Contains(err.Error(), "encountered a cycle") {
+			t.Errorf("[%d] Interface(%T) error = %v; want a cycle error", i, v, err)
+		}
+		if _, err := json.Marshal(v); err == nil {
+			t.Errorf("[%d] json.Marshal(%T) ok; want error", i, v)
+		}
+	}
+
+	// Values nested deeply without a cycle are written.
+	var deep *reflectNode
+	for i := 0; i < 2*startDetectingCyclesAfter; i++ {
+		deep = &reflectNode{Name: "n", Next: deep}
+	}
+	w := Writer{}
+	w.Interface(deep)
+	if _, err := w.BuildBytes(); err != nil {
+		t.Errorf("Interface() of a deep list error: %v", err)
+	}
+}
+
+func BenchmarkInterfaceCached(b *testing.B) {
+	v := reflectSample{Name: "name", Count: 10, Tree: reflectRecursive{Name: "root"}}
+	w := Writer{}
+	w.Interface(v)
+	w.Buffer.BuildBytes()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.Interface(v)
+		w.Buffer.BuildBytes()
+	}
+}
+
+func BenchmarkInterfaceUncached(b *testing.B) {
+	v := reflectSample{Name: "name", Count: 10, Tree: reflectRecursive{Name: "root"}}
+	w := Writer{}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		encoderCache.Range(func(k, _ interface{}) bool {
+			encoderCache.Delete(k)
+			return true
+		})
+		w.Interface(v)
+		w.Buffer.BuildBytes()
+	}
+}
+
+func BenchmarkInterfaceEncodingJSON(b *testing.B) {
+	v := reflectSample{Name: "name", Count: 10, Tree: reflectRecursive{Name: "root"}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package jwriter
+
+import (
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/mailru/easyjson/buffer"
+)
+
+// SortedMap collects entries of an object to output them ordered by key, e.g. to produce stable
+// output for maps. Values of the entries are written with the Writer methods as usual:
+//
+//	m := w.SortedMapStart()
+//	for k, v := range values {
+//		m.Key(k)
+//		w.Int(v)
+//	}
+//	m.End()
+type SortedMap struct {
+	w    *Writer
+	less func(a, b string) bool
+
+	out     buffer.Buffer // The writer buffer saved while the entry values are collected.
+	entries []sortedEntry
+}
+
+type sortedEntry struct {
+	key   string
+	value []byte
+}
+
+// SortedMapStart starts an object with entries ordered by key.
+func (w *Writer) SortedMapStart() *SortedMap {
+	return w.SortedMapStartFunc(func(a, b string) bool { return a < b })
+}
+
+// SortedMapStartFunc starts an object with entries ordered by the given comparator. The order of
+// keys that are equal for the comparator is preserved.
+func (w *Writer) SortedMapStartFunc(less func(a, b string) bool) *SortedMap {
+	m := &SortedMap{w: w, less: less, out: w.Buffer}
+	w.Buffer = buffer.Buffer{}
+	return m
+}
+
+// Key starts a new entry, the value of which is written next.
+func (m *SortedMap) Key(key string) {
+	m.finishEntry()
+	m.entries = append(m.entries, sortedEntry{key: key})
+}
+
+// finishEntry stores the data written since the last Key as the value of the last entry.
+func (m *SortedMap) finishEntry() {
+	data := m.w.Buffer.BuildBytes()
+	if n := len(m.entries); n > 0 {
+		m.entries[n-1].value = data
+	}
+}
+
+// End outputs the object with the sorted entries to the writer.
+func (m *SortedMap) End() {
+	m.finishEntry()
+	m.w.Buffer = m.out
+
+	sort.SliceStable(m.entries, func(i, j int) bool {
+		return m.less(m.entries[i].key, m.entries[j].key)
+	})
+
+	m.w.RawByte('{')
+	for i, e := range m.entries {
+		if i > 0 {
+			m.w.RawByte(',')
+		}
+		m.w.String(e.key)
+		m.w.RawByte(':')
+		m.w.Buffer.AppendBytes(e.value)
+	}
+	m.w.RawByte('}')
+}
+
+// CanonicalLess orders keys by their UTF-16 code units, as required by JSON Canonicalization
+// Scheme (RFC 8785).
+func CanonicalLess(a, b string) bool {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			if ua, ub := firstUTF16Unit(ra), firstUTF16Unit(rb); ua != ub {
+				return ua < ub
+			}
+			// Runes with the same high surrogate are ordered by code point.
+			return ra < rb
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return a == "" && b != ""
+}
+
+// firstUTF16Unit returns the first UTF-16 code unit of the rune encoding.
+func firstUTF16Unit(r rune) rune {
+	if r < 0x10000 {
+		return r
+	}
+	r1, _ := utf16.EncodeRune(r)
+	return r1
+}
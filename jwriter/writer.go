@@ -2,17 +2,63 @@
 package jwriter
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
 	"io"
+	"math"
+	"math/bits"
 	"strconv"
+	"unicode/utf16"
 	"unicode/utf8"
+	"unsafe"
 
 	"github.com/mailru/easyjson/buffer"
+	"github.com/mailru/easyjson/internal/jsonnum"
 )
 
 // Writer is a JSON writer.
 type Writer struct {
 	Error  error
 	Buffer buffer.Buffer
+
+	// ASCIIOnly enables escaping of all non-ASCII characters in strings, so that the output is
+	// pure ASCII.
+	ASCIIOnly bool
+
+	// Debug enables recording of the fields skipped by generated marshalers due to omitempty.
+	Debug bool
+
+	// Canonical enables the formatting of floats and strings required by JSON Canonicalization
+	// Scheme (RFC 8785): floats are output as in ECMAScript, NaN and infinities are errors, and
+	// strings are escaped minimally. Ordering of object keys is up to the marshalers, see
+	// SortedMapStartFunc and CanonicalLess. It should not be combined with ASCIIOnly.
+	Canonical bool
+
+	skipped []string
+
+	// Nesting level of pointers, maps and slices written by Interface and the ones entered past
+	// startDetectingCyclesAfter, to detect cyclic values.
+	ptrLevel uint
+	ptrSeen  map[interface{}]struct{}
+}
+
+// ErrBufferFull is the error of the output that does not fit in a fixed buffer.
+var ErrBufferFull = buffer.ErrBufferFull
+
+// SetFixedBuffer makes the writer output into buf, up to its capacity, instead of pooled chunks,
+// so that marshaling does not allocate. Output that does not fit is not grown into, but is an
+// ErrBufferFull error of BuildBytes, DumpTo and AppendTo.
+func (w *Writer) SetFixedBuffer(buf []byte) {
+	w.Buffer.SetFixed(buf)
+}
+
+// checkFull sets the error if the output did not fit in the fixed buffer.
+func (w *Writer) checkFull() {
+	if w.Error == nil && w.Buffer.Full() {
+		w.Error = ErrBufferFull
+	}
 }
 
 // Size returns the size of the data that was written out.
@@ -22,18 +68,49 @@ func (w *Writer) Size() int {
 
 // DumpTo outputs the data to given io.Writer, resetting the buffer.
 func (w *Writer) DumpTo(out io.Writer) (written int, err error) {
+	if w.Buffer.Full() {
+		// The partial output is not written, only the fixed buffer is released.
+		w.checkFull()
+		w.Buffer.BuildBytes()
+		return 0, ErrBufferFull
+	}
 	return w.Buffer.DumpTo(out)
 }
 
 // BuildBytes returns writer data as a single byte slice.
 func (w *Writer) BuildBytes() ([]byte, error) {
+	w.checkFull()
 	if w.Error != nil {
+		// The fixed buffer is released as well, so that it is not written into after the error.
+		w.Buffer.ResetFixed()
 		return nil, w.Error
 	}
 
 	return w.Buffer.BuildBytes(), nil
 }
 
+// AppendTo appends the data written out to dst and returns the extended slice, unless there
+// was an error, in which case dst is returned unchanged along with the error. The writer
+// buffer is not reset, so it can be still dumped or released with BuildBytes.
+func (w *Writer) AppendTo(dst []byte) ([]byte, error) {
+	w.checkFull()
+	if w.Error != nil {
+		return dst, w.Error
+	}
+	return w.Buffer.AppendTo(dst), nil
+}
+
+// SkipField records a field name as omitted from the output. Generated marshalers call it
+// for empty omitempty fields if Debug is set.
+func (w *Writer) SkipField(name string) {
+	w.skipped = append(w.skipped, name)
+}
+
+// SkippedFields returns names of the fields that were omitted from the output in Debug mode.
+func (w *Writer) SkippedFields() []string {
+	return w.skipped
+}
+
 // RawByte appends raw binary data to the buffer.
 func (w *Writer) RawByte(c byte) {
 	w.Buffer.AppendByte(c)
@@ -59,6 +136,19 @@ func (w *Writer) Raw(data []byte, err error) {
 	}
 }
 
+// RawNumber appends a pre-rendered number literal to the buffer or sets the error if the data
+// is not a valid JSON number.
+func (w *Writer) RawNumber(data []byte) {
+	if w.Error != nil {
+		return
+	}
+	if !jsonnum.Valid(data) {
+		w.Error = fmt.Errorf("jwriter: invalid number literal %q", data)
+		return
+	}
+	w.Buffer.AppendBytes(data)
+}
+
 func (w *Writer) Uint8(n uint8) {
 	w.Buffer.EnsureSpace(3)
 	w.Buffer.Buf = strconv.AppendUint(w.Buffer.Buf, uint64(n), 10)
@@ -179,16 +269,264 @@ func (w *Writer) Int64Str(n int64) {
 	w.Buffer.Buf = append(w.Buffer.Buf, '"')
 }
 
+// IntHexStr writes n as a string with a 0x-prefixed lowercase hex number, e.g. "0xff" or "-0x1f".
+func (w *Writer) IntHexStr(n int64) {
+	w.Buffer.EnsureSpace(21)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+	u := uint64(n)
+	if n < 0 {
+		w.Buffer.Buf = append(w.Buffer.Buf, '-')
+		u = -u
+	}
+	w.Buffer.Buf = append(w.Buffer.Buf, '0', 'x')
+	w.Buffer.Buf = strconv.AppendUint(w.Buffer.Buf, u, 16)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+}
+
+// UintHexStr writes n as a string with a 0x-prefixed lowercase hex number.
+func (w *Writer) UintHexStr(n uint64) {
+	w.Buffer.EnsureSpace(20)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"', '0', 'x')
+	w.Buffer.Buf = strconv.AppendUint(w.Buffer.Buf, n, 16)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+}
+
+// IntGroupedStr writes n as a string with a decimal number with the digits grouped by thousands
+// with commas, e.g. "-1,234,567", for human-facing output.
+func (w *Writer) IntGroupedStr(n int64) {
+	w.Buffer.EnsureSpace(28)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+	u := uint64(n)
+	if n < 0 {
+		w.Buffer.Buf = append(w.Buffer.Buf, '-')
+		u = -u
+	}
+	w.Buffer.Buf = appendGrouped(w.Buffer.Buf, u)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+}
+
+// UintGroupedStr writes n as a string with a decimal number with the digits grouped by
+// thousands with commas, e.g. "1,234,567".
+func (w *Writer) UintGroupedStr(n uint64) {
+	w.Buffer.EnsureSpace(28)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+	w.Buffer.Buf = appendGrouped(w.Buffer.Buf, n)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+}
+
+// IntPaddedStr writes n as a string with a decimal number zero-padded to width characters,
+// including the sign, e.g. "00000042" or "-0000042" for the width of 8. Numbers wider than
+// width are written in full.
+func (w *Writer) IntPaddedStr(n int64, width int) {
+	var digits [20]byte
+	d := strconv.AppendInt(digits[:0], n, 10)
+	w.Buffer.EnsureSpace(len(d) + width + 2)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+	if n < 0 {
+		w.Buffer.Buf = append(w.Buffer.Buf, '-')
+		d = d[1:]
+		width--
+	}
+	w.Buffer.Buf = appendPadded(w.Buffer.Buf, d, width)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+}
+
+// UintPaddedStr writes n as a string with a decimal number zero-padded to width characters,
+// e.g. "00000042" for the width of 8. Numbers wider than width are written in full.
+func (w *Writer) UintPaddedStr(n uint64, width int) {
+	var digits [20]byte
+	d := strconv.AppendUint(digits[:0], n, 10)
+	w.Buffer.EnsureSpace(len(d) + width + 2)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+	w.Buffer.Buf = appendPadded(w.Buffer.Buf, d, width)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+}
+
+// appendPadded appends the digits preceded by as many zeros as needed for width characters.
+func appendPadded(buf, digits []byte, width int) []byte {
+	for i := len(digits); i < width; i++ {
+		buf = append(buf, '0')
+	}
+	return append(buf, digits...)
+}
+
+// appendGrouped appends the decimal digits of n grouped by thousands with commas.
+func appendGrouped(buf []byte, n uint64) []byte {
+	var digits [20]byte
+	d := strconv.AppendUint(digits[:0], n, 10)
+	for i, c := range d {
+		if i > 0 && (len(d)-i)%3 == 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// Uint128Str writes an unsigned 128-bit integer given by its high and low 64-bit words as a
+// string with a decimal number, since JSON numbers of that size are not portable.
+func (w *Writer) Uint128Str(hi, lo uint64) {
+	w.Buffer.EnsureSpace(41)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+	w.Buffer.Buf = appendUint128(w.Buffer.Buf, hi, lo)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+}
+
+// Int128Str writes a signed 128-bit integer given by its high and low 64-bit words in two's
+// complement as a string with a decimal number.
+func (w *Writer) Int128Str(hi int64, lo uint64) {
+	w.Buffer.EnsureSpace(42)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+	u := uint64(hi)
+	if hi < 0 {
+		w.Buffer.Buf = append(w.Buffer.Buf, '-')
+		u, lo = ^u, -lo
+		if lo == 0 {
+			u++
+		}
+	}
+	w.Buffer.Buf = appendUint128(w.Buffer.Buf, u, lo)
+	w.Buffer.Buf = append(w.Buffer.Buf, '"')
+}
+
+// appendUint128 appends the decimal representation of a 128-bit integer to buf. The value is
+// split into chunks of 19 digits by dividing it by 10^19, so no big integers are needed.
+func appendUint128(buf []byte, hi, lo uint64) []byte {
+	const chunk = 1e19
+	const chunkDigits = 19
+
+	var chunks [2]uint64
+	n := 0
+	for hi != 0 {
+		var rem uint64
+		hi, rem = bits.Div64(0, hi, chunk)
+		lo, rem = bits.Div64(rem, lo, chunk)
+		chunks[n] = rem
+		n++
+	}
+
+	buf = strconv.AppendUint(buf, lo, 10)
+	for n > 0 {
+		n--
+		var tmp [chunkDigits]byte
+		digits := strconv.AppendUint(tmp[:0], chunks[n], 10)
+		buf = append(buf, "0000000000000000000"[:chunkDigits-len(digits)]...)
+		buf = append(buf, digits...)
+	}
+	return buf
+}
+
+// Float32 writes n in the shortest representation. Floats are formatted by strconv, so the
+// output always uses a dot as the decimal separator and no digit grouping, regardless of the
+// process locale (LC_NUMERIC etc).
 func (w *Writer) Float32(n float32) {
+	if w.Canonical {
+		w.canonicalFloat(float64(n), 32)
+		return
+	}
 	w.Buffer.EnsureSpace(20)
 	w.Buffer.Buf = strconv.AppendFloat(w.Buffer.Buf, float64(n), 'g', -1, 32)
 }
 
+// Float64 writes n in the shortest representation, see Float32 for the locale guarantees.
 func (w *Writer) Float64(n float64) {
+	if w.Canonical {
+		w.canonicalFloat(n, 64)
+		return
+	}
 	w.Buffer.EnsureSpace(20)
 	w.Buffer.Buf = strconv.AppendFloat(w.Buffer.Buf, n, 'g', -1, 64)
 }
 
+// canonicalFloat writes n as ECMAScript Number.prototype.toString() does, as required by RFC 8785:
+// the shortest representation without an exponent unless the value is below 1e-6 or at least
+// 1e21. Floats of 32 bits use their own shortest representation.
+func (w *Writer) canonicalFloat(n float64, bitSize int) {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		if w.Error == nil {
+			w.Error = fmt.Errorf("jwriter: %v is not allowed in canonical output", n)
+		}
+		return
+	}
+	if n == 0 {
+		// Negative zero is output as 0 too.
+		w.Buffer.AppendByte('0')
+		return
+	}
+
+	format := byte('f')
+	if abs := math.Abs(n); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+
+	w.Buffer.EnsureSpace(32)
+	b := strconv.AppendFloat(w.Buffer.Buf, n, format, -1, bitSize)
+	if format == 'e' {
+		// Exponents have no leading zeroes, e.g. 1e-7 instead of 1e-07.
+		if l := len(b); b[l-4] == 'e' && b[l-3] == '-' && b[l-2] == '0' {
+			b[l-2] = b[l-1]
+			b = b[:l-1]
+		}
+	}
+	w.Buffer.Buf = b
+}
+
+// Float64Matrix outputs a slice of float pairs (e.g. coordinates) as nested arrays. Floats are
+// formatted with prec digits after the decimal point, or with the shortest representation if
+// prec is negative.
+func (w *Writer) Float64Matrix(m [][2]float64, prec int) {
+	format := byte('f')
+	if prec < 0 {
+		format = 'g'
+	}
+
+	w.Buffer.AppendByte('[')
+	for i, p := range m {
+		w.Buffer.EnsureSpace(44)
+		if i > 0 {
+			w.Buffer.Buf = append(w.Buffer.Buf, ',')
+		}
+		w.Buffer.Buf = append(w.Buffer.Buf, '[')
+		w.Buffer.Buf = strconv.AppendFloat(w.Buffer.Buf, p[0], format, prec, 64)
+		w.Buffer.Buf = append(w.Buffer.Buf, ',')
+		w.Buffer.Buf = strconv.AppendFloat(w.Buffer.Buf, p[1], format, prec, 64)
+		w.Buffer.Buf = append(w.Buffer.Buf, ']')
+	}
+	w.Buffer.AppendByte(']')
+}
+
+// FixedDecimal outputs an integer amount of minor units (e.g. cents) as a decimal number with
+// scale digits after the point, e.g. 1234 with scale 2 as 12.34. No float conversion is done.
+func (w *Writer) FixedDecimal(n int64, scale int) {
+	if scale <= 0 {
+		w.Int64(n)
+		return
+	}
+
+	u := uint64(n)
+	if n < 0 {
+		u = -u
+	}
+	var digits [20]byte
+	d := strconv.AppendUint(digits[:0], u, 10)
+
+	w.Buffer.EnsureSpace(len(d) + scale + 3)
+	if n < 0 {
+		w.Buffer.Buf = append(w.Buffer.Buf, '-')
+	}
+	if len(d) <= scale {
+		w.Buffer.Buf = append(w.Buffer.Buf, '0', '.')
+		for i := len(d); i < scale; i++ {
+			w.Buffer.Buf = append(w.Buffer.Buf, '0')
+		}
+		w.Buffer.Buf = append(w.Buffer.Buf, d...)
+	} else {
+		w.Buffer.Buf = append(w.Buffer.Buf, d[:len(d)-scale]...)
+		w.Buffer.Buf = append(w.Buffer.Buf, '.')
+		w.Buffer.Buf = append(w.Buffer.Buf, d[len(d)-scale:]...)
+	}
+}
+
 func (w *Writer) Bool(v bool) {
 	w.Buffer.EnsureSpace(5)
 	if v {
@@ -198,17 +536,292 @@ func (w *Writer) Bool(v bool) {
 	}
 }
 
+// IntBool writes a bool as a 1 or 0 number, e.g. for backends without a boolean type.
+func (w *Writer) IntBool(v bool) {
+	if v {
+		w.Buffer.AppendByte('1')
+	} else {
+		w.Buffer.AppendByte('0')
+	}
+}
+
+// packedChunkLen is the number of integers Uint32sLE encodes at once. A multiple of 3 integers
+// is encoded to base64 without padding, so that the chunks can be concatenated.
+const packedChunkLen = 768
+
+// Uint32sLE outputs the integers packed in little-endian order as a base64 string, e.g. for
+// binary telemetry data.
+func (w *Writer) Uint32sLE(v []uint32) {
+	var raw [packedChunkLen * 4]byte
+	var enc [packedChunkLen * 4 / 3 * 4]byte
+
+	w.Buffer.AppendByte('"')
+	for len(v) > 0 {
+		n := len(v)
+		if n > packedChunkLen {
+			n = packedChunkLen
+		}
+		for i, x := range v[:n] {
+			binary.LittleEndian.PutUint32(raw[4*i:], x)
+		}
+		base64.StdEncoding.Encode(enc[:], raw[:4*n])
+		w.Buffer.AppendBytes(enc[:base64.StdEncoding.EncodedLen(4*n)])
+		v = v[n:]
+	}
+	w.Buffer.AppendByte('"')
+}
+
+// base64ChunkLen is the number of bytes Base64Bytes encodes at once, a multiple of 3 so that the
+// chunks are encoded without padding.
+const base64ChunkLen = 768
+
+// Base64Bytes outputs the data as a base64 string, or null if it is nil, e.g. for binary values.
+func (w *Writer) Base64Bytes(data []byte) {
+	if data == nil {
+		w.RawString("null")
+		return
+	}
+
+	var enc [base64ChunkLen / 3 * 4]byte
+
+	w.Buffer.AppendByte('"')
+	for len(data) > 0 {
+		n := len(data)
+		if n > base64ChunkLen {
+			n = base64ChunkLen
+		}
+		base64.StdEncoding.Encode(enc[:], data[:n])
+		w.Buffer.AppendBytes(enc[:base64.StdEncoding.EncodedLen(n)])
+		data = data[n:]
+	}
+	w.Buffer.AppendByte('"')
+}
+
+func (w *Writer) BoolStr(v bool) {
+	w.Buffer.EnsureSpace(7)
+	if v {
+		w.Buffer.Buf = append(w.Buffer.Buf, `"true"`...)
+	} else {
+		w.Buffer.Buf = append(w.Buffer.Buf, `"false"`...)
+	}
+}
+
 const chars = "0123456789abcdef"
 
 func isNotEscapedSingleChar(c byte) bool {
-	// Note: might make sense to use a table if there are more chars to escape. With 4 chars
-	// it benchmarks the same.
-	return c != '<' && c != '\\' && c != '"' && c != '>' && c >= 0x20 && c < utf8.RuneSelf
+	// Note: might make sense to use a table if there are more chars to escape. With 5 chars
+	// it benchmarks the same. HTML-sensitive chars are escaped as in encoding/json.
+	return c != '<' && c != '\\' && c != '"' && c != '>' && c != '&' && c >= 0x20 && c < utf8.RuneSelf
 }
 
 func (w *Writer) String(s string) {
 	w.Buffer.AppendByte('"')
+	w.stringContents(s)
+	w.Buffer.AppendByte('"')
+}
+
+// Ellipsis is appended to the strings truncated by StringTruncated.
+const Ellipsis = "…"
+
+// StringTruncated outputs the string as a string literal, truncated to max runes followed by
+// Ellipsis if it is longer, e.g. to limit the size of logged values. Runes are never split;
+// invalid UTF-8 bytes are counted as single runes.
+func (w *Writer) StringTruncated(s string, max int) {
+	n := 0
+	for i := range s {
+		if n == max {
+			w.Buffer.AppendByte('"')
+			w.stringContents(s[:i])
+			w.stringContents(Ellipsis)
+			w.Buffer.AppendByte('"')
+			return
+		}
+		n++
+	}
+	w.String(s)
+}
+
+// ObjectKey outputs an object key made of the path prefix and the name, followed by a colon,
+// e.g. for flattened output with dotted keys. The prefix and the name are escaped.
+func (w *Writer) ObjectKey(prefix, name string) {
+	w.Buffer.AppendByte('"')
+	w.stringContents(prefix)
+	w.stringContents(name)
+	w.Buffer.AppendString(`":`)
+}
 
+// StringRunes outputs the runes as a string literal. The output is the same as of
+// String(string(rs)), but without converting the runes to a string first.
+func (w *Writer) StringRunes(rs []rune) {
+	w.Buffer.AppendByte('"')
+	for _, r := range rs {
+		w.runeContents(r)
+	}
+	w.Buffer.AppendByte('"')
+}
+
+// StringBuffer outputs the unread contents of b as a string literal. The output is the same as
+// of String(b.String()), but without copying the contents to a string first. Strings built with
+// strings.Builder need no such method, as its String does not copy.
+func (w *Writer) StringBuffer(b *bytes.Buffer) {
+	data := b.Bytes()
+	w.Buffer.AppendByte('"')
+	w.stringContents(*(*string)(unsafe.Pointer(&data)))
+	w.Buffer.AppendByte('"')
+}
+
+// StringDocument outputs the data of doc as a string literal, e.g. for a double-encoded object,
+// and resets doc. An error of doc is set as the error of w instead.
+func (w *Writer) StringDocument(doc *Writer) {
+	data, err := doc.BuildBytes()
+	if err != nil {
+		if w.Error == nil {
+			w.Error = err
+		}
+		return
+	}
+	w.Buffer.AppendByte('"')
+	w.stringContents(*(*string)(unsafe.Pointer(&data)))
+	w.Buffer.AppendByte('"')
+}
+
+// runeContents outputs a single rune of a string literal, escaping it if needed.
+func (w *Writer) runeContents(r rune) {
+	switch {
+	case r >= 0 && r < utf8.RuneSelf && isNotEscapedSingleChar(byte(r)):
+		w.Buffer.AppendByte(byte(r))
+		return
+	case r >= 0 && r < utf8.RuneSelf:
+		w.asciiEscape(byte(r))
+		return
+	case !utf8.ValidRune(r):
+		// Same as string conversion does for invalid runes.
+		r = utf8.RuneError
+	}
+
+	switch {
+	case w.ASCIIOnly && r > 0xffff:
+		r1, r2 := utf16.EncodeRune(r)
+		w.unicodeEscape(r1)
+		w.unicodeEscape(r2)
+	case w.ASCIIOnly, (r == '\u2028' || r == '\u2029') && !w.Canonical:
+		w.unicodeEscape(r)
+	default:
+		w.Buffer.EnsureSpace(utf8.UTFMax)
+		w.Buffer.Buf = utf8.AppendRune(w.Buffer.Buf, r)
+	}
+}
+
+// asciiEscape outputs an escape sequence for a single-width character.
+func (w *Writer) asciiEscape(c byte) {
+	if w.Canonical {
+		// RFC 8785 only escapes quotes, backslashes and control chars, using short forms if any.
+		switch c {
+		case '<', '>', '&':
+			w.Buffer.AppendByte(c)
+			return
+		case '\b':
+			w.Buffer.AppendString(`\b`)
+			return
+		case '\f':
+			w.Buffer.AppendString(`\f`)
+			return
+		}
+	}
+
+	switch c {
+	case '\t':
+		w.Buffer.AppendString(`\t`)
+	case '\r':
+		w.Buffer.AppendString(`\r`)
+	case '\n':
+		w.Buffer.AppendString(`\n`)
+	case '\\':
+		w.Buffer.AppendString(`\\`)
+	case '"':
+		w.Buffer.AppendString(`\"`)
+	default:
+		w.Buffer.AppendString(`\u00`)
+		w.Buffer.AppendByte(chars[c>>4])
+		w.Buffer.AppendByte(chars[c&0xf])
+	}
+}
+
+// unicodeEscape outputs a \uXXXX escape for a rune from the basic multilingual plane.
+func (w *Writer) unicodeEscape(r rune) {
+	w.Buffer.EnsureSpace(6)
+	w.Buffer.Buf = append(w.Buffer.Buf, '\\', 'u',
+		chars[r>>12&0xf], chars[r>>8&0xf], chars[r>>4&0xf], chars[r&0xf])
+}
+
+// readerChunkSize is the size of chunks StringFromReader reads the data in.
+const readerChunkSize = 4096
+
+// StringFromReader outputs all the data from the reader as a single string literal, escaping
+// it on the fly without reading the whole string into memory.
+func (w *Writer) StringFromReader(r io.Reader) {
+	w.Buffer.AppendByte('"')
+
+	buf := make([]byte, readerChunkSize)
+	n := 0 // bytes of an incomplete rune left over from the previous chunk
+	for {
+		m, err := r.Read(buf[n:])
+		n += m
+
+		// Keep a rune split between chunks until it is complete, flush everything at the end.
+		end := n
+		if err == nil {
+			end = fullRunesLen(buf[:n])
+		}
+		w.stringContents(string(buf[:end]))
+		n = copy(buf, buf[end:n])
+
+		if err != nil {
+			if err != io.EOF && w.Error == nil {
+				w.Error = err
+			}
+			break
+		}
+	}
+
+	w.Buffer.AppendByte('"')
+}
+
+// RowMarshaler is an iterator over rows, e.g. of a database query: Next advances to the next
+// row, returning false if there are no more rows, and MarshalRow outputs the current one.
+type RowMarshaler interface {
+	Next() bool
+	MarshalRow(w *Writer)
+}
+
+// ArrayFromIter outputs the rows of the iterator as an array, stopping at the first error. The
+// whole array is kept in the buffer, easyjson.ArrayStream flushes the rows to an output instead.
+func (w *Writer) ArrayFromIter(it RowMarshaler) {
+	w.RawByte('[')
+	for first := true; w.Error == nil && it.Next(); first = false {
+		if !first {
+			w.RawByte(',')
+		}
+		it.MarshalRow(w)
+	}
+	w.RawByte(']')
+}
+
+// fullRunesLen returns the length of the data prefix not ending with an incomplete utf-8 rune.
+func fullRunesLen(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if utf8.FullRune(data[i:]) {
+				return len(data)
+			}
+			return i
+		}
+	}
+	return len(data)
+}
+
+// stringContents outputs an escaped string without the enclosing quotes.
+func (w *Writer) stringContents(s string) {
 	// Portions of the string that contain no escapes are appended as
 	// byte slices.
 
@@ -224,22 +837,7 @@ func (w *Writer) String(s string) {
 		} else if c < utf8.RuneSelf {
 			// single-with character, need to escape
 			w.Buffer.AppendString(s[p:i])
-			switch c {
-			case '\t':
-				w.Buffer.AppendString(`\t`)
-			case '\r':
-				w.Buffer.AppendString(`\r`)
-			case '\n':
-				w.Buffer.AppendString(`\n`)
-			case '\\':
-				w.Buffer.AppendString(`\\`)
-			case '"':
-				w.Buffer.AppendString(`\"`)
-			default:
-				w.Buffer.AppendString(`\u00`)
-				w.Buffer.AppendByte(chars[c>>4])
-				w.Buffer.AppendByte(chars[c&0xf])
-			}
+			w.asciiEscape(c)
 
 			i++
 			p = i
@@ -256,8 +854,22 @@ func (w *Writer) String(s string) {
 			continue
 		}
 
+		if w.ASCIIOnly {
+			w.Buffer.AppendString(s[p:i])
+			if runeValue > 0xffff {
+				r1, r2 := utf16.EncodeRune(runeValue)
+				w.unicodeEscape(r1)
+				w.unicodeEscape(r2)
+			} else {
+				w.unicodeEscape(runeValue)
+			}
+			i += runeWidth
+			p = i
+			continue
+		}
+
 		// jsonp stuff - tab separator and line separator
-		if runeValue == '\u2028' || runeValue == '\u2029' {
+		if (runeValue == '\u2028' || runeValue == '\u2029') && !w.Canonical {
 			w.Buffer.AppendString(s[p:i])
 			w.Buffer.AppendString(`\u202`)
 			w.Buffer.AppendByte(chars[runeValue&0xf])
@@ -268,5 +880,4 @@ func (w *Writer) String(s string) {
 		i += runeWidth
 	}
 	w.Buffer.AppendString(s[p:])
-	w.Buffer.AppendByte('"')
 }
@@ -0,0 +1,945 @@
+package jwriter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+)
+
+func TestStringFromReader(t *testing.T) {
+	for i, test := range []string{
+		"",
+		"simple string",
+		"\"quoted\"\n\t\\",
+		"тест绿茶ü",
+		"emoji \U0001F600 and \u2028",
+		"broken \xc5 utf",
+		strings.Repeat("ж", readerChunkSize),
+	} {
+		w := Writer{}
+		w.String(test)
+		want := string(w.Buffer.BuildBytes())
+
+		for j, r := range []io.Reader{
+			strings.NewReader(test),
+			iotest.OneByteReader(strings.NewReader(test)),
+		} {
+			w := Writer{}
+			w.StringFromReader(r)
+
+			got, err := w.BuildBytes()
+			if err != nil {
+				t.Errorf("[%d, %d] StringFromReader() error: %v", i, j, err)
+			}
+			if string(got) != want {
+				t.Errorf("[%d, %d] StringFromReader() = %s; want %s", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestStringFromReaderError(t *testing.T) {
+	wantErr := errors.New("read failed")
+
+	w := Writer{}
+	w.StringFromReader(iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader("test"))))
+	if _, err := w.BuildBytes(); err != iotest.ErrTimeout {
+		t.Errorf("StringFromReader() error = %v; want %v", err, iotest.ErrTimeout)
+	}
+
+	w = Writer{}
+	w.StringFromReader(iotest.ErrReader(wantErr))
+	if _, err := w.BuildBytes(); err != wantErr {
+		t.Errorf("StringFromReader() error = %v; want %v", err, wantErr)
+	}
+}
+
+func TestStringBuffer(t *testing.T) {
+	for i, test := range []string{
+		"",
+		"simple string",
+		"\"quoted\"\n\t\\",
+		"тест绿茶ü",
+		"emoji \U0001F600 and \u2028",
+		"broken \xc5 utf",
+	} {
+		w := Writer{}
+		w.String(test)
+		want := string(w.Buffer.BuildBytes())
+
+		b := bytes.NewBufferString(test)
+		w = Writer{}
+		w.StringBuffer(b)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != want {
+			t.Errorf("[%d, %q] StringBuffer() = %s; want %s", i, test, got, want)
+		}
+		if b.String() != test {
+			t.Errorf("[%d, %q] buffer after StringBuffer() = %q; want unchanged", i, test, b.String())
+		}
+	}
+}
+
+func TestStringTruncated(t *testing.T) {
+	for i, test := range []struct {
+		in        string
+		max       int
+		asciiOnly bool
+		want      string
+	}{
+		{in: "", max: 3, want: `""`},
+		{in: "abc", max: 3, want: `"abc"`},
+		{in: "abcd", max: 3, want: `"abc…"`},
+		{in: "abcd", max: 0, want: `"…"`},
+		{in: "тест绿茶ü", max: 5, want: `"тест绿…"`},
+		{in: "тест", max: 4, want: `"тест"`},
+		{in: "a😀b", max: 2, want: `"a` + "😀" + `…"`},
+		{in: "\"q\"\n", max: 2, want: `"\"q…"`},
+		{in: "\xc5\xc5\xc5", max: 2, want: `"\ufffd\ufffd…"`},
+		{in: "тест", max: 2, asciiOnly: true, want: `"\u0442\u0435\u2026"`},
+	} {
+		w := Writer{ASCIIOnly: test.asciiOnly}
+		w.StringTruncated(test.in, test.max)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d, %q] StringTruncated(%v) = %s; want %s", i, test.in, test.max, got, test.want)
+		}
+	}
+}
+
+// intRows iterates over rows with the numbers from 0 to n-1.
+type intRows struct {
+	n, cur int
+}
+
+func (r *intRows) Next() bool {
+	r.cur++
+	return r.cur <= r.n
+}
+
+func (r *intRows) MarshalRow(w *Writer) {
+	w.RawString(`{"id":`)
+	w.Int(r.cur - 1)
+	w.RawByte('}')
+}
+
+func TestArrayFromIter(t *testing.T) {
+	for _, n := range []int{0, 1, 1000} {
+		w := Writer{}
+		w.ArrayFromIter(&intRows{n: n})
+
+		data, err := w.BuildBytes()
+		if err != nil {
+			t.Errorf("[%d] ArrayFromIter() error: %v", n, err)
+		}
+		var got []struct{ ID int }
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Errorf("[%d] ArrayFromIter() = %s: %v", n, data, err)
+		}
+		if len(got) != n || n > 0 && got[n-1].ID != n-1 {
+			t.Errorf("[%d] ArrayFromIter() got %v rows; want %v", n, len(got), n)
+		}
+	}
+}
+
+func TestUint32sLE(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 4, packedChunkLen, packedChunkLen + 1, 10000} {
+		v := make([]uint32, n)
+		raw := make([]byte, 4*n)
+		for i := range v {
+			v[i] = uint32(i) * 0x01020304
+			binary.LittleEndian.PutUint32(raw[4*i:], v[i])
+		}
+		want := `"` + base64.StdEncoding.EncodeToString(raw) + `"`
+
+		w := Writer{}
+		w.Uint32sLE(v)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != want {
+			t.Errorf("[%d] Uint32sLE() = %.40s...; want %.40s...", n, got, want)
+		}
+	}
+}
+
+func TestBase64Bytes(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, base64ChunkLen - 1, base64ChunkLen, base64ChunkLen + 1, 10000} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		want := `"` + base64.StdEncoding.EncodeToString(data) + `"`
+
+		w := Writer{}
+		w.Base64Bytes(data)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != want {
+			t.Errorf("[%d] Base64Bytes() = %.40s...; want %.40s...", n, got, want)
+		}
+	}
+
+	w := Writer{}
+	w.Base64Bytes(nil)
+	if got := string(w.Buffer.BuildBytes()); got != "null" {
+		t.Errorf("Base64Bytes(nil) = %v; want null", got)
+	}
+}
+
+func TestStringDocument(t *testing.T) {
+	w := Writer{}
+	doc := &Writer{}
+	doc.RawString(`{"a":"<b>"}`)
+	w.StringDocument(doc)
+
+	want := `"{\"a\":\"\u003cb\u003e\"}"`
+	if got := string(w.Buffer.BuildBytes()); got != want {
+		t.Errorf("StringDocument() = %s; want %s", got, want)
+	}
+	if doc.Size() != 0 {
+		t.Errorf("document size after StringDocument() = %v; want 0", doc.Size())
+	}
+
+	wantErr := errors.New("marshal failed")
+	w = Writer{}
+	w.StringDocument(&Writer{Error: wantErr})
+	if _, err := w.BuildBytes(); err != wantErr {
+		t.Errorf("StringDocument() error = %v; want %v", err, wantErr)
+	}
+}
+
+func TestASCIIOnly(t *testing.T) {
+	for i, test := range []struct {
+		in, want string
+	}{
+		{"simple", `"simple"`},
+		{"café crème", `"caf\u00e9 cr\u00e8me"`},
+		{"тест", `"\u0442\u0435\u0441\u0442"`},
+		{"\U0001F600!", `"\ud83d\ude00!"`},
+		{"\U0010FFFF", `"\udbff\udfff"`},
+		{"\u2028", `"\u2028"`},
+		{"broken \xc5", `"broken \ufffd"`},
+	} {
+		w := Writer{ASCIIOnly: true}
+		w.String(test.in)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d, %q] String() = %s; want %s", i, test.in, got, test.want)
+		}
+
+		if test.in == "broken \xc5" {
+			continue
+		}
+		var decoded string
+		if err := json.Unmarshal([]byte(got), &decoded); err != nil {
+			t.Errorf("[%d, %q] json.Unmarshal(%s) error: %v", i, test.in, got, err)
+		}
+		if decoded != test.in {
+			t.Errorf("[%d, %q] json.Unmarshal(%s) = %q; want %q", i, test.in, got, decoded, test.in)
+		}
+	}
+}
+
+func TestHTMLEscape(t *testing.T) {
+	for i, test := range []string{
+		"a & b",
+		"<script>alert('&amp;')</script>",
+		"&&<>",
+		"tom&jerry\u2028",
+	} {
+		w := Writer{}
+		w.String(test)
+		got := string(w.Buffer.BuildBytes())
+
+		want, err := json.Marshal(test)
+		if err != nil {
+			t.Fatalf("[%d, %q] json.Marshal() error: %v", i, test, err)
+		}
+		if got != string(want) {
+			t.Errorf("[%d, %q] String() = %s; want %s", i, test, got, want)
+		}
+	}
+}
+
+func TestStringRunes(t *testing.T) {
+	for i, test := range []string{
+		"",
+		"plain ascii",
+		"quote \" backslash \\ slash /",
+		"<html> & \t\r\n\b\f\x00\x1f\x7f",
+		"кириллица",
+		"astral \U0001F600\U00010348 runes",
+		"jsonp \u2028\u2029 separators",
+		"\ufffd replacement",
+	} {
+		for _, asciiOnly := range []bool{false, true} {
+			want := Writer{ASCIIOnly: asciiOnly}
+			want.String(test)
+			got := Writer{ASCIIOnly: asciiOnly}
+			got.StringRunes([]rune(test))
+
+			if g, w := string(got.Buffer.BuildBytes()), string(want.Buffer.BuildBytes()); g != w {
+				t.Errorf("[%d, %v] StringRunes(%q) = %s; want %s", i, asciiOnly, test, g, w)
+			}
+		}
+	}
+}
+
+func TestStringRunesInvalid(t *testing.T) {
+	rs := []rune{'a', 0xd800, -1, utf8.MaxRune + 1, 'b'}
+
+	want := Writer{}
+	want.String(string(rs))
+	got := Writer{}
+	got.StringRunes(rs)
+
+	if g, w := string(got.Buffer.BuildBytes()), string(want.Buffer.BuildBytes()); g != w {
+		t.Errorf("StringRunes(%v) = %s; want %s", rs, g, w)
+	}
+}
+
+func TestObjectKey(t *testing.T) {
+	for i, test := range []struct {
+		prefix, name string
+		want         string
+	}{
+		{"", "a", `"a":`},
+		{"user.", "name", `"user.name":`},
+		{"user.address.", "city", `"user.address.city":`},
+		{"q\"uote.", "b\\s", `"q\"uote.b\\s":`},
+	} {
+		w := Writer{}
+		w.ObjectKey(test.prefix, test.name)
+		if got := string(w.Buffer.BuildBytes()); got != test.want {
+			t.Errorf("[%d, %q, %q] ObjectKey() = %s; want %s", i, test.prefix, test.name, got, test.want)
+		}
+	}
+}
+
+func TestSortedMap(t *testing.T) {
+	for i, test := range []struct {
+		less func(a, b string) bool
+		want string
+	}{
+		{
+			less: nil,
+			want: `{"B":2,"C":[3],"a":{"x":1},"b":"4"}`,
+		},
+		{
+			less: func(a, b string) bool { return strings.ToLower(a) < strings.ToLower(b) },
+			want: `{"a":{"x":1},"b":"4","B":2,"C":[3]}`,
+		},
+		{
+			less: func(a, b string) bool { return a > b },
+			want: `{"b":"4","a":{"x":1},"C":[3],"B":2}`,
+		},
+	} {
+		w := Writer{}
+		w.RawString("[")
+
+		var m *SortedMap
+		if test.less == nil {
+			m = w.SortedMapStart()
+		} else {
+			m = w.SortedMapStartFunc(test.less)
+		}
+		m.Key("b")
+		w.String("4")
+		m.Key("B")
+		w.Int(2)
+		m.Key("C")
+		w.RawString("[3]")
+		m.Key("a")
+		w.RawString(`{"x":1}`)
+		m.End()
+
+		w.RawString("]")
+		if got, want := string(w.Buffer.BuildBytes()), "["+test.want+"]"; got != want {
+			t.Errorf("[%d] SortedMap = %s; want %s", i, got, want)
+		}
+	}
+}
+
+func TestSortedMapEmpty(t *testing.T) {
+	w := Writer{}
+	w.SortedMapStart().End()
+	if got := string(w.Buffer.BuildBytes()); got != "{}" {
+		t.Errorf("empty SortedMap = %s; want {}", got)
+	}
+}
+
+// Number serialization samples from RFC 8785, appendix B.
+func TestCanonicalFloat64(t *testing.T) {
+	for i, test := range []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	} {
+		w := Writer{Canonical: true}
+		w.Float64(math.Float64frombits(test.bits))
+		if got := string(w.Buffer.BuildBytes()); got != test.want || w.Error != nil {
+			t.Errorf("[%d, %#x] Float64() = %s, %v; want %s", i, test.bits, got, w.Error, test.want)
+		}
+	}
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		w := Writer{Canonical: true}
+		if w.Float64(f); w.Error == nil {
+			t.Errorf("Float64(%v) ok; want error", f)
+		}
+	}
+}
+
+func TestCanonicalString(t *testing.T) {
+	for i, test := range []struct {
+		s    string
+		want string
+	}{
+		{"<a&b>", `"<a&b>"`},
+		{"\b\f\n\r\t\x01\x1f\x7f", `"\b\f\n\r\t\u0001\u001f` + "\x7f" + `"`},
+		{"\"\\/", `"\"\\/"`},
+		{"\u2028\u2029€😀", "\"\u2028\u2029€😀\""},
+	} {
+		w := Writer{Canonical: true}
+		w.String(test.s)
+		if got := string(w.Buffer.BuildBytes()); got != test.want {
+			t.Errorf("[%d, %q] String() = %s; want %s", i, test.s, got, test.want)
+		}
+
+		w = Writer{Canonical: true}
+		w.StringRunes([]rune(test.s))
+		if got := string(w.Buffer.BuildBytes()); got != test.want {
+			t.Errorf("[%d, %q] StringRunes() = %s; want %s", i, test.s, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalLess(t *testing.T) {
+	// RFC 8785, section 3.2.3.
+	want := []string{"\r", "1", "\u0080", "\u00f6", "\u20ac", "\U0001F600", "\ufb33"}
+
+	keys := []string{"\u20ac", "\r", "\ufb33", "1", "\U0001F600", "\u0080", "\u00f6"}
+	sort.Slice(keys, func(i, j int) bool { return CanonicalLess(keys[i], keys[j]) })
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("sorted keys = %q; want %q", keys, want)
+	}
+
+	for _, test := range [][2]string{{"", "a"}, {"a", "ab"}, {"\U00010000", "\U00010001"}} {
+		if !CanonicalLess(test[0], test[1]) || CanonicalLess(test[1], test[0]) {
+			t.Errorf("CanonicalLess(%q, %q) is not true", test[0], test[1])
+		}
+	}
+}
+
+func TestAppendTo(t *testing.T) {
+	for i, test := range []struct {
+		dst  []byte
+		want string
+	}{
+		{dst: nil, want: `{"a":[1,2]}`},
+		{dst: []byte(`prefix `), want: `prefix {"a":[1,2]}`},
+	} {
+		w := Writer{}
+		w.RawString(`{"a":[1,2]}`)
+
+		got, err := w.AppendTo(test.dst)
+		if err != nil {
+			t.Errorf("[%d] AppendTo() error: %v", i, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("[%d] AppendTo() = %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestAppendToError(t *testing.T) {
+	w := Writer{}
+	w.RawString(`{"a":`)
+	w.Error = errors.New("failed")
+
+	dst := []byte("prefix")
+	got, err := w.AppendTo(dst)
+	if err != w.Error {
+		t.Errorf("AppendTo() error = %v; want %v", err, w.Error)
+	}
+	if string(got) != "prefix" || cap(got) != cap(dst) {
+		t.Errorf("AppendTo() = %q; want dst unchanged", got)
+	}
+}
+
+func TestRawNumber(t *testing.T) {
+	for i, test := range []struct {
+		data      string
+		wantError bool
+	}{
+		{data: "0"},
+		{data: "-0"},
+		{data: "123"},
+		{data: "-12.5"},
+		{data: "1e10"},
+		{data: "1.5E-3"},
+		{data: "2e+8"},
+
+		{data: "", wantError: true},
+		{data: "12.3.4", wantError: true},
+		{data: "-", wantError: true},
+		{data: "+1", wantError: true},
+		{data: "01", wantError: true},
+		{data: "1.", wantError: true},
+		{data: ".5", wantError: true},
+		{data: "1e", wantError: true},
+		{data: "1e+", wantError: true},
+		{data: "1,5", wantError: true},
+		{data: " 1", wantError: true},
+		{data: "NaN", wantError: true},
+	} {
+		w := Writer{}
+		w.RawNumber([]byte(test.data))
+
+		got, err := w.BuildBytes()
+		if err != nil && !test.wantError {
+			t.Errorf("[%d, %q] RawNumber() error: %v", i, test.data, err)
+		} else if err == nil && test.wantError {
+			t.Errorf("[%d, %q] RawNumber() ok; want error", i, test.data)
+		} else if err == nil && string(got) != test.data {
+			t.Errorf("[%d, %q] RawNumber() = %q; want %q", i, test.data, got, test.data)
+		}
+	}
+}
+
+func TestFixedDecimal(t *testing.T) {
+	for i, test := range []struct {
+		n     int64
+		scale int
+		want  string
+	}{
+		{1234, 2, "12.34"},
+		{5, 2, "0.05"},
+		{-5, 2, "-0.05"},
+		{1000, 3, "1.000"},
+		{0, 2, "0.00"},
+		{-1234, 2, "-12.34"},
+		{100, 2, "1.00"},
+		{42, 0, "42"},
+		{math.MinInt64, 4, "-922337203685477.5808"},
+		{math.MaxInt64, 20, "0.09223372036854775807"},
+	} {
+		w := Writer{}
+		w.FixedDecimal(test.n, test.scale)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] FixedDecimal(%v, %v) = %v; want %v", i, test.n, test.scale, got, test.want)
+		}
+	}
+}
+
+func TestIntHexStr(t *testing.T) {
+	for i, test := range []struct {
+		n    int64
+		want string
+	}{
+		{0, `"0x0"`},
+		{255, `"0xff"`},
+		{-31, `"-0x1f"`},
+		{math.MaxInt64, `"0x7fffffffffffffff"`},
+		{math.MinInt64, `"-0x8000000000000000"`},
+	} {
+		w := Writer{}
+		w.IntHexStr(test.n)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] IntHexStr(%v) = %v; want %v", i, test.n, got, test.want)
+		}
+	}
+}
+
+func TestUintHexStr(t *testing.T) {
+	for i, test := range []struct {
+		n    uint64
+		want string
+	}{
+		{0, `"0x0"`},
+		{0xabc, `"0xabc"`},
+		{math.MaxUint64, `"0xffffffffffffffff"`},
+	} {
+		w := Writer{}
+		w.UintHexStr(test.n)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] UintHexStr(%v) = %v; want %v", i, test.n, got, test.want)
+		}
+	}
+}
+
+func TestIntGroupedStr(t *testing.T) {
+	for i, test := range []struct {
+		n    int64
+		want string
+	}{
+		{0, `"0"`},
+		{7, `"7"`},
+		{999, `"999"`},
+		{1000, `"1,000"`},
+		{1234567, `"1,234,567"`},
+		{-12345, `"-12,345"`},
+		{-100, `"-100"`},
+		{math.MaxInt64, `"9,223,372,036,854,775,807"`},
+		{math.MinInt64, `"-9,223,372,036,854,775,808"`},
+	} {
+		w := Writer{}
+		w.IntGroupedStr(test.n)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] IntGroupedStr(%v) = %v; want %v", i, test.n, got, test.want)
+		}
+	}
+}
+
+func TestUintGroupedStr(t *testing.T) {
+	for i, test := range []struct {
+		n    uint64
+		want string
+	}{
+		{0, `"0"`},
+		{123456, `"123,456"`},
+		{math.MaxUint64, `"18,446,744,073,709,551,615"`},
+	} {
+		w := Writer{}
+		w.UintGroupedStr(test.n)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] UintGroupedStr(%v) = %v; want %v", i, test.n, got, test.want)
+		}
+	}
+}
+
+func TestIntPaddedStr(t *testing.T) {
+	for i, test := range []struct {
+		n     int64
+		width int
+		want  string
+	}{
+		{42, 8, `"00000042"`},
+		{0, 3, `"000"`},
+		{-42, 8, `"-0000042"`},
+		{12345678, 8, `"12345678"`},
+		{123456789, 8, `"123456789"`},
+		{-12345678, 8, `"-12345678"`},
+		{math.MinInt64, 1, `"-9223372036854775808"`},
+	} {
+		w := Writer{}
+		w.IntPaddedStr(test.n, test.width)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] IntPaddedStr(%v, %v) = %v; want %v", i, test.n, test.width, got, test.want)
+		}
+	}
+}
+
+func TestUintPaddedStr(t *testing.T) {
+	for i, test := range []struct {
+		n     uint64
+		width int
+		want  string
+	}{
+		{7, 4, `"0007"`},
+		{12345, 4, `"12345"`},
+		{math.MaxUint64, 24, `"000018446744073709551615"`},
+	} {
+		w := Writer{}
+		w.UintPaddedStr(test.n, test.width)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] UintPaddedStr(%v, %v) = %v; want %v", i, test.n, test.width, got, test.want)
+		}
+	}
+}
+
+func TestIntBool(t *testing.T) {
+	w := Writer{}
+	w.IntBool(true)
+	w.RawByte(',')
+	w.IntBool(false)
+
+	if got, want := string(w.Buffer.BuildBytes()), "1,0"; got != want {
+		t.Errorf("IntBool() = %v; want %v", got, want)
+	}
+}
+
+func TestUint128Str(t *testing.T) {
+	for i, test := range []struct {
+		hi, lo uint64
+		want   string
+	}{
+		{0, 0, `"0"`},
+		{0, math.MaxUint64, `"18446744073709551615"`},
+		{1, 0, `"18446744073709551616"`},
+		{0x4b3b4ca85a86c47a, 0x98a224000000000, `"100000000000000000000000000000000000000"`},
+		{0x8ac7230489e80000, 5, `"184467440737095516160000000000000000005"`},
+		{math.MaxUint64, math.MaxUint64, `"340282366920938463463374607431768211455"`},
+	} {
+		w := Writer{}
+		w.Uint128Str(test.hi, test.lo)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] Uint128Str(%#x, %#x) = %v; want %v", i, test.hi, test.lo, got, test.want)
+		}
+	}
+}
+
+func TestInt128Str(t *testing.T) {
+	for i, test := range []struct {
+		hi   int64
+		lo   uint64
+		want string
+	}{
+		{0, 0, `"0"`},
+		{0, 42, `"42"`},
+		{-1, math.MaxUint64, `"-1"`},
+		{-1, 0, `"-18446744073709551616"`},
+		{-6, 0x9438a1d29cefffff, `"-100000000000000000001"`},
+		{math.MaxInt64, math.MaxUint64, `"170141183460469231731687303715884105727"`},
+		{math.MinInt64, 0, `"-170141183460469231731687303715884105728"`},
+	} {
+		w := Writer{}
+		w.Int128Str(test.hi, test.lo)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] Int128Str(%d, %#x) = %v; want %v", i, test.hi, test.lo, got, test.want)
+		}
+	}
+}
+
+func TestFloatLocale(t *testing.T) {
+	// Locales using a comma as the decimal separator and a dot or space for digit grouping.
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		t.Setenv(name, "de_DE.UTF-8")
+	}
+
+	for i, test := range []struct {
+		n    float64
+		want string
+	}{
+		{0.5, "0.5"},
+		{-1.25, "-1.25"},
+		{1234567.5, "1.2345675e+06"},
+		{1234.5678, "1234.5678"},
+		{1e21, "1e+21"},
+		{-2.5e-7, "-2.5e-07"},
+	} {
+		w := Writer{}
+		w.Float64(test.n)
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] Float64(%v) = %v; want %v", i, test.n, got, test.want)
+		}
+
+		w.Float32(float32(test.n))
+		w.RawByte(' ')
+		w.FixedDecimal(int64(test.n*100), 2)
+		w.RawByte(' ')
+		w.Float64Matrix([][2]float64{{test.n, 0}}, 3)
+
+		other := string(w.Buffer.BuildBytes())
+		if strings.Count(other, ",") != 1 || !strings.Contains(other, ".") {
+			t.Errorf("[%d] %v formatted as %q; want dot separators only", i, test.n, other)
+		}
+	}
+}
+
+func TestFloat64Matrix(t *testing.T) {
+	for i, test := range []struct {
+		m    [][2]float64
+		prec int
+		want string
+	}{
+		{nil, -1, "[]"},
+		{[][2]float64{}, 2, "[]"},
+		{[][2]float64{{}}, -1, "[[0,0]]"},
+		{[][2]float64{{1.5, -2}, {3, 4.25}}, -1, "[[1.5,-2],[3,4.25]]"},
+		{[][2]float64{{1.23456, -2.5}}, 2, "[[1.23,-2.50]]"},
+		{[][2]float64{{37.6173, 55.7558}}, 0, "[[38,56]]"},
+	} {
+		w := Writer{}
+		w.Float64Matrix(test.m, test.prec)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] Float64Matrix(%v, %v) = %v; want %v", i, test.m, test.prec, got, test.want)
+		}
+	}
+}
+
+var benchMatrix = func() [][2]float64 {
+	m := make([][2]float64, 1000)
+	for i := range m {
+		m[i] = [2]float64{float64(i) * 0.123456, -float64(i) * 1.5}
+	}
+	return m
+}()
+
+func BenchmarkFloat64Matrix(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		w := Writer{}
+		w.Float64Matrix(benchMatrix, -1)
+		w.Buffer.BuildBytes()
+	}
+}
+
+// BenchmarkFloat64MatrixGeneric mirrors the code generated for nested slices.
+func BenchmarkFloat64MatrixGeneric(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		w := Writer{}
+		w.RawByte('[')
+		for j, p := range benchMatrix {
+			if j > 0 {
+				w.RawByte(',')
+			}
+			w.RawByte('[')
+			for k, v := range p {
+				if k > 0 {
+					w.RawByte(',')
+				}
+				w.Float64(v)
+			}
+			w.RawByte(']')
+		}
+		w.RawByte(']')
+		w.Buffer.BuildBytes()
+	}
+}
+
+func TestFixedBuffer(t *testing.T) {
+	const want = `{"name":"fixed","n":12345}`
+	write := func(w *Writer) {
+		w.RawString(`{"name":`)
+		w.String("fixed")
+		w.RawString(`,"n":`)
+		w.Int(12345)
+		w.RawByte('}')
+	}
+
+	for i, test := range []struct {
+		size    int
+		wantErr error
+	}{
+		{size: len(want)},
+		{size: len(want) + 100},
+		{size: len(want) - 1, wantErr: ErrBufferFull},
+		{size: 8, wantErr: ErrBufferFull},
+	} {
+		buf := make([]byte, test.size)
+		w := Writer{}
+		w.SetFixedBuffer(buf)
+		write(&w)
+
+		got, err := w.BuildBytes()
+		if err != test.wantErr {
+			t.Errorf("[%d] BuildBytes() error = %v; want %v", i, err, test.wantErr)
+		}
+		if test.wantErr == nil && string(got) != want {
+			t.Errorf("[%d] BuildBytes() = %s; want %s", i, got, want)
+		}
+		if test.wantErr != nil && got != nil {
+			t.Errorf("[%d] BuildBytes() = %s; want nil", i, got)
+		}
+
+		// The writer is back to a regular buffer either way, so buf is not written into any more.
+		w.Buffer.AppendString("grown")
+		if got := string(w.Buffer.BuildBytes()); got != "grown" || string(buf[:5]) == "grown" {
+			t.Errorf("[%d] output after BuildBytes() = %q in buffer %q; want %q in a new buffer", i, got, buf[:5], "grown")
+		}
+	}
+
+	var out strings.Builder
+	w := Writer{}
+	w.SetFixedBuffer(make([]byte, 4))
+	write(&w)
+	if n, err := w.DumpTo(&out); n != 0 || err != ErrBufferFull || out.Len() != 0 {
+		t.Errorf("DumpTo() = %v, %v, %q; want 0, %v and no output", n, err, out.String(), ErrBufferFull)
+	}
+
+	buf := make([]byte, len(want))
+	allocs := testing.AllocsPerRun(100, func() {
+		w := Writer{}
+		w.SetFixedBuffer(buf)
+		write(&w)
+		if _, err := w.BuildBytes(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("marshaling into a fixed buffer made %v allocations; want 0", allocs)
+	}
+}
+
+var benchText = bytes.Repeat([]byte("some text with \"quotes\" and\nnewlines "), 100)
+
+func BenchmarkStringBuffer(b *testing.B) {
+	buf := bytes.NewBuffer(benchText)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w := Writer{}
+		w.StringBuffer(buf)
+		w.Buffer.DumpTo(ioutil.Discard)
+	}
+}
+
+// BenchmarkStringBufferCopy is the same as BenchmarkStringBuffer, converting the buffer to a
+// string first.
+func BenchmarkStringBufferCopy(b *testing.B) {
+	buf := bytes.NewBuffer(benchText)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w := Writer{}
+		w.String(buf.String())
+		w.Buffer.DumpTo(ioutil.Discard)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build go1.18
+// +build go1.18
+
+package opt
+
+import "fmt"
+
+// Optional is a generic optional value, telling a missing field from a field set to the zero
+// value without using pointers. The generated marshalers recognize the type: a field is output
+// with Value, even if it is zero, only when Set is true, and decoding a non-null value sets Set.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+// Some creates an optional value set to v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Set: true}
+}
+
+// Get returns the value or given default in the case the value is not set.
+func (v Optional[T]) Get(deflt T) T {
+	if !v.Set {
+		return deflt
+	}
+	return v.Value
+}
+
+// IsDefined returns whether the value is set, implementing easyjson.Optional interface.
+func (v Optional[T]) IsDefined() bool {
+	return v.Set
+}
+
+// String implements a stringer interface using fmt.Sprint for the value.
+func (v Optional[T]) String() string {
+	if !v.Set {
+		return "<undefined>"
+	}
+	return fmt.Sprint(v.Value)
+}
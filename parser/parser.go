@@ -1,35 +1,150 @@
 package parser
 
 import (
+	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
+	"path/filepath"
 	"strings"
 )
 
 const structComment = "easyjson:json"
+const virtualComment = "easyjson:virtual"
+const methodsOption = "methods="
+const sampleOption = "sample="
 
 type Parser struct {
 	PkgPath     string
 	PkgName     string
 	StructNames []string
 	AllStructs  bool
+
+	// BuildTags are the tags the package is built with, only the files matching them are looked
+	// up for existing methods.
+	BuildTags []string
+
+	// MethodNames contains custom names of MarshalJSON/UnmarshalJSON methods for the types,
+	// specified with a 'methods=Marshal,Unmarshal' option of the type comment.
+	MethodNames map[string][2]string
+
+	// Samples contains expressions of sample values of the types used for generated benchmarks,
+	// specified with a 'sample=expr' option of the type comment.
+	Samples map[string]string
+
+	// KeepMarshalJSON contains the types with a hand-written MarshalJSON method in the package
+	// and no custom method names, the method is used for marshaling instead of a generated one.
+	KeepMarshalJSON map[string]bool
+
+	// VirtualFields contains the keys and the method names of virtual fields of the types, output
+	// by the marshalers with the values returned by the methods, specified with
+	// '//easyjson:virtual key=Method' lines of the type comment.
+	VirtualFields map[string][][2]string
+
+	// FieldPositions contains the positions ("file:line") of the fields of the structs by field
+	// name, used to point at the declarations in generation errors.
+	FieldPositions map[string]map[string]string
+
+	err error
 }
 
 type visitor struct {
 	*Parser
 
+	fset     *token.FileSet
 	name     string
 	explicit bool
+	options  string
+	doc      *ast.CommentGroup
 }
 
-func (p *Parser) needType(comments string) bool {
-	for _, v := range strings.Split(comments, "\n") {
+// needType returns whether the type needs to be processed according to its comment, and
+// the options given in the comment after the 'easyjson:json' mark.
+func (p *Parser) needType(comments *ast.CommentGroup) (bool, string) {
+	if comments == nil {
+		return false, ""
+	}
+
+	// Raw comments are used since CommentGroup.Text() drops '//easyjson:json'-like directives.
+	for _, c := range comments.List {
+		v := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
 		if strings.HasPrefix(v, structComment) {
-			return true
+			return true, strings.TrimSpace(strings.TrimPrefix(v, structComment))
 		}
 	}
-	return false
+	return false, ""
+}
+
+// parseVirtualFields processes the '//easyjson:virtual key=Method' lines of the type comment.
+func (p *Parser) parseVirtualFields(name string, comments *ast.CommentGroup) error {
+	if comments == nil {
+		return nil
+	}
+
+	for _, c := range comments.List {
+		v := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if !strings.HasPrefix(v, virtualComment) {
+			continue
+		}
+		for _, f := range strings.Fields(strings.TrimPrefix(v, virtualComment)) {
+			parts := strings.Split(f, "=")
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return fmt.Errorf("type %v: expected %v key=Method, got %q", name, virtualComment, f)
+			}
+			if p.VirtualFields == nil {
+				p.VirtualFields = make(map[string][][2]string)
+			}
+			p.VirtualFields[name] = append(p.VirtualFields[name], [2]string{parts[0], parts[1]})
+		}
+	}
+	return nil
+}
+
+// addFieldPositions records the positions of the named fields of the struct.
+func (v *visitor) addFieldPositions(name string, t *ast.StructType) {
+	for _, f := range t.Fields.List {
+		for _, id := range f.Names {
+			if v.FieldPositions == nil {
+				v.FieldPositions = make(map[string]map[string]string)
+			}
+			if v.FieldPositions[name] == nil {
+				v.FieldPositions[name] = make(map[string]string)
+			}
+			pos := v.fset.Position(id.Pos())
+			v.FieldPositions[name][id.Name] = fmt.Sprintf("%v:%d", pos.Filename, pos.Line)
+		}
+	}
+}
+
+// parseOptions processes the options of the type comment.
+func (p *Parser) parseOptions(name, options string) error {
+	for _, o := range strings.Fields(options) {
+		if strings.HasPrefix(o, sampleOption) {
+			sample := strings.TrimPrefix(o, sampleOption)
+			if sample == "" {
+				return fmt.Errorf("type %v: expected %vexpr, got %q", name, sampleOption, o)
+			}
+			if p.Samples == nil {
+				p.Samples = make(map[string]string)
+			}
+			p.Samples[name] = sample
+			continue
+		}
+		if !strings.HasPrefix(o, methodsOption) {
+			continue
+		}
+
+		names := strings.Split(strings.TrimPrefix(o, methodsOption), ",")
+		if len(names) != 2 || names[0] == "" || names[1] == "" {
+			return fmt.Errorf("type %v: expected %vMarshal,Unmarshal, got %q", name, methodsOption, o)
+		}
+		if p.MethodNames == nil {
+			p.MethodNames = make(map[string][2]string)
+		}
+		p.MethodNames[name] = [2]string{names[0], names[1]}
+	}
+	return nil
 }
 
 func (v *visitor) Visit(n ast.Node) (w ast.Visitor) {
@@ -39,7 +154,8 @@ func (v *visitor) Visit(n ast.Node) (w ast.Visitor) {
 		return v
 
 	case *ast.GenDecl:
-		v.explicit = v.needType(n.Doc.Text())
+		v.explicit, v.options = v.needType(n.Doc)
+		v.doc = n.Doc
 
 		if !v.explicit && !v.AllStructs {
 			return nil
@@ -51,11 +167,24 @@ func (v *visitor) Visit(n ast.Node) (w ast.Visitor) {
 		// Allow to specify non-structs explicitly independent of '-all' flag.
 		if v.explicit {
 			v.StructNames = append(v.StructNames, v.name)
+			if err := v.parseOptions(v.name, v.options); err != nil && v.err == nil {
+				v.err = err
+			}
+			if err := v.parseVirtualFields(v.name, v.doc); err != nil && v.err == nil {
+				v.err = err
+			}
+			if t, ok := n.Type.(*ast.StructType); ok {
+				v.addFieldPositions(v.name, t)
+			}
 			return nil
 		}
 		return v
 	case *ast.StructType:
 		v.StructNames = append(v.StructNames, v.name)
+		v.addFieldPositions(v.name, n)
+		if err := v.parseVirtualFields(v.name, v.doc); err != nil && v.err == nil {
+			v.err = err
+		}
 		return nil
 	}
 	return nil
@@ -73,6 +202,82 @@ func (p *Parser) Parse(fname string) error {
 		return err
 	}
 
-	ast.Walk(&visitor{Parser: p}, f)
+	ast.Walk(&visitor{Parser: p, fset: fset}, f)
+	if p.err != nil {
+		return p.err
+	}
+	return p.findMarshalJSON(filepath.Dir(fname))
+}
+
+// findMarshalJSON collects the parsed types having a MarshalJSON method declared in the Go files
+// of the package directory. Test files and files generated by easyjson are skipped, as well as
+// the types with custom method names, which don't clash with the existing methods.
+func (p *Parser) findMarshalJSON(dir string) error {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	types := make(map[string]bool, len(p.StructNames))
+	for _, name := range p.StructNames {
+		if _, ok := p.MethodNames[name]; !ok {
+			types[name] = true
+		}
+	}
+
+	ctx := build.Default
+	ctx.BuildTags = p.BuildTags
+
+	fset := token.NewFileSet()
+	for _, name := range names {
+		base := filepath.Base(name)
+		if strings.HasSuffix(base, "_test.go") || strings.HasSuffix(base, "_easyjson.go") ||
+			strings.HasPrefix(base, "easyjson-bootstrap") {
+			continue
+		}
+		if ok, err := ctx.MatchFile(dir, base); err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		if isGenerated(f) {
+			continue
+		}
+
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Name.Name != "MarshalJSON" {
+				continue
+			}
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if id, ok := recv.(*ast.Ident); ok && types[id.Name] {
+				if p.KeepMarshalJSON == nil {
+					p.KeepMarshalJSON = make(map[string]bool)
+				}
+				p.KeepMarshalJSON[id.Name] = true
+			}
+		}
+	}
 	return nil
 }
+
+// isGenerated returns whether the file is output by easyjson (with the default header), possibly
+// with a custom name.
+func isGenerated(f *ast.File) bool {
+	for _, c := range f.Comments {
+		if c.Pos() > f.Package {
+			break
+		}
+		if strings.Contains(c.Text(), "AUTOGENERATED FILE: easyjson") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package easyjson
+
+// Presence is a set of the keys of the fields present in a decoded JSON object, in the order the
+// keys first occur. With -track_presence, the generated unmarshalers of structs fill a Presence
+// field and the PresentFields method returns it. The field is never decoded from the input; it
+// is marshaled as an array of keys, unless it is tagged with `json:"-"`.
+type Presence []string
+
+// Has returns true if the key was present.
+func (p Presence) Has(key string) bool {
+	for _, k := range p {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Set adds the key to the set.
+func (p *Presence) Set(key string) {
+	if !p.Has(key) {
+		*p = append(*p, key)
+	}
+}
+
+// PresenceTracker is implemented by structs with unmarshalers generated with -track_presence.
+type PresenceTracker interface {
+	PresentFields() Presence
+}
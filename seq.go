@@ -0,0 +1,79 @@
+package easyjson
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/mailru/easyjson/jwriter"
+)
+
+// recordSeparator is the ASCII RS character starting every record of a JSON text sequence.
+const recordSeparator = 0x1e
+
+// SeqWriter writes values as a JSON text sequence (RFC 7464): every value is prefixed by RS
+// character and followed by a newline.
+type SeqWriter struct {
+	out io.Writer
+	w   jwriter.Writer
+}
+
+// NewSeqWriter creates a JSON text sequence writer outputting the data to out.
+func NewSeqWriter(out io.Writer) *SeqWriter {
+	return &SeqWriter{out: out}
+}
+
+// Write marshals a single record of the sequence to the output.
+func (s *SeqWriter) Write(v Marshaler) error {
+	s.w.RawByte(recordSeparator)
+	v.MarshalEasyJSON(&s.w)
+	s.w.RawByte('\n')
+
+	if err := s.w.Error; err != nil {
+		// Drop the partial record, releasing the buffer chunks.
+		s.w.Error = nil
+		s.w.DumpTo(ioutil.Discard)
+		return err
+	}
+
+	_, err := s.w.DumpTo(s.out)
+	return err
+}
+
+// SeqReader reads values from a JSON text sequence (RFC 7464).
+type SeqReader struct {
+	r *bufio.Reader
+}
+
+// NewSeqReader creates a JSON text sequence reader for the data from r.
+func NewSeqReader(r io.Reader) *SeqReader {
+	return &SeqReader{r: bufio.NewReader(r)}
+}
+
+// Reset discards the buffered data and makes the reader read the sequence from r, reusing the read
+// buffer, e.g. to keep readers in a sync.Pool. The previous reader is not referenced anymore.
+func (s *SeqReader) Reset(r io.Reader) {
+	if s.r == nil {
+		s.r = bufio.NewReader(r)
+		return
+	}
+	s.r.Reset(r)
+}
+
+// Read unmarshals the next record of the sequence into v. Empty records are skipped, io.EOF is
+// returned if there are no more records.
+func (s *SeqReader) Read(v Unmarshaler) error {
+	for {
+		data, err := s.r.ReadBytes(recordSeparator)
+		if len(data) > 0 && data[len(data)-1] == recordSeparator {
+			data = data[:len(data)-1]
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			return Unmarshal(data, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package easyjson
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/mailru/easyjson/jwriter"
+)
+
+// DefaultArrayStreamThreshold is the buffer size after which ArrayStream flushes the data to
+// the output if no other threshold is given.
+const DefaultArrayStreamThreshold = 32 * 1024
+
+// ArrayStream writes a JSON array element by element, flushing the buffered data to the output
+// whenever it grows over the threshold, so that a large array is never kept in memory as a whole.
+type ArrayStream struct {
+	w         *jwriter.Writer
+	out       io.Writer
+	threshold int
+
+	progress func(bytesWritten int64)
+	written  int64
+
+	first bool
+	err   error
+}
+
+// NewArrayStream creates an array stream marshaling the elements with w and flushing the data
+// to out once the buffer holds at least threshold bytes (DefaultArrayStreamThreshold if it is
+// not positive).
+func NewArrayStream(w *jwriter.Writer, out io.Writer, threshold int) *ArrayStream {
+	if threshold <= 0 {
+		threshold = DefaultArrayStreamThreshold
+	}
+	return &ArrayStream{w: w, out: out, threshold: threshold}
+}
+
+// SetProgress sets a callback invoked after each flush with the total number of bytes written to
+// the output so far, e.g. to report the progress of a large export. It is not invoked for a flush
+// that failed, nor after that.
+func (s *ArrayStream) SetProgress(progress func(bytesWritten int64)) {
+	s.progress = progress
+}
+
+// Start outputs the beginning of the array.
+func (s *ArrayStream) Start() {
+	s.w.RawByte('[')
+	s.first = true
+}
+
+// Add marshals a single element of the array. After an error of marshaling or writing to the
+// output the stream is stopped, and the error is returned by all subsequent calls.
+func (s *ArrayStream) Add(v Marshaler) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if !s.first {
+		s.w.RawByte(',')
+	}
+	s.first = false
+	v.MarshalEasyJSON(s.w)
+
+	if err := s.w.Error; err != nil {
+		// Drop the partial data, releasing the buffer chunks.
+		s.w.DumpTo(ioutil.Discard)
+		s.err = err
+		return err
+	}
+
+	if s.w.Size() >= s.threshold {
+		s.flush()
+	}
+	return s.err
+}
+
+// AddRows adds the rows of the iterator as elements of the array, flushing the data to the
+// output as it grows, e.g. to export the results of a database query without keeping them in
+// memory. It stops at the first error, returning it the same way as Add.
+func (s *ArrayStream) AddRows(it jwriter.RowMarshaler) error {
+	row := &rowMarshaler{it: it}
+	for s.err == nil && it.Next() {
+		s.Add(row)
+	}
+	return s.err
+}
+
+// rowMarshaler marshals the current row of an iterator as an element of an array stream.
+type rowMarshaler struct {
+	it jwriter.RowMarshaler
+}
+
+func (m *rowMarshaler) MarshalEasyJSON(w *jwriter.Writer) {
+	m.it.MarshalRow(w)
+}
+
+// End outputs the end of the array and flushes the remaining data to the output.
+func (s *ArrayStream) End() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.w.RawByte(']')
+	s.flush()
+	return s.err
+}
+
+func (s *ArrayStream) flush() {
+	n, err := s.w.DumpTo(s.out)
+	s.written += int64(n)
+	if err != nil {
+		s.err = err
+		return
+	}
+	if s.progress != nil {
+		s.progress(s.written)
+	}
+}
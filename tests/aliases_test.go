@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"testing"
+)
+
+func TestFieldAliasesUnmarshal(t *testing.T) {
+	for i, test := range []struct {
+		data string
+		want RenamedFields
+	}{
+		{data: `{"user_name":"a"}`, want: RenamedFields{UserName: "a"}},
+		{data: `{"username":"b"}`, want: RenamedFields{UserName: "b"}},
+		{data: `{"login":"c","mail":"c@example.com"}`, want: RenamedFields{UserName: "c", Email: "c@example.com"}},
+		{data: `{"login":"d","user_name":"e","username":"f"}`, want: RenamedFields{UserName: "f"}},
+		{data: `{"username":"g","user_name":"h"}`, want: RenamedFields{UserName: "h"}},
+	} {
+		var got RenamedFields
+		if err := got.UnmarshalJSON([]byte(test.data)); err != nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v", i, test.data, err)
+		}
+		if got != test.want {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %+v; want %+v", i, test.data, got, test.want)
+		}
+	}
+}
+
+func TestFieldAliasesMarshal(t *testing.T) {
+	v := RenamedFields{UserName: "a", Email: "a@example.com"}
+	want := `{"user_name":"a","email":"a@example.com"}`
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		t.Errorf("MarshalJSON() error: %v", err)
+	}
+	if got := string(data); got != want {
+		t.Errorf("MarshalJSON() = %s; want %s", got, want)
+	}
+}
@@ -1,7 +1,12 @@
 package tests
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
 	"reflect"
+	"strings"
 	"testing"
 
 	"encoding/json"
@@ -33,6 +38,20 @@ var testCases = []struct {
 	{&mapsValue, mapsString},
 	{&deepNestValue, deepNestString},
 	{&IntsValue, IntsString},
+	{&escapedKeyValue, escapedKeyString},
+	{&namedPrimitiveFieldsValue, namedPrimitiveFieldsString},
+	{&celsiusValue, celsiusString},
+	{&pointersValue, pointersString},
+	{&nilPtrSliceValue, nilPtrSliceString},
+	{&subStructPtrsValue, subStructPtrsString},
+	{&inlineMapValue, inlineMapString},
+	{&nilCollectionsValue, nilCollectionsString},
+	{&nilAsEmptyValue, nilAsEmptyString},
+	{&crossPackageValue, crossPackageString},
+	{&hexIntsValue, hexIntsString},
+	{&stdRawMessagesValue, stdRawMessagesString},
+	{&unsupportedFieldsValue, unsupportedFieldsString},
+	{&timestampsValue, timestampsString},
 }
 
 func TestMarshal(t *testing.T) {
@@ -49,6 +68,86 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestMarshalToString(t *testing.T) {
+	for i, test := range testCases {
+		v := test.Decoded.(easyjson.Marshaler)
+		want, err := easyjson.Marshal(v)
+		if err != nil {
+			t.Errorf("[%d, %T] Marshal() error: %v", i, test.Decoded, err)
+		}
+
+		got, err := easyjson.MarshalToString(v)
+		if err != nil {
+			t.Errorf("[%d, %T] MarshalToString() error: %v", i, test.Decoded, err)
+		}
+		if got != string(want) {
+			t.Errorf("[%d, %T] MarshalToString() = %v; want %s", i, test.Decoded, got, want)
+		}
+	}
+}
+
+func TestMarshalToStringPooled(t *testing.T) {
+	got, err := easyjson.MarshalToString(IOStruct{Name: strings.Repeat("a", 10000)})
+	if err != nil {
+		t.Fatalf("MarshalToString() error: %v", err)
+	}
+	want := strings.Clone(got)
+
+	// The chunks released to the pool are reused and overwritten by the next values.
+	for i := 0; i < 10; i++ {
+		if _, err := easyjson.MarshalToString(IOStruct{Name: strings.Repeat("b", 10000)}); err != nil {
+			t.Fatalf("[%d] MarshalToString() error: %v", i, err)
+		}
+	}
+	if got != want {
+		t.Errorf("MarshalToString() result changed after marshaling other values")
+	}
+}
+
+func TestMarshalToStringError(t *testing.T) {
+	if _, err := easyjson.MarshalToString(failingMarshaler{}); err != errFailingMarshaler {
+		t.Errorf("MarshalToString() error = %v; want %v", err, errFailingMarshaler)
+	}
+}
+
+func TestWriteFramed(t *testing.T) {
+	v := IOStruct{Name: strings.Repeat("frame", 1000), Count: 7}
+	want, err := easyjson.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(easyjson.WriteFramed(w, v))
+	}()
+
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		t.Fatalf("reading the prefix error: %v", err)
+	}
+	payload, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("WriteFramed() error: %v", err)
+	}
+	if size := binary.BigEndian.Uint32(prefix[:]); int(size) != len(payload) {
+		t.Errorf("WriteFramed() prefix = %v; want payload length %v", size, len(payload))
+	}
+	if !bytes.Equal(payload, want) {
+		t.Errorf("WriteFramed() payload = %s; want %s", payload, want)
+	}
+}
+
+func TestWriteFramedError(t *testing.T) {
+	var out bytes.Buffer
+	if err := easyjson.WriteFramed(&out, failingMarshaler{}); err != errFailingMarshaler {
+		t.Errorf("WriteFramed() error = %v; want %v", err, errFailingMarshaler)
+	}
+	if out.Len() != 0 {
+		t.Errorf("WriteFramed() output = %q; want none", out.String())
+	}
+}
+
 func TestUnmarshal(t *testing.T) {
 	for i, test := range testCases {
 		v1 := reflect.New(reflect.TypeOf(test.Decoded).Elem()).Interface()
@@ -95,6 +194,101 @@ func TestRawMessageSTD(t *testing.T) {
 	}
 }
 
+func TestStdRawMessageCopy(t *testing.T) {
+	data := []byte(`{"Object":{"a":1}}`)
+
+	var v StdRawMessages
+	if err := v.UnmarshalJSON(data); err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	for i := range data {
+		data[i] = ' '
+	}
+	if got := string(v.Object); got != `{"a":1}` {
+		t.Errorf("UnmarshalJSON() Object = %v; want %v", got, `{"a":1}`)
+	}
+}
+
+func TestUnsupportedFieldsIgnored(t *testing.T) {
+	var v UnsupportedFields
+	if err := v.UnmarshalJSON([]byte(`{"Name":"b","Callback":"f","Events":[1,2],"Count":2}`)); err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	if v.Name != "b" || v.Count != 2 || v.Callback != nil || v.Events != nil {
+		t.Errorf("UnmarshalJSON() = %+v; want only Name and Count set", v)
+	}
+}
+
+func TestEscapedKey(t *testing.T) {
+	data, err := escapedKeyValue.MarshalJSON()
+	if err != nil {
+		t.Errorf("MarshalJSON() error: %v", err)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("json.Unmarshal(%s) error: %v", data, err)
+	}
+	want := map[string]string{"weird \"key\"\n\x01": "test"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("json.Unmarshal(%s) = %v; want %v", data, got, want)
+	}
+}
+
+func TestPointersReuse(t *testing.T) {
+	i, s := 1, []int{3}
+	sub := &SubStruct{Value: "old"}
+	v := Pointers{Struct: sub, Int: &i, Slice: &s, StructNil: &SubStruct{}, IntNil: &i, SliceNil: &s}
+
+	if err := v.UnmarshalJSON([]byte(pointersString)); err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+
+	if v.Struct != sub || v.Int != &i || v.Slice != &s {
+		t.Errorf("UnmarshalJSON() reallocated non-nil pointers")
+	}
+	if *v.Struct != (SubStruct{Value: "test"}) || i != 5 || !reflect.DeepEqual(s, []int{1, 2}) {
+		t.Errorf("UnmarshalJSON() = %+v, %v, %v; want decoded values", *v.Struct, i, s)
+	}
+	if v.StructNil != nil || v.IntNil != nil || v.SliceNil != nil {
+		t.Errorf("UnmarshalJSON() = %+v; want null fields to be nil", v)
+	}
+}
+
+func TestNullNonPointer(t *testing.T) {
+	v := PrimitiveTypes{String: "keep", Int: 5}
+	if err := v.UnmarshalJSON([]byte(`{"String":null,"Int":null}`)); err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	if v.String != "keep" || v.Int != 5 {
+		t.Errorf("UnmarshalJSON() = %+v; want null to leave values unchanged", v)
+	}
+}
+
+func TestInlineMap(t *testing.T) {
+	var v InlineMap
+	err := v.UnmarshalJSON([]byte(`{"a":"1","Name":"test","b":"2","c":null,"Age":5}`))
+	if err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	want := InlineMap{Name: "test", Extra: map[string]string{"a": "1", "b": "2"}, Age: 5}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", v, want)
+	}
+
+	data, err := InlineMap{Name: "test"}.MarshalJSON()
+	if err != nil || string(data) != `{"Name":"test","Age":0}` {
+		t.Errorf("MarshalJSON() = %s, %v; want no inline entries", data, err)
+	}
+}
+
+func TestBoolStringError(t *testing.T) {
+	var v PrimitiveTypes
+	if err := v.UnmarshalJSON([]byte(`{"BoolString":"yes"}`)); err == nil {
+		t.Errorf("UnmarshalJSON() ok; want error")
+	}
+}
+
 func TestParseNull(t *testing.T) {
 	var got, want SubStruct
 	if err := easyjson.Unmarshal([]byte("null"), &got); err != nil {
@@ -131,3 +325,27 @@ func TestSpecialCases(t *testing.T) {
 		}
 	}
 }
+
+func TestSkippedFields(t *testing.T) {
+	for i, test := range []struct {
+		Value OmitEmpty
+		Want  []string
+	}{
+		{OmitEmpty{}, []string{"StrE", "StrNE", "PtrE", "PtrNE", "intField", "IntE", "SubPE", "SubPNE"}},
+		{omitEmptyValue, []string{"StrE", "PtrE", "IntE", "SubPE"}},
+	} {
+		w := jwriter.Writer{Debug: true}
+		test.Value.MarshalEasyJSON(&w)
+
+		got := w.SkippedFields()
+		if !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("[%d] SkippedFields() = %v; want %v", i, got, test.Want)
+		}
+	}
+
+	w := jwriter.Writer{}
+	omitEmptyValue.MarshalEasyJSON(&w)
+	if got := w.SkippedFields(); got != nil {
+		t.Errorf("SkippedFields() without Debug = %v; want nil", got)
+	}
+}
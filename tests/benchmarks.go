@@ -0,0 +1,14 @@
+package tests
+
+//easyjson:json sample=benchSample
+type BenchStruct struct {
+	Name   string
+	Values []int
+	Tags   map[string]string
+}
+
+var benchSample = BenchStruct{
+	Name:   "sample",
+	Values: []int{1, 2, 3},
+	Tags:   map[string]string{"a": "b"},
+}
@@ -0,0 +1,15 @@
+package tests
+
+import "testing"
+
+func TestGeneratedBenchmarks(t *testing.T) {
+	for name, bench := range map[string]func(*testing.B){
+		"BenchmarkEasyJSONMarshalBenchStruct":   BenchmarkEasyJSONMarshalBenchStruct,
+		"BenchmarkEasyJSONUnmarshalBenchStruct": BenchmarkEasyJSONUnmarshalBenchStruct,
+	} {
+		res := testing.Benchmark(bench)
+		if res.N == 0 {
+			t.Errorf("%v() did not run", name)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package tests
+
+import "fmt"
+
+// PackedID implements only the binary marshaling interfaces.
+type PackedID [8]byte
+
+func (id PackedID) MarshalBinary() ([]byte, error) {
+	return id[:], nil
+}
+
+func (id *PackedID) UnmarshalBinary(data []byte) error {
+	if len(data) != len(id) {
+		return fmt.Errorf("packed id of %v bytes; want %v", len(data), len(id))
+	}
+	copy(id[:], data)
+	return nil
+}
+
+// TextID implements both the binary and the text marshaling interfaces, so it is not encoded
+// with the binary ones.
+type TextID struct {
+	Hi, Lo byte
+}
+
+func (id TextID) MarshalBinary() ([]byte, error) {
+	return []byte{id.Hi, id.Lo}, nil
+}
+
+func (id *TextID) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("text id of %v bytes; want 2", len(data))
+	}
+	id.Hi, id.Lo = data[0], data[1]
+	return nil
+}
+
+func (id TextID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%02x%02x", id.Hi, id.Lo)), nil
+}
+
+func (id *TextID) UnmarshalText(data []byte) error {
+	_, err := fmt.Sscanf(string(data), "%02x%02x", &id.Hi, &id.Lo)
+	return err
+}
+
+//easyjson:json
+type BinaryFields struct {
+	ID     PackedID   `json:"id"`
+	Parent *PackedID  `json:"parent"`
+	Refs   []PackedID `json:"refs,omitempty"`
+	Text   TextID     `json:"text"`
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestBinaryMarshaler(t *testing.T) {
+	parent := PackedID{0xff, 0xfe}
+
+	for i, test := range []struct {
+		v    BinaryFields
+		want string
+	}{
+		{v: BinaryFields{}, want: `{"id":"AAAAAAAAAAA=","parent":null,"text":{"Hi":0,"Lo":0}}`},
+		{
+			v:    BinaryFields{ID: PackedID{1, 2, 3, 4, 5, 6, 7, 8}, Parent: &parent, Refs: []PackedID{{1}, {2}}, Text: TextID{1, 2}},
+			want: `{"id":"AQIDBAUGBwg=","parent":"//4AAAAAAAA=","refs":["AQAAAAAAAAA=","AgAAAAAAAAA="],"text":{"Hi":1,"Lo":2}}`,
+		},
+	} {
+		data, err := easyjson.Marshal(test.v)
+		if err != nil {
+			t.Errorf("[%d] Marshal() error: %v", i, err)
+			continue
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] Marshal() = %v; want %v", i, got, test.want)
+		}
+
+		var got BinaryFields
+		if err := easyjson.Unmarshal(data, &got); err != nil {
+			t.Errorf("[%d] Unmarshal() error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("[%d] Unmarshal() = %+v; want %+v", i, got, test.v)
+		}
+	}
+}
+
+func TestBinaryUnmarshalerErrors(t *testing.T) {
+	for i, data := range []string{
+		`{"id":"AQID"}`,
+		`{"id":"not base64"}`,
+		`{"id":[1,2,3,4,5,6,7,8]}`,
+	} {
+		var v BinaryFields
+		if err := easyjson.Unmarshal([]byte(data), &v); err == nil {
+			t.Errorf("[%d, %q] Unmarshal() ok; want error", i, data)
+		}
+	}
+
+	v := BinaryFields{ID: PackedID{1}}
+	if err := easyjson.Unmarshal([]byte(`{"id":null}`), &v); err != nil {
+		t.Errorf("Unmarshal() of null error: %v", err)
+	}
+	if v.ID != (PackedID{1}) {
+		t.Errorf("Unmarshal() of null = %v; want unchanged", v.ID)
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestBuildTagMarshal(t *testing.T) {
+	defer easyjson.SetBuildTag("pro", false)
+	defer easyjson.SetBuildTag("beta", false)
+
+	beta := "on"
+	v := Gated{Name: "a", Quota: 10, Limits: map[string]int{"x": 1}, Beta: &beta, Plan: "team"}
+	for i, test := range []struct {
+		pro, beta bool
+		want      string
+	}{
+		{want: `{"Name":"a"}`},
+		{pro: true, want: `{"Name":"a","quota":10,"limits":{"x":1},"plan":"team"}`},
+		{beta: true, want: `{"Name":"a","Beta":"on"}`},
+		{pro: true, beta: true, want: `{"Name":"a","quota":10,"limits":{"x":1},"Beta":"on","plan":"team"}`},
+	} {
+		easyjson.SetBuildTag("pro", test.pro)
+		easyjson.SetBuildTag("beta", test.beta)
+
+		data, err := v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d] MarshalJSON() error: %v", i, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] MarshalJSON() = %s; want %s", i, got, test.want)
+		}
+	}
+}
+
+func TestBuildTagUnmarshal(t *testing.T) {
+	defer easyjson.SetBuildTag("pro", false)
+
+	data := `{"Name":"a","quota":10,"limits":{"x":1},"Beta":"on","plan":"team"}`
+	for i, test := range []struct {
+		pro  bool
+		want Gated
+	}{
+		{want: Gated{Name: "a"}},
+		{pro: true, want: Gated{Name: "a", Quota: 10, Limits: map[string]int{"x": 1}, Plan: "team"}},
+	} {
+		easyjson.SetBuildTag("pro", test.pro)
+
+		var got Gated
+		if err := got.UnmarshalJSON([]byte(data)); err != nil {
+			t.Errorf("[%d] UnmarshalJSON() error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d] UnmarshalJSON() = %+v; want %+v", i, got, test.want)
+		}
+	}
+}
+
+func TestBuildTagRequired(t *testing.T) {
+	defer easyjson.SetBuildTag("pro", false)
+
+	var v Gated
+	if err := v.UnmarshalJSON([]byte(`{"Name":"a"}`)); err != nil {
+		t.Errorf("UnmarshalJSON() with disabled tag error: %v", err)
+	}
+
+	easyjson.SetBuildTag("pro", true)
+	if err := v.UnmarshalJSON([]byte(`{"Name":"a"}`)); err == nil {
+		t.Errorf("UnmarshalJSON() with enabled tag ok; want error for missing required field")
+	}
+}
@@ -0,0 +1,14 @@
+package tests
+
+type CanonicalExample struct {
+	String   string    `json:"string"`
+	Numbers  []float64 `json:"numbers"`
+	Literals []*bool   `json:"literals"`
+}
+
+type CanonicalSorting struct {
+	Keys  map[string]string `json:"keys"`
+	Zebra int
+	Apple *CanonicalSorting `json:",omitempty"`
+	Mark  string            `json:"<mark>"`
+}
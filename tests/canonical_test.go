@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"math"
+	"testing"
+)
+
+// Examples from RFC 8785, sections 3.2.2 and 3.2.3.
+func TestCanonicalExample(t *testing.T) {
+	input := `{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+	want := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	var v CanonicalExample
+	if err := v.UnmarshalJSON([]byte(input)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	data, err := v.MarshalJSON()
+	if err != nil {
+		t.Errorf("MarshalJSON() error: %v", err)
+	}
+	if got := string(data); got != want {
+		t.Errorf("MarshalJSON() = %s; want %s", got, want)
+	}
+}
+
+func TestCanonicalSorting(t *testing.T) {
+	v := CanonicalSorting{
+		Keys: map[string]string{
+			"\u20ac":     "Euro Sign",
+			"\r":         "Carriage Return",
+			"\ufb33":     "Hebrew Letter Dalet With Dagesh",
+			"1":          "One",
+			"\U0001F600": "Emoji: Grinning Face",
+			"\u0080":     "Control",
+			"\u00f6":     "Latin Small Letter O With Diaeresis",
+		},
+		Zebra: 1,
+		Apple: &CanonicalSorting{Zebra: 2},
+		Mark:  "<b> ",
+	}
+	want := `{"<mark>":"<b> ","Apple":{"<mark>":"","Zebra":2,"keys":null},"Zebra":1,"keys":{` +
+		`"\r":"Carriage Return",` +
+		`"1":"One",` +
+		"\"\u0080\":\"Control\"," +
+		"\"\u00f6\":\"Latin Small Letter O With Diaeresis\"," +
+		"\"\u20ac\":\"Euro Sign\"," +
+		"\"\U0001F600\":\"Emoji: Grinning Face\"," +
+		"\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}}"
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		t.Errorf("MarshalJSON() error: %v", err)
+	}
+	if got := string(data); got != want {
+		t.Errorf("MarshalJSON() = %s; want %s", got, want)
+	}
+}
+
+func TestCanonicalNaN(t *testing.T) {
+	v := CanonicalExample{Numbers: []float64{math.NaN()}}
+	if _, err := v.MarshalJSON(); err == nil {
+		t.Errorf("MarshalJSON() of NaN ok; want error")
+	}
+}
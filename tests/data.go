@@ -1,12 +1,14 @@
 package tests
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"time"
 
 	"github.com/mailru/easyjson"
 	"github.com/mailru/easyjson/opt"
+	"github.com/mailru/easyjson/tests/ext"
 )
 
 type PrimitiveTypes struct {
@@ -25,6 +27,7 @@ type PrimitiveTypes struct {
 	Uint32 uint32
 	Uint64 uint64
 
+	BoolString  bool  `json:",string"`
 	IntString   int   `json:",string"`
 	Int8String  int8  `json:",string"`
 	Int16String int16 `json:",string"`
@@ -61,6 +64,7 @@ var primitiveTypesValue = PrimitiveTypes{
 	Uint32: math.MaxUint32,
 	Uint64: math.MaxUint64,
 
+	BoolString:  true,
 	IntString:   math.MinInt32,
 	Int8String:  math.MinInt8,
 	Int16String: math.MinInt16,
@@ -94,6 +98,7 @@ var primitiveTypesString = "{" +
 	`"Uint32":` + fmt.Sprint(math.MaxUint32) + `,` +
 	`"Uint64":` + fmt.Sprint(uint64(math.MaxUint64)) + `,` +
 
+	`"BoolString":"true",` +
 	`"IntString":"` + fmt.Sprint(math.MinInt32) + `",` +
 	`"Int8String":"` + fmt.Sprint(math.MinInt8) + `",` +
 	`"Int16String":"` + fmt.Sprint(math.MinInt16) + `",` +
@@ -539,7 +544,597 @@ var IntsValue = Ints{1, 2, 3, 4, 5}
 
 var IntsString = `[1,2,3,4,5]`
 
+type EscapedKey struct {
+	Weird string `json:"weird \"key\"\n\x01"`
+}
+
+var escapedKeyValue = EscapedKey{Weird: "test"}
+var escapedKeyString = `{"weird \"key\"\n\u0001":"test"}`
+
+type (
+	Email  string
+	UserID int64
+	Ratio  float64
+)
+
+type NamedPrimitiveFields struct {
+	Email     Email
+	UserID    UserID
+	UserIDStr UserID `json:",string"`
+	Ratio     Ratio
+
+	EmailPtr *Email
+	Emails   []Email
+	Ratios   map[Email]Ratio
+}
+
+var email = Email("user@example.com")
+
+var namedPrimitiveFieldsValue = NamedPrimitiveFields{
+	Email:     "user@example.com",
+	UserID:    math.MaxInt64,
+	UserIDStr: 42,
+	Ratio:     0.25,
+
+	EmailPtr: &email,
+	Emails:   []Email{"a@example.com", "b@example.com"},
+	Ratios:   map[Email]Ratio{"c@example.com": 1.5},
+}
+
+var namedPrimitiveFieldsString = `{` +
+	`"Email":"user@example.com",` +
+	`"UserID":` + fmt.Sprint(int64(math.MaxInt64)) + `,` +
+	`"UserIDStr":"42",` +
+	`"Ratio":0.25,` +
+	`"EmailPtr":"user@example.com",` +
+	`"Emails":["a@example.com","b@example.com"],` +
+	`"Ratios":{"c@example.com":1.5}` +
+	`}`
+
+//easyjson:json
+type Celsius float64
+
+var celsiusValue = Celsius(-12.5)
+var celsiusString = `-12.5`
+
 type RequiredOptionalStruct struct {
 	FirstName string `json:"first_name,required"`
 	Lastname  string `json:"last_name"`
 }
+
+type Pointers struct {
+	Struct *SubStruct
+	Int    *int
+	Slice  *[]int
+
+	StructNil *SubStruct
+	IntNil    *int
+	SliceNil  *[]int
+}
+
+var pointersInt = 5
+var pointersSlice = []int{1, 2}
+
+var pointersValue = Pointers{
+	Struct: &SubStruct{Value: "test"},
+	Int:    &pointersInt,
+	Slice:  &pointersSlice,
+}
+
+var pointersString = `{` +
+	`"Struct":{"Value":"test","Value2":""},` +
+	`"Int":5,` +
+	`"Slice":[1,2],` +
+	`"StructNil":null,` +
+	`"IntNil":null,` +
+	`"SliceNil":null` +
+	`}`
+
+type NilPtrSlice struct {
+	Items []*SubStruct
+	Ints  []*int
+}
+
+//easyjson:json
+type SubStructPtrs []*SubStruct
+
+var nilPtrSliceValue = NilPtrSlice{
+	Items: []*SubStruct{{Value: "a"}, nil, {Value: "b"}},
+	Ints:  []*int{nil, &pointersInt},
+}
+
+var nilPtrSliceString = `{` +
+	`"Items":[{"Value":"a","Value2":""},null,{"Value":"b","Value2":""}],` +
+	`"Ints":[null,5]` +
+	`}`
+
+var subStructPtrsValue = SubStructPtrs{nil, {Value: "a"}, nil}
+var subStructPtrsString = `[null,{"Value":"a","Value2":""},null]`
+
+type InlineMap struct {
+	Name  string
+	Extra map[string]string `json:",inline"`
+	Age   int
+}
+
+var inlineMapValue = InlineMap{
+	Name:  "test",
+	Extra: map[string]string{"color": "red"}, // only one item since map iteration is randomized
+	Age:   5,
+}
+
+var inlineMapString = `{"Name":"test","color":"red","Age":5}`
+
+type NilCollections struct {
+	Slice []int
+	Map   map[string]int
+}
+
+var nilCollectionsValue = NilCollections{}
+var nilCollectionsString = `{"Slice":[],"Map":null}`
+
+type CrossPackage struct {
+	Value    ext.Value
+	Ptr      *ext.Value
+	Slice    []ext.Value
+	Map      map[string]ext.Value
+	ValueNil *ext.Value
+	PtrMap   map[string]ext.PtrValue
+}
+
+var crossPackageValue = CrossPackage{
+	Value:  ext.Value{V: 1},
+	Ptr:    &ext.Value{V: 2},
+	Slice:  []ext.Value{{V: 3}},
+	Map:    map[string]ext.Value{"a": {V: 4}},
+	PtrMap: map[string]ext.PtrValue{"b": {V: 5}},
+}
+
+var crossPackageString = `{` +
+	`"Value":{"easyjson":1},` +
+	`"Ptr":{"easyjson":2},` +
+	`"Slice":[{"easyjson":3}],` +
+	`"Map":{"a":{"easyjson":4}},` +
+	`"ValueNil":null,` +
+	`"PtrMap":{"b":{"easyjson":5}}` +
+	`}`
+
+type HexInts struct {
+	Zero     int     `easyjson:"format=hex"`
+	Positive int64   `easyjson:"format=hex"`
+	Negative int16   `easyjson:"format=hex"`
+	Unsigned uint8   `easyjson:"format=hex"`
+	Slice    []int32 `easyjson:"format=hex"`
+	Ptr      *uint64 `json:",format=hex"` // The deprecated form of the tag.
+}
+
+var hexIntsValue = HexInts{
+	Positive: 0xff,
+	Negative: -0x1f,
+	Unsigned: 0xab,
+	Slice:    []int32{0, -1, 0x7fffffff},
+	Ptr:      &hexIntsPtrValue,
+}
+
+var hexIntsPtrValue uint64 = 0xffffffffffffffff
+
+var hexIntsString = `{` +
+	`"Zero":"0x0",` +
+	`"Positive":"0xff",` +
+	`"Negative":"-0x1f",` +
+	`"Unsigned":"0xab",` +
+	`"Slice":["0x0","-0x1","0x7fffffff"],` +
+	`"Ptr":"0xffffffffffffffff"` +
+	`}`
+
+type StdRawMessages struct {
+	Object json.RawMessage
+	String json.RawMessage
+	Slice  []json.RawMessage
+	Map    map[string]json.RawMessage
+}
+
+var stdRawMessagesValue = StdRawMessages{
+	Object: json.RawMessage(`{"a":[1,2]}`),
+	String: json.RawMessage(`"abc"`),
+	Slice:  []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`null`)},
+	Map:    map[string]json.RawMessage{"b": json.RawMessage(`true`)},
+}
+
+var stdRawMessagesString = `{` +
+	`"Object":{"a":[1,2]},` +
+	`"String":"abc",` +
+	`"Slice":[1,null],` +
+	`"Map":{"b":true}` +
+	`}`
+
+type Trimmed struct {
+	Name  string   `easyjson:"trim"`
+	Tags  []string `json:"tags" easyjson:"trim"`
+	Plain string
+}
+
+type Gated struct {
+	Name   string
+	Quota  int               `json:"quota" easyjson:"buildtag=pro"`
+	Limits map[string]int    `json:"limits,omitempty" easyjson:"buildtag=pro"`
+	Beta   *string           `easyjson:"buildtag=beta"`
+	Plan   string            `json:"plan,required" easyjson:"buildtag=pro"`
+	Extra  map[string]string `json:"-" easyjson:"buildtag=pro"`
+}
+
+type Audit struct {
+	By string `json:"by"`
+}
+
+type Meta struct {
+	ID   int
+	Tags []string `json:"tags,omitempty"`
+	*Audit
+}
+
+type EmbeddedPtrs struct {
+	*Meta
+	Name string
+}
+
+type IntBools struct {
+	Active bool      `easyjson:"format=intbool"`
+	Flags  []bool    `json:"flags" easyjson:"format=intbool"`
+	Named  NamedBool `json:"named,omitempty" easyjson:"format=intbool"`
+	Plain  bool
+}
+
+// dynamicKeyPrefix is the runtime state the keys of DynamicKeys depend on.
+var dynamicKeyPrefix = "v1_"
+
+type DynamicKeys struct {
+	Name   string
+	Count  int          `json:"count,omitempty"`
+	Nested *DynamicKeys `json:",omitempty"`
+}
+
+// FieldJSONKey implements easyjson.FieldKeyer interface.
+func (DynamicKeys) FieldJSONKey(fieldName string) string {
+	return dynamicKeyPrefix + fieldName
+}
+
+type TypedMapItem struct {
+	Name  string
+	Count int
+}
+
+type TypedMaps struct {
+	Items map[string]*TypedMapItem
+	Ints  map[string][]int
+}
+
+type GenericMaps struct {
+	Items map[string]interface{}
+	Ints  map[string]interface{}
+}
+
+type ErrorResponse struct {
+	Code  int
+	Err   error `json:"error"`
+	Cause error `json:"cause,omitempty"`
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64
+}
+
+func (c *Circle) Area() float64 {
+	return 3 * c.Radius * c.Radius
+}
+
+type Drawing struct {
+	Shape
+	Name string
+	Main Shape `json:"main"`
+}
+
+type SignedPayload struct {
+	Subject string
+	Scopes  []string
+}
+
+type Signed struct {
+	Payload    SignedPayload   `easyjson:"preserve"`
+	PayloadRaw json.RawMessage `json:"-"`
+	Signature  string
+}
+
+type UnsupportedFields struct {
+	Name     string
+	Callback func()
+	Events   chan int
+	Done     <-chan struct{} `json:"-"`
+	Count    int
+}
+
+var unsupportedFieldsValue = UnsupportedFields{Name: "a", Count: 1}
+var unsupportedFieldsString = `{"Name":"a","Count":1}`
+
+type Merge struct {
+	*SubP
+
+	Name  string
+	Count int
+	Sub   SubStruct
+	Ptr   *SubStruct
+	Map   map[string]int
+	Slice []int
+}
+
+type Filtered struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Sub     SubStruct
+	Skipped string         `json:"-"`
+	Extra   map[string]int `json:",inline"`
+}
+
+type Timestamps struct {
+	Unix  time.Time  `easyjson:"format=unix"`
+	Milli time.Time  `easyjson:"format=unixmilli"`
+	Nano  time.Time  `easyjson:"format=unixnano"`
+	Ptr   *time.Time `easyjson:"format=unixmilli"`
+	Zero  time.Time  `easyjson:"format=unixmilli"`
+	Std   time.Time
+}
+
+type TimeZones struct {
+	UTC   time.Time   `easyjson:"tz=UTC"`
+	Local time.Time   `easyjson:"tz=Local"`
+	Ptr   *time.Time  `easyjson:"tz=UTC"`
+	Unix  time.Time   `easyjson:"format=unix,tz=Local"`
+	Slice []time.Time `easyjson:"tz=UTC"`
+	Std   time.Time
+}
+
+var timestampsPtrValue = time.Date(1969, 7, 20, 20, 17, 40, 0, time.UTC)
+
+var timestampsValue = Timestamps{
+	Unix:  time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+	Milli: time.Date(2017, 1, 2, 3, 4, 5, 6000000, time.UTC),
+	Nano:  time.Date(2017, 1, 2, 3, 4, 5, 6, time.UTC),
+	Ptr:   &timestampsPtrValue,
+	Std:   time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+var timestampsString = `{` +
+	`"Unix":1483326245,` +
+	`"Milli":1483326245006,` +
+	`"Nano":1483326245000000006,` +
+	`"Ptr":-14182940000,` +
+	`"Zero":0,` +
+	`"Std":"2017-01-02T03:04:05Z"` +
+	`}`
+
+type Color int
+
+const (
+	ColorUnknown Color = iota
+	ColorRed
+	ColorGreen
+)
+
+func (c Color) EnumLabel() string {
+	switch c {
+	case ColorRed:
+		return "red"
+	case ColorGreen:
+		return "green"
+	}
+	return "unknown"
+}
+
+func (c *Color) SetEnumLabel(label string) bool {
+	switch label {
+	case "red":
+		*c = ColorRed
+	case "green":
+		*c = ColorGreen
+	case "unknown":
+		*c = ColorUnknown
+	default:
+		return false
+	}
+	return true
+}
+
+type Palette struct {
+	Primary Color     `json:"primary"`
+	Accent  Color     `json:"accent" easyjson:"enum_fallback=ColorUnknown"`
+	Extra   []Color   `json:"extra,omitempty" easyjson:"enum_fallback=ColorUnknown"`
+	Ptr     *Color    `json:"ptr,omitempty" easyjson:"enum_fallback=ColorUnknown"`
+	Level   ext.Level `json:"level" easyjson:"enum_fallback=LevelUnknown"`
+}
+
+type Generic struct {
+	Value  any
+	Values []any          `json:",omitempty"`
+	Extra  map[string]any `json:",omitempty"`
+	Ptr    *any           `json:",omitempty"`
+}
+
+// PaymentMethod is a sealed interface of the ways an Order is paid with.
+type PaymentMethod interface {
+	isPaymentMethod()
+}
+
+type CardPayment struct {
+	Last4 string
+}
+
+type WirePayment struct {
+	IBAN string
+}
+
+func (CardPayment) isPaymentMethod()  {}
+func (*WirePayment) isPaymentMethod() {}
+
+type Order struct {
+	ID      int
+	Payment PaymentMethod   `easyjson:"oneof=card:CardPayment|wire:*WirePayment"`
+	Refunds []PaymentMethod `json:",omitempty" easyjson:"oneof=card:CardPayment|wire:*WirePayment"`
+}
+
+type RenamedFields struct {
+	UserName string `json:"user_name" easyjson:"aliases=username;login"`
+	Email    string `json:"email,omitempty" easyjson:"aliases=mail"`
+}
+
+type Telemetry struct {
+	Device  string
+	Samples []uint32 `easyjson:"format=base64le_u32"`
+}
+
+type IndentedItem struct {
+	ID    int
+	Attrs map[string]string
+	Extra IndentedEmpty `json:"extra"`
+}
+
+type IndentedEmpty struct{}
+
+type IndentedDoc struct {
+	Title  string         `json:"title"`
+	Tags   []string       `json:"tags"`
+	Items  []IndentedItem `json:"items"`
+	Matrix [][]float64    `json:"matrix,omitempty"`
+	Parent *IndentedDoc   `json:"parent"`
+}
+
+type EnvelopeData struct {
+	X    int    `json:"x"`
+	Note string `json:"note,omitempty"`
+}
+
+type Envelope struct {
+	Kind string        `json:"kind"`
+	Data EnvelopeData  `json:"data" easyjson:"format=jsonstring"`
+	Meta *EnvelopeData `json:"meta" easyjson:"format=jsonstring"`
+	List []int         `json:"list,omitempty" easyjson:"format=jsonstring"`
+}
+
+// Employee has virtual fields derived from the stored ones.
+//
+//easyjson:virtual full_name=FullName years=Years
+//easyjson:virtual manager=IsManager
+type Employee struct {
+	First   string `json:"first"`
+	Last    string `json:"last"`
+	Started int    `json:"started"`
+	Reports []string
+}
+
+func (e Employee) FullName() string {
+	return e.First + " " + e.Last
+}
+
+func (e *Employee) Years() int {
+	return 2024 - e.Started
+}
+
+func (e Employee) IsManager() bool {
+	return len(e.Reports) > 0
+}
+
+type SparseMaps struct {
+	Labels   map[string]string         `json:"labels" easyjson:"value_omitempty"`
+	Counts   map[string]*int           `json:"counts,omitempty" easyjson:"value_omitempty"`
+	Groups   map[string][]string       `json:"groups" easyjson:"value_omitempty"`
+	Nested   map[string]map[string]int `json:"nested" easyjson:"value_omitempty"`
+	Settings map[string]EnvelopeData   `json:"settings" easyjson:"value_omitempty"`
+}
+
+// Version is a version number used as a map key, marshaled as text, e.g. v1.2.
+type Version uint16
+
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("v%d.%d", v>>8, v&0xff)), nil
+}
+
+func (v *Version) UnmarshalText(data []byte) error {
+	var major, minor uint8
+	if _, err := fmt.Sscanf(string(data), "v%d.%d", &major, &minor); err != nil {
+		return fmt.Errorf("bad version %q: %v", data, err)
+	}
+	*v = Version(major)<<8 | Version(minor)
+	return nil
+}
+
+type TextKeyMaps struct {
+	Hits     map[time.Time]int   `json:"hits"`
+	Releases map[Version]string  `json:"releases,omitempty"`
+	Nested   map[time.Time][]int `json:"nested,omitempty"`
+}
+
+type LogLine struct {
+	Message string   `json:"msg" easyjson:"maxlen=8"`
+	Tags    []string `json:"tags,omitempty" easyjson:"maxlen=3"`
+	Detail  *string  `json:"detail,omitempty" easyjson:"maxlen=2"`
+	Plain   string   `json:"plain,omitempty"`
+}
+
+type TagCorners struct {
+	Name  string `json:",omitempty"`
+	Skip  string `json:"-"`
+	Dash  string `json:"-,"`
+	Count int    `json:",string"`
+}
+
+type SparseSeries struct {
+	Levels  []int8   `json:"levels" easyjson:"format=rle"`
+	Samples []uint32 `json:"samples,omitempty" easyjson:"format=rle"`
+}
+
+type WideRecord struct {
+	ID        int64   `json:"id"`
+	AccountID int64   `json:"account_id"`
+	Region    int32   `json:"region"`
+	Zone      int32   `json:"zone"`
+	Shard     uint16  `json:"shard"`
+	Replica   uint16  `json:"replica"`
+	Created   int64   `json:"created"`
+	Updated   int64   `json:"updated"`
+	Deleted   bool    `json:"deleted"`
+	Archived  bool    `json:"archived"`
+	Score     float64 `json:"score"`
+	Weight    float64 `json:"weight"`
+	Retries   uint32  `json:"retries"`
+	Priority  int8    `json:"priority"`
+	Version   uint64  `json:"version"`
+	Checksum  uint64  `json:"checksum"`
+}
+
+type Normalized struct {
+	Email string   `json:"email" easyjson:"transform=lower"`
+	Code  string   `json:"code" easyjson:"transform=trim;upper"`
+	Name  string   `json:"name" easyjson:"trim,transform=lower"`
+	Tags  []string `json:"tags" easyjson:"transform=lower"`
+	Slug  string   `json:"slug" easyjson:"transform=slug"`
+	Other string   `json:"other" easyjson:"transform=missing"`
+}
+
+type Report struct {
+	Total int64   `json:"total" easyjson:"format=grouped"`
+	Count uint32  `json:"count" easyjson:"format=grouped"`
+	Small int8    `json:"small" easyjson:"format=grouped"`
+	Sizes []int   `json:"sizes,omitempty" easyjson:"format=grouped"`
+	Max   *uint64 `json:"max,omitempty" easyjson:"format=grouped"`
+}
+
+type PaddedRecord struct {
+	ID      uint32  `json:"id" easyjson:"format=pad:8"`
+	Delta   int64   `json:"delta" easyjson:"format=pad:6"`
+	Codes   []int16 `json:"codes,omitempty" easyjson:"format=pad:3"`
+	Account *uint64 `json:"account,omitempty" easyjson:"format=pad:10"`
+}
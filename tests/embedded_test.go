@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEmbeddedPtrsMarshal(t *testing.T) {
+	for i, test := range []struct {
+		v    EmbeddedPtrs
+		want string
+	}{
+		{
+			v:    EmbeddedPtrs{Name: "a"},
+			want: `{"Name":"a"}`,
+		},
+		{
+			v:    EmbeddedPtrs{Name: "a", Meta: &Meta{ID: 1}},
+			want: `{"Name":"a","ID":1}`,
+		},
+		{
+			v:    EmbeddedPtrs{Name: "a", Meta: &Meta{ID: 1, Tags: []string{"x"}, Audit: &Audit{By: "me"}}},
+			want: `{"Name":"a","ID":1,"tags":["x"],"by":"me"}`,
+		},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d] MarshalJSON() error: %v", i, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] MarshalJSON() = %s; want %s", i, got, test.want)
+		}
+
+		// The same fields as with encoding/json are output, only the order differs.
+		var got, want map[string]interface{}
+		std, _ := json.Marshal(test.v)
+		json.Unmarshal(data, &got)
+		json.Unmarshal(std, &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("[%d] MarshalJSON() = %s; encoding/json gives %s", i, data, std)
+		}
+	}
+}
+
+func TestEmbeddedPtrsUnmarshal(t *testing.T) {
+	for i, data := range []string{
+		`{"Name":"a"}`,
+		`{"Name":"a","ID":1}`,
+		`{"by":"me"}`,
+		`{"ID":null}`,
+		`{"Name":"a","ID":1,"tags":["x"],"by":"me"}`,
+	} {
+		var got, want EmbeddedPtrs
+		if err := got.UnmarshalJSON([]byte(data)); err != nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v", i, data, err)
+		}
+		if err := json.Unmarshal([]byte(data), &want); err != nil {
+			t.Fatalf("[%d, %s] json.Unmarshal() error: %v", i, data, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %+v; want %+v", i, data, got, want)
+		}
+	}
+}
+
+func TestEmbeddedPtrsUnmarshalMerge(t *testing.T) {
+	audit := &Audit{By: "me"}
+	v := EmbeddedPtrs{Meta: &Meta{ID: 1, Audit: audit}}
+	if err := v.UnmarshalJSON([]byte(`{"by":"you"}`)); err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	if v.Audit != audit || v.By != "you" || v.ID != 1 {
+		t.Errorf("UnmarshalJSON() = %+v, %+v; want the existing pointers updated", v.Meta, v.Audit)
+	}
+}
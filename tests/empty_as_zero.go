@@ -0,0 +1,11 @@
+package tests
+
+//easyjson:json
+type EmptyAsZero struct {
+	Count int     `json:"count"`
+	Ratio float64 `json:"ratio"`
+	Flag  bool    `json:"flag"`
+	Named NamedInt
+	Ptr   *uint
+	Str   string
+}
@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmptyStringAsZero(t *testing.T) {
+	one := uint(1)
+	v := EmptyAsZero{Count: 5, Ratio: 1.5, Flag: true, Named: 3, Ptr: &one, Str: "s"}
+
+	err := v.UnmarshalJSON([]byte(`{"count":"","ratio":"","flag":"","Named":"","Ptr":"","Str":""}`))
+	if err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	zero := uint(0)
+	want := EmptyAsZero{Ptr: &zero}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", v, want)
+	}
+
+	for _, data := range []string{`{"count":" "}`, `{"count":"1"}`, `{"flag":"false"}`} {
+		if err := v.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("UnmarshalJSON(%s) ok; want error", data)
+		}
+	}
+}
+
+func TestEmptyStringStrict(t *testing.T) {
+	var v PrimitiveTypes
+	for _, data := range []string{`{"Int":""}`, `{"Float64":""}`, `{"Bool":""}`} {
+		if err := v.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("UnmarshalJSON(%s) ok; want error", data)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson/jlexer"
+	"github.com/mailru/easyjson/tests/ext"
+)
+
+func TestEnumMarshal(t *testing.T) {
+	red := ColorRed
+	v := Palette{Primary: ColorGreen, Extra: []Color{ColorRed}, Ptr: &red, Level: ext.LevelHigh}
+	want := `{"primary":"green","accent":"unknown","extra":["red"],"ptr":"red","level":"high"}`
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		t.Errorf("MarshalJSON() error: %v", err)
+	}
+	if got := string(data); got != want {
+		t.Errorf("MarshalJSON() = %s; want %s", got, want)
+	}
+}
+
+func TestEnumUnmarshal(t *testing.T) {
+	red, unknown := ColorRed, ColorUnknown
+	for i, test := range []struct {
+		data    string
+		want    Palette
+		wantErr bool
+	}{
+		{
+			data: `{"primary":"red","accent":"green","extra":["green","red"],"ptr":"red","level":"low"}`,
+			want: Palette{Primary: ColorRed, Accent: ColorGreen, Extra: []Color{ColorGreen, ColorRed}, Ptr: &red, Level: ext.LevelLow},
+		},
+		{
+			data: `{"primary":"red","accent":"blue","extra":["blue","green"],"ptr":"blue","level":"max"}`,
+			want: Palette{Primary: ColorRed, Accent: ColorUnknown, Extra: []Color{ColorUnknown, ColorGreen}, Ptr: &unknown, Level: ext.LevelUnknown},
+		},
+		{
+			data: `{"primary":null,"accent":null}`,
+			want: Palette{},
+		},
+		{
+			data:    `{"primary":"blue"}`,
+			wantErr: true,
+		},
+		{
+			data:    `{"primary":1}`,
+			wantErr: true,
+		},
+	} {
+		var got Palette
+		err := got.UnmarshalJSON([]byte(test.data))
+		if (err != nil) != test.wantErr {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v; want error %v", i, test.data, err, test.wantErr)
+		}
+		if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %+v; want %+v", i, test.data, got, test.want)
+		}
+	}
+}
+
+func TestEnumUnknownLabelError(t *testing.T) {
+	var v Palette
+	err := v.UnmarshalJSON([]byte(`{"primary":"blue"}`))
+
+	lexerErr, ok := err.(*jlexer.LexerError)
+	if !ok {
+		t.Fatalf("UnmarshalJSON() error = %v; want *jlexer.LexerError", err)
+	}
+	if lexerErr.Reason != "unknown enum label" || lexerErr.Data != "blue" {
+		t.Errorf("UnmarshalJSON() error = %+v; want unknown enum label blue", lexerErr)
+	}
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mailru/easyjson/jlexer"
+)
+
+func TestErrorField(t *testing.T) {
+	for i, test := range []struct {
+		v    ErrorResponse
+		data string
+	}{
+		{
+			v:    ErrorResponse{Code: 404, Err: errors.New(`not "found"`), Cause: errors.New("missing")},
+			data: `{"Code":404,"error":"not \"found\"","cause":"missing"}`,
+		},
+		{
+			v:    ErrorResponse{Code: 200},
+			data: `{"Code":200,"error":null}`,
+		},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d] MarshalJSON() error: %v", i, err)
+		}
+		if got := string(data); got != test.data {
+			t.Errorf("[%d] MarshalJSON() = %s; want %s", i, got, test.data)
+		}
+
+		var got ErrorResponse
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Errorf("[%d] UnmarshalJSON() error: %v", i, err)
+		}
+		if got.Code != test.v.Code || errorString(got.Err) != errorString(test.v.Err) || errorString(got.Cause) != errorString(test.v.Cause) {
+			t.Errorf("[%d] UnmarshalJSON() = %+v; want %+v", i, got, test.v)
+		}
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return "error: " + err.Error()
+}
+
+func TestTypedDecodeErrors(t *testing.T) {
+	var v IOStruct
+
+	err := v.UnmarshalJSON([]byte(`{"Name":"a","Count":"5"}`))
+	var typeErr *jlexer.TypeMismatchError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("UnmarshalJSON() error = %v; want *jlexer.TypeMismatchError", err)
+	}
+	if want := (jlexer.TypeMismatchError{Field: "Count", Offset: 23, Expected: "number", Got: "string"}); *typeErr != want {
+		t.Errorf("UnmarshalJSON() error = %+v; want %+v", *typeErr, want)
+	}
+
+	err = v.UnmarshalJSON([]byte(`{"Name":"a",}`))
+	var syntaxErr *jlexer.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("UnmarshalJSON() error = %v; want *jlexer.SyntaxError", err)
+	}
+	if want := (jlexer.SyntaxError{Reason: "syntax error", Offset: 12}); *syntaxErr != want {
+		t.Errorf("UnmarshalJSON() error = %+v; want %+v", *syntaxErr, want)
+	}
+}
@@ -0,0 +1,181 @@
+// Package ext contains types from a separate package used by the easyjson tests.
+package ext
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mailru/easyjson/jlexer"
+	"github.com/mailru/easyjson/jwriter"
+)
+
+// Value implements both easyjson and encoding/json interfaces with distinct output, so that it
+// is possible to check which ones are used by a generated code.
+type Value struct {
+	V int
+}
+
+// MarshalEasyJSON implements easyjson.Marshaler interface.
+func (v Value) MarshalEasyJSON(w *jwriter.Writer) {
+	w.RawString(`{"easyjson":`)
+	w.Int(v.V)
+	w.RawByte('}')
+}
+
+// UnmarshalEasyJSON implements easyjson.Unmarshaler interface.
+func (v *Value) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	if l.IsNull() {
+		l.Skip()
+		return
+	}
+	l.Delim('{')
+	for !l.IsDelim('}') {
+		key := l.UnsafeString()
+		l.WantColon()
+		if key == "easyjson" {
+			v.V = l.Int()
+		} else {
+			l.SkipRecursive()
+		}
+		l.WantComma()
+	}
+	l.Delim('}')
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (v Value) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"std":%d}`, v.V)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	return errors.New("encoding/json unmarshaler used")
+}
+
+// PtrValue implements easyjson.Marshaler on a pointer receiver.
+type PtrValue struct {
+	V int
+}
+
+// MarshalEasyJSON implements easyjson.Marshaler interface.
+func (v *PtrValue) MarshalEasyJSON(w *jwriter.Writer) {
+	w.RawString(`{"easyjson":`)
+	w.Int(v.V)
+	w.RawByte('}')
+}
+
+// UnmarshalEasyJSON implements easyjson.Unmarshaler interface.
+func (v *PtrValue) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	(*Value)(v).UnmarshalEasyJSON(l)
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (v *PtrValue) MarshalJSON() ([]byte, error) {
+	return (*Value)(v).MarshalJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (v *PtrValue) UnmarshalJSON(data []byte) error {
+	return (*Value)(v).UnmarshalJSON(data)
+}
+
+// U128 is an unsigned 128-bit integer given by its high and low words.
+type U128 struct {
+	Hi, Lo uint64
+}
+
+// I128 is a signed 128-bit integer given by its high and low words in two's complement.
+type I128 struct {
+	Hi int64
+	Lo uint64
+}
+
+// Decimal is a fixed-point number with two fractional digits, similar to external decimal types
+// that implement encoding.TextMarshaler but no JSON interfaces.
+type Decimal struct {
+	Cents int64
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (d Decimal) MarshalText() ([]byte, error) {
+	sign := ""
+	cents := d.Cents
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return []byte(fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+func (d *Decimal) UnmarshalText(data []byte) error {
+	s := strings.TrimPrefix(string(data), "-")
+	dot := strings.IndexByte(s, '.')
+	if dot < 0 || len(s)-dot != 3 {
+		return fmt.Errorf("invalid decimal %q: two fractional digits expected", data)
+	}
+	units, err1 := strconv.ParseUint(s[:dot], 10, 63)
+	cents, err2 := strconv.ParseUint(s[dot+1:], 10, 8)
+	if err1 != nil || err2 != nil {
+		return fmt.Errorf("invalid decimal %q", data)
+	}
+
+	d.Cents = int64(units*100 + cents)
+	if len(s) < len(data) {
+		d.Cents = -d.Cents
+	}
+	return nil
+}
+
+// UUID is an identifier in the canonical hex form, similar to external UUID types.
+type UUID [16]byte
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+func (u *UUID) UnmarshalText(data []byte) error {
+	if len(data) != 36 || data[8] != '-' || data[13] != '-' || data[18] != '-' || data[23] != '-' {
+		return fmt.Errorf("invalid uuid %q", data)
+	}
+	digits := strings.Replace(string(data), "-", "", -1)
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return fmt.Errorf("invalid uuid %q: %v", data, err)
+	}
+	return nil
+}
+
+// Level is an enum type implementing easyjson.Enum.
+type Level int
+
+const (
+	LevelUnknown Level = iota
+	LevelLow
+	LevelHigh
+)
+
+var levelLabels = map[Level]string{
+	LevelUnknown: "unknown",
+	LevelLow:     "low",
+	LevelHigh:    "high",
+}
+
+// EnumLabel implements easyjson.Enum interface.
+func (l Level) EnumLabel() string {
+	return levelLabels[l]
+}
+
+// SetEnumLabel implements easyjson.Enum interface.
+func (l *Level) SetEnumLabel(label string) bool {
+	for v, s := range levelLabels {
+		if s == label {
+			*l = v
+			return true
+		}
+	}
+	return false
+}
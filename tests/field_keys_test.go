@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldKeyerMarshal(t *testing.T) {
+	defer func(prefix string) { dynamicKeyPrefix = prefix }(dynamicKeyPrefix)
+
+	v := DynamicKeys{Name: "a", Nested: &DynamicKeys{Name: "b", Count: 2}}
+	for i, test := range []struct {
+		prefix string
+		want   string
+	}{
+		{prefix: "v1_", want: `{"v1_Name":"a","v1_Nested":{"v1_Name":"b","v1_Count":2}}`},
+		{prefix: "v2.", want: `{"v2.Name":"a","v2.Nested":{"v2.Name":"b","v2.Count":2}}`},
+		{prefix: `"`, want: `{"\"Name":"a","\"Nested":{"\"Name":"b","\"Count":2}}`},
+	} {
+		dynamicKeyPrefix = test.prefix
+
+		data, err := v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d, %q] MarshalJSON() error: %v", i, test.prefix, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d, %q] MarshalJSON() = %s; want %s", i, test.prefix, got, test.want)
+		}
+	}
+}
+
+func TestFieldKeyerUnmarshal(t *testing.T) {
+	defer func(prefix string) { dynamicKeyPrefix = prefix }(dynamicKeyPrefix)
+
+	dynamicKeyPrefix = "v2_"
+	var got DynamicKeys
+	data := `{"v2_Name":"a","v1_Count":1,"Name":"x","count":3,"v2_Nested":{"v2_Count":2}}`
+	if err := got.UnmarshalJSON([]byte(data)); err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	if want := (DynamicKeys{Name: "a", Nested: &DynamicKeys{Count: 2}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", got, want)
+	}
+}
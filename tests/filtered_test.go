@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/mailru/easyjson/jwriter"
+)
+
+var filteredValue = Filtered{
+	ID:      1,
+	Name:    "name",
+	Email:   "a@b.c",
+	Sub:     SubStruct{Value: "v", Value2: "v2"},
+	Skipped: "skipped",
+	Extra:   map[string]int{"x": 5},
+}
+
+func TestMarshalFiltered(t *testing.T) {
+	for i, test := range []struct {
+		include map[string]bool
+		want    string
+	}{
+		{map[string]bool{"id": true, "Sub": true}, `{"id":1,"Sub":{"Value":"v","Value2":"v2"}}`},
+		{map[string]bool{"name": true, "email": true}, `{"name":"name","email":"a@b.c"}`},
+		{map[string]bool{"Skipped": true, "Value": true, "missing": true}, `{}`},
+		{map[string]bool{"x": true}, `{"x":5}`},
+		{map[string]bool{}, `{}`},
+		{nil, `{"id":1,"name":"name","email":"a@b.c","Sub":{"Value":"v","Value2":"v2"},"x":5}`},
+	} {
+		w := jwriter.Writer{}
+		filteredValue.MarshalEasyJSONFiltered(&w, test.include)
+
+		got := string(w.Buffer.BuildBytes())
+		if got != test.want {
+			t.Errorf("[%d] MarshalEasyJSONFiltered(%v) = %v; want %v", i, test.include, got, test.want)
+		}
+	}
+}
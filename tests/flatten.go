@@ -0,0 +1,42 @@
+package tests
+
+type FlatAddress struct {
+	City   string `json:"city"`
+	Street string `json:"street,omitempty"`
+}
+
+type FlatUser struct {
+	Name    string       `json:"name"`
+	Address FlatAddress  `json:"address"`
+	Billing *FlatAddress `json:"billing"`
+	Work    *FlatAddress `json:"work,omitempty"`
+}
+
+type Flatten struct {
+	ID    int               `json:"id"`
+	User  FlatUser          `json:"user"`
+	Raw   SubStruct         `json:"-"`
+	Tags  []FlatAddress     `json:"tags"`
+	Extra map[string]string `json:",inline"`
+}
+
+var flattenValue = Flatten{
+	ID: 1,
+	User: FlatUser{
+		Name:    "name",
+		Address: FlatAddress{City: "Moscow", Street: "Tverskaya"},
+		Billing: &FlatAddress{City: "Berlin"},
+	},
+	Tags:  []FlatAddress{{City: "x"}},
+	Extra: map[string]string{"k": "v"},
+}
+
+var flattenString = `{` +
+	`"id":1,` +
+	`"user.name":"name",` +
+	`"user.address.city":"Moscow",` +
+	`"user.address.street":"Tverskaya",` +
+	`"user.billing.city":"Berlin",` +
+	`"tags":[{"city":"x"}],` +
+	`"k":"v"` +
+	`}`
@@ -0,0 +1,28 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestFlattenDotted(t *testing.T) {
+	data, err := easyjson.Marshal(flattenValue)
+	if err != nil {
+		t.Errorf("easyjson.Marshal() error: %v", err)
+	}
+	if got := string(data); got != flattenString {
+		t.Errorf("easyjson.Marshal() = %v; want %v", got, flattenString)
+	}
+}
+
+func TestFlattenDottedNil(t *testing.T) {
+	data, err := easyjson.Marshal(FlatUser{Name: "a"})
+	if err != nil {
+		t.Errorf("easyjson.Marshal() error: %v", err)
+	}
+	want := `{"name":"a","address.city":"","billing":null}`
+	if got := string(data); got != want {
+		t.Errorf("easyjson.Marshal() = %v; want %v", got, want)
+	}
+}
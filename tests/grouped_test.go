@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGrouped(t *testing.T) {
+	max := uint64(math.MaxUint64)
+	for i, test := range []struct {
+		value Report
+		data  string
+	}{
+		{
+			value: Report{Total: 1234567, Count: 1000, Small: 5},
+			data:  `{"total":"1,234,567","count":"1,000","small":"5"}`,
+		},
+		{
+			value: Report{Total: -9876543210, Count: 999, Small: -128},
+			data:  `{"total":"-9,876,543,210","count":"999","small":"-128"}`,
+		},
+		{
+			value: Report{Sizes: []int{12, 12345, -1000000}, Max: &max},
+			data:  `{"total":"0","count":"0","small":"0","sizes":["12","12,345","-1,000,000"],"max":"18,446,744,073,709,551,615"}`,
+		},
+	} {
+		data, err := test.value.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d] MarshalJSON() error: %v", i, err)
+		}
+		if string(data) != test.data {
+			t.Errorf("[%d] MarshalJSON() = %s; want %s", i, data, test.data)
+		}
+
+		var got Report
+		if err := got.UnmarshalJSON([]byte(test.data)); err != nil {
+			t.Errorf("[%d] UnmarshalJSON() error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.value) {
+			t.Errorf("[%d] UnmarshalJSON() = %+v; want %+v", i, got, test.value)
+		}
+	}
+}
+
+func TestGroupedErrors(t *testing.T) {
+	for i, data := range []string{
+		`{"total":"1,23,456"}`,
+		`{"total":1234}`,
+		`{"small":"1,000"}`,
+		`{"count":"-1"}`,
+	} {
+		var got Report
+		if err := got.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() ok; want error", i, data)
+		}
+	}
+}
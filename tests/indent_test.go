@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+// plainIndentedDoc and plainIndentedItem mirror IndentedDoc and IndentedItem without the
+// generated methods, so that encoding/json marshals them itself.
+type plainIndentedItem struct {
+	ID    int
+	Attrs map[string]string
+	Extra struct{} `json:"extra"`
+}
+
+type plainIndentedDoc struct {
+	Title  string              `json:"title"`
+	Tags   []string            `json:"tags"`
+	Items  []plainIndentedItem `json:"items"`
+	Matrix [][]float64         `json:"matrix,omitempty"`
+	Parent *plainIndentedDoc   `json:"parent"`
+}
+
+func TestMarshalIndent(t *testing.T) {
+	for i, plain := range []plainIndentedDoc{
+		{Tags: []string{}, Items: []plainIndentedItem{}},
+		{
+			Title: "nested <doc>",
+			Tags:  []string{"a", "b"},
+			Items: []plainIndentedItem{
+				{ID: 1},
+				{ID: 2, Attrs: map[string]string{"k": "v"}},
+			},
+			Matrix: [][]float64{{1.5, 2}, {}},
+			Parent: &plainIndentedDoc{Title: "parent", Tags: []string{""}, Items: []plainIndentedItem{{}}},
+		},
+	} {
+		data, err := json.Marshal(plain)
+		if err != nil {
+			t.Fatalf("[%d] json.Marshal() error: %v", i, err)
+		}
+		var v IndentedDoc
+		if err := v.UnmarshalJSON(data); err != nil {
+			t.Fatalf("[%d] UnmarshalJSON() error: %v", i, err)
+		}
+
+		for _, indent := range [][2]string{{"", "  "}, {"> ", "\t"}, {"", ""}} {
+			want, err := json.MarshalIndent(plain, indent[0], indent[1])
+			if err != nil {
+				t.Fatalf("[%d, %q] json.MarshalIndent() error: %v", i, indent, err)
+			}
+
+			got, err := easyjson.MarshalIndent(v, indent[0], indent[1])
+			if err != nil {
+				t.Errorf("[%d, %q] MarshalIndent() error: %v", i, indent, err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("[%d, %q] MarshalIndent() = %s; want %s", i, indent, got, want)
+			}
+		}
+	}
+}
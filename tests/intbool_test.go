@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIntBoolRoundTrip(t *testing.T) {
+	for i, test := range []struct {
+		v    IntBools
+		want string
+	}{
+		{
+			v:    IntBools{Active: true, Flags: []bool{true, false}, Named: true, Plain: true},
+			want: `{"Active":1,"flags":[1,0],"named":1,"Plain":true}`,
+		},
+		{
+			v:    IntBools{Flags: []bool{false}},
+			want: `{"Active":0,"flags":[0],"Plain":false}`,
+		},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d] MarshalJSON() error: %v", i, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] MarshalJSON() = %s; want %s", i, got, test.want)
+		}
+
+		var got IntBools
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Errorf("[%d] UnmarshalJSON() error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("[%d] UnmarshalJSON() = %+v; want %+v", i, got, test.v)
+		}
+	}
+}
+
+func TestIntBoolUnmarshal(t *testing.T) {
+	for i, test := range []struct {
+		data string
+		want IntBools
+	}{
+		{
+			data: `{"Active":1,"flags":[0,1],"named":1}`,
+			want: IntBools{Active: true, Flags: []bool{false, true}, Named: true},
+		},
+		{
+			data: `{"Active":true,"flags":[false,true],"named":true}`,
+			want: IntBools{Active: true, Flags: []bool{false, true}, Named: true},
+		},
+		{
+			data: `{"Active":false,"flags":[1,true,0,false],"named":0}`,
+			want: IntBools{Flags: []bool{true, true, false, false}},
+		},
+	} {
+		var got IntBools
+		if err := got.UnmarshalJSON([]byte(test.data)); err != nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v", i, test.data, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %+v; want %+v", i, test.data, got, test.want)
+		}
+	}
+}
+
+func TestIntBoolUnmarshalErrors(t *testing.T) {
+	for i, data := range []string{
+		`{"Active":2}`,
+		`{"Active":"1"}`,
+		`{"flags":[1,5]}`,
+		`{"Plain":1}`,
+	} {
+		var got IntBools
+		if err := got.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() ok; want error", i, data)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+// jsonShape is a Shape implementing only the standard unmarshaler.
+type jsonShape struct {
+	data string
+}
+
+func (s *jsonShape) Area() float64 { return 0 }
+
+func (s *jsonShape) UnmarshalJSON(data []byte) error {
+	s.data = string(data)
+	return nil
+}
+
+func TestInterfaceDecode(t *testing.T) {
+	v := Drawing{Shape: &Circle{Radius: 1}, Main: &jsonShape{}}
+	data := `{"Shape":{"Radius":2},"Name":"a","main":[1, 2]}`
+	if err := v.UnmarshalJSON([]byte(data)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+
+	want := Drawing{Shape: &Circle{Radius: 2}, Name: "a", Main: &jsonShape{data: "[1, 2]"}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", v, want)
+	}
+
+	out, err := v.MarshalJSON()
+	if err != nil {
+		t.Errorf("MarshalJSON() error: %v", err)
+	}
+	wantOut := `{"Shape":{"Radius":2},"Name":"a","main":{}}`
+	if got := string(out); got != wantOut {
+		t.Errorf("MarshalJSON() = %s; want %s", got, wantOut)
+	}
+}
+
+func TestInterfaceDecodeNull(t *testing.T) {
+	v := Drawing{Shape: &Circle{Radius: 1}}
+	if err := v.UnmarshalJSON([]byte(`{"Shape":null}`)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	if v.Shape != nil {
+		t.Errorf("UnmarshalJSON() Shape = %+v; want nil", v.Shape)
+	}
+}
+
+func TestInterfaceDecodeUnset(t *testing.T) {
+	var v Drawing
+	if err := v.UnmarshalJSON([]byte(`{"Shape":{"Radius":2},"Name":"a"}`)); err == nil {
+		t.Errorf("UnmarshalJSON() into nil interface ok; want error")
+	}
+}
+
+func TestGenericRoundTrip(t *testing.T) {
+	var ptrValue any = "p"
+	for i, test := range []struct {
+		data string
+		want Generic
+	}{
+		{
+			data: `{"Value":{"a":1,"b":[true,null,"s"],"c":{"d":2.5}}}`,
+			want: Generic{Value: map[string]interface{}{
+				"a": float64(1),
+				"b": []interface{}{true, nil, "s"},
+				"c": map[string]interface{}{"d": 2.5},
+			}},
+		},
+		{
+			data: `{"Value":[1,"x",{"y":false}],"Values":[[],{}]}`,
+			want: Generic{
+				Value:  []interface{}{float64(1), "x", map[string]interface{}{"y": false}},
+				Values: []any{[]interface{}{}, map[string]interface{}{}},
+			},
+		},
+		{data: `{"Value":"scalar"}`, want: Generic{Value: "scalar"}},
+		{data: `{"Value":-1500.5}`, want: Generic{Value: -1500.5}},
+		{data: `{"Value":true}`, want: Generic{Value: true}},
+		{data: `{"Value":null}`, want: Generic{}},
+		{
+			data: `{"Value":1,"Extra":{"k":[1]},"Ptr":"p"}`,
+			want: Generic{Value: float64(1), Extra: map[string]any{"k": []interface{}{float64(1)}}, Ptr: &ptrValue},
+		},
+	} {
+		var got Generic
+		if err := got.UnmarshalJSON([]byte(test.data)); err != nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v", i, test.data, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %#v; want %#v", i, test.data, got, test.want)
+		}
+
+		data, err := got.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d, %s] MarshalJSON() error: %v", i, test.data, err)
+		}
+		if got := string(data); got != test.data {
+			t.Errorf("[%d, %s] MarshalJSON() = %s; want the input", i, test.data, got)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package tests
+
+//easyjson:json
+type IOStruct struct {
+	Name  string
+	Count int
+}
+
+var ioStructValue = IOStruct{Name: "test", Count: 5}
+var ioStructString = `{"Name":"test","Count":5}`
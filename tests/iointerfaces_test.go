@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+var (
+	_ io.WriterTo   = IOStruct{}
+	_ io.ReaderFrom = &IOStruct{}
+)
+
+func TestWriteTo(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	n, err := ioStructValue.WriteTo(out)
+	if err != nil {
+		t.Errorf("WriteTo() error: %v", err)
+	}
+	if got := out.String(); got != ioStructString {
+		t.Errorf("WriteTo() wrote %v; want %v", got, ioStructString)
+	}
+	if n != int64(len(ioStructString)) {
+		t.Errorf("WriteTo() = %v; want %v", n, len(ioStructString))
+	}
+}
+
+func TestReadFrom(t *testing.T) {
+	var v IOStruct
+
+	n, err := v.ReadFrom(strings.NewReader(ioStructString))
+	if err != nil {
+		t.Errorf("ReadFrom() error: %v", err)
+	}
+	if v != ioStructValue {
+		t.Errorf("ReadFrom() decoded %+v; want %+v", v, ioStructValue)
+	}
+	if n != int64(len(ioStructString)) {
+		t.Errorf("ReadFrom() = %v; want %v", n, len(ioStructString))
+	}
+
+	var v1 IOStruct
+	if _, err := v1.ReadFrom(strings.NewReader(`{"Name":`)); err == nil {
+		t.Errorf("ReadFrom() of truncated input ok; want error")
+	}
+}
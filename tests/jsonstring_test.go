@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONStringRoundTrip(t *testing.T) {
+	for i, test := range []struct {
+		v    Envelope
+		want string
+	}{
+		{
+			v:    Envelope{Kind: "empty"},
+			want: `{"kind":"empty","data":"{\"x\":0}","meta":null}`,
+		},
+		{
+			v:    Envelope{Kind: "full", Data: EnvelopeData{X: 1, Note: `say "hi"`}, Meta: &EnvelopeData{X: 2}, List: []int{1, 2}},
+			want: `{"kind":"full","data":"{\"x\":1,\"note\":\"say \\\"hi\\\"\"}","meta":"{\"x\":2}","list":"[1,2]"}`,
+		},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d, %v] MarshalJSON() error: %v", i, test.v.Kind, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d, %v] MarshalJSON() = %s; want %s", i, test.v.Kind, got, test.want)
+		}
+
+		var got Envelope
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Errorf("[%d, %v] UnmarshalJSON() error: %v", i, test.v.Kind, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("[%d, %v] UnmarshalJSON() = %+v; want %+v", i, test.v.Kind, got, test.v)
+		}
+	}
+}
+
+func TestJSONStringUnmarshalError(t *testing.T) {
+	for i, data := range []string{
+		`{"data":{"x":1}}`,
+		`{"data":"{\"x\":\"1\"}"}`,
+		`{"data":"{\"x\":"}`,
+		`{"data":"{\"x\":1} trailing garbage"}`,
+		`{"data":"{\"x\":1}{\"x\":2}"}`,
+	} {
+		var v Envelope
+		if err := v.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() ok; want error", i, data)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestMaxLen(t *testing.T) {
+	detail := "детали"
+	long := strings.Repeat("x", 100)
+
+	for i, test := range []struct {
+		v    LogLine
+		want string
+	}{
+		{v: LogLine{Message: "short"}, want: `{"msg":"short"}`},
+		{v: LogLine{Message: "12345678"}, want: `{"msg":"12345678"}`},
+		{v: LogLine{Message: "123456789"}, want: `{"msg":"12345678…"}`},
+		{v: LogLine{Message: "привет, мир!"}, want: `{"msg":"привет, …"}`},
+		{
+			v:    LogLine{Tags: []string{"ab", "abcd", "日本語です"}, Detail: &detail, Plain: long},
+			want: `{"msg":"","tags":["ab","abc…","日本語…"],"detail":"де…","plain":"` + long + `"}`,
+		},
+	} {
+		data, err := easyjson.Marshal(test.v)
+		if err != nil {
+			t.Errorf("[%d] Marshal() error: %v", i, err)
+			continue
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] Marshal() = %v; want %v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package tests
+
+type PatchAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type PatchMeta struct {
+	Owner string `json:"owner"`
+}
+
+type PatchUser struct {
+	*PatchMeta
+
+	Name     string                   `json:"name"`
+	Age      int                      `json:"age"`
+	Email    *string                  `json:"email"`
+	Tags     []string                 `json:"tags"`
+	Address  PatchAddress             `json:"address"`
+	Billing  *PatchAddress            `json:"billing"`
+	Labels   map[string]string        `json:"labels"`
+	Contacts map[string]*PatchAddress `json:"contacts"`
+	Counts   map[string]int           `json:"counts" easyjson:"value_omitempty"`
+	Extra    interface{}              `json:"extra"`
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// applyMergePatch applies a JSON merge patch to a decoded document as described in RFC 7386.
+func applyMergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = applyMergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// dropNulls removes the null members of the objects of a decoded document, which merge patches
+// can't tell from missing ones.
+func dropNulls(doc interface{}) interface{} {
+	if m, ok := doc.(map[string]interface{}); ok {
+		for k, v := range m {
+			if v == nil {
+				delete(m, k)
+			} else {
+				m[k] = dropNulls(v)
+			}
+		}
+	}
+	return doc
+}
+
+func newPatchUser() PatchUser {
+	email := "a@example.com"
+	return PatchUser{
+		PatchMeta: &PatchMeta{Owner: "root"},
+		Name:      "alice",
+		Age:       30,
+		Email:     &email,
+		Tags:      []string{"a", "b"},
+		Address:   PatchAddress{City: "Paris", Zip: "75001"},
+		Billing:   &PatchAddress{City: "Lyon"},
+		Labels:    map[string]string{"team": "core", "role": "dev"},
+		Contacts:  map[string]*PatchAddress{"home": {City: "Nice", Zip: "06000"}},
+		Counts:    map[string]int{"a": 1, "b": 2},
+		Extra:     "x",
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	for i, test := range []struct {
+		update func(v *PatchUser)
+		want   string
+	}{
+		{update: func(v *PatchUser) {}, want: `{}`},
+		{update: func(v *PatchUser) { v.Age = 31 }, want: `{"age":31}`},
+		{update: func(v *PatchUser) { v.Email = nil }, want: `{"email":null}`},
+		{update: func(v *PatchUser) {
+			email := "a@example.com"
+			v.Email = &email
+		}, want: `{}`},
+		{update: func(v *PatchUser) { v.Name, v.Labels = "bob", nil }, want: `{"name":"bob","labels":null}`},
+		{update: func(v *PatchUser) { v.Tags = []string{"a"} }, want: `{"tags":["a"]}`},
+		{update: func(v *PatchUser) { v.Address.Zip = "" }, want: `{"address":{"zip":null}}`},
+		{update: func(v *PatchUser) { v.Billing.City = "Lille" }, want: `{"billing":{"city":"Lille"}}`},
+		{update: func(v *PatchUser) { v.Billing = nil }, want: `{"billing":null}`},
+		{update: func(v *PatchUser) { v.Labels = map[string]string{"team": "web"} }, want: `{"labels":{"role":null,"team":"web"}}`},
+		{update: func(v *PatchUser) { v.Contacts["home"].City = "Pau" }, want: `{"contacts":{"home":{"city":"Pau"}}}`},
+		{update: func(v *PatchUser) { v.Contacts["work"] = nil }, want: `{"contacts":{"work":null}}`},
+		{update: func(v *PatchUser) { v.Counts = map[string]int{"a": 0, "b": 3} }, want: `{"counts":{"a":null,"b":3}}`},
+		{update: func(v *PatchUser) { v.PatchMeta = nil }, want: `{"owner":null}`},
+		{update: func(v *PatchUser) { v.Extra = []int{1} }, want: `{"extra":[1]}`},
+	} {
+		from, to := newPatchUser(), newPatchUser()
+		test.update(&to)
+
+		data, err := MergePatchPatchUser(from, to)
+		if err != nil {
+			t.Errorf("[%d] MergePatchPatchUser() error: %v", i, err)
+			continue
+		}
+
+		var got, want interface{}
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Errorf("[%d] MergePatchPatchUser() = %s: %v", i, data, err)
+			continue
+		}
+		if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+			t.Fatalf("[%d] json.Unmarshal(%s) error: %v", i, test.want, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("[%d] MergePatchPatchUser() = %s; want %s", i, data, test.want)
+		}
+
+		// The patch applied to the JSON of the old value gives the JSON of the new one.
+		var doc, wantDoc interface{}
+		fromData, _ := from.MarshalJSON()
+		toData, _ := to.MarshalJSON()
+		json.Unmarshal(fromData, &doc)
+		json.Unmarshal(toData, &wantDoc)
+		if doc, wantDoc = applyMergePatch(doc, got), dropNulls(wantDoc); !reflect.DeepEqual(doc, wantDoc) {
+			t.Errorf("[%d] patched document = %v; want %v", i, doc, wantDoc)
+		}
+	}
+}
+
+func TestMergePatchNilToSet(t *testing.T) {
+	to := newPatchUser()
+	data, err := MergePatchPatchUser(PatchUser{}, to)
+	if err != nil {
+		t.Fatalf("MergePatchPatchUser() error: %v", err)
+	}
+
+	var got PatchUser
+	if err := got.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON(%s) error: %v", data, err)
+	}
+	if !reflect.DeepEqual(got, to) {
+		t.Errorf("UnmarshalJSON(%s) = %+v; want %+v", data, got, to)
+	}
+}
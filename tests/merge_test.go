@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newMerge() Merge {
+	return Merge{
+		SubP:  &SubP{V: "embedded"},
+		Name:  "name",
+		Count: 1,
+		Sub:   SubStruct{Value: "sub", Value2: "sub2"},
+		Ptr:   &SubStruct{Value: "ptr", Value2: "ptr2"},
+		Map:   map[string]int{"a": 1, "b": 2},
+		Slice: []int{1, 2, 3},
+	}
+}
+
+func TestMerge(t *testing.T) {
+	for i, test := range []struct {
+		data   string
+		update func(v *Merge)
+	}{
+		{`{}`, func(v *Merge) {}},
+		{`{"Count":2}`, func(v *Merge) { v.Count = 2 }},
+		{`{"V":"new","Sub":{"Value":"new"},"Ptr":{"Value2":"new"}}`, func(v *Merge) {
+			v.V = "new"
+			v.Sub.Value = "new"
+			v.Ptr.Value2 = "new"
+		}},
+		{`{"Map":{"b":3,"c":4},"Slice":[5]}`, func(v *Merge) {
+			v.Map["b"] = 3
+			v.Map["c"] = 4
+			v.Slice = []int{5}
+		}},
+		{`{"Map":{},"Name":null,"Count":null,"Sub":null}`, func(v *Merge) {}},
+	} {
+		got := newMerge()
+		embedded, ptr := got.SubP, got.Ptr
+		if err := got.UnmarshalJSON([]byte(test.data)); err != nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v", i, test.data, err)
+		}
+
+		want := newMerge()
+		test.update(&want)
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %+v; want %+v", i, test.data, got, want)
+		}
+		if embedded != got.SubP || ptr != got.Ptr {
+			t.Errorf("[%d, %s] UnmarshalJSON() replaced a set pointer field", i, test.data)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package tests
+
+//easyjson:json methods=EJMarshal,EJUnmarshal
+type CustomMethods struct {
+	Name string
+}
+
+// MarshalJSON is a hand-written marshaler clashing with the generated one.
+func (v CustomMethods) MarshalJSON() ([]byte, error) {
+	return []byte(`"custom"`), nil
+}
+
+// UnmarshalJSON is a hand-written unmarshaler clashing with the generated one.
+func (v *CustomMethods) UnmarshalJSON(data []byte) error {
+	v.Name = "custom"
+	return nil
+}
+
+var customMethodsValue = CustomMethods{Name: "test"}
+var customMethodsString = `{"Name":"test"}`
@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestCustomMethodNames(t *testing.T) {
+	data, err := customMethodsValue.EJMarshal()
+	if err != nil || string(data) != customMethodsString {
+		t.Errorf("EJMarshal() = %s, %v; want %s", data, err, customMethodsString)
+	}
+	data, err = easyjson.Marshal(customMethodsValue)
+	if err != nil || string(data) != customMethodsString {
+		t.Errorf("easyjson.Marshal() = %s, %v; want %s", data, err, customMethodsString)
+	}
+	data, err = json.Marshal(customMethodsValue)
+	if err != nil || string(data) != `"custom"` {
+		t.Errorf("json.Marshal() = %s, %v; want hand-written output", data, err)
+	}
+
+	var v CustomMethods
+	if err := v.EJUnmarshal([]byte(customMethodsString)); err != nil || v != customMethodsValue {
+		t.Errorf("EJUnmarshal() = %+v, %v; want %+v", v, err, customMethodsValue)
+	}
+	v = CustomMethods{}
+	if err := easyjson.Unmarshal([]byte(customMethodsString), &v); err != nil || v != customMethodsValue {
+		t.Errorf("easyjson.Unmarshal() = %+v, %v; want %+v", v, err, customMethodsValue)
+	}
+	v = CustomMethods{}
+	if err := json.Unmarshal([]byte(customMethodsString), &v); err != nil || v.Name != "custom" {
+		t.Errorf("json.Unmarshal() = %+v, %v; want hand-written result", v, err)
+	}
+}
@@ -0,0 +1,11 @@
+package tests
+
+//easyjson:json
+type NilAsEmpty struct {
+	Slice     []int
+	Map       map[string]int
+	NestedMap []map[string]int
+}
+
+var nilAsEmptyValue = NilAsEmpty{NestedMap: []map[string]int{nil}}
+var nilAsEmptyString = `{"Slice":[],"Map":{},"NestedMap":[{}]}`
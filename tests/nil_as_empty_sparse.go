@@ -0,0 +1,6 @@
+package tests
+
+//easyjson:json
+type NilAsEmptySparse struct {
+	Labels map[string]string `easyjson:"value_omitempty"`
+}
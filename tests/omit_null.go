@@ -0,0 +1,19 @@
+package tests
+
+import "encoding/json"
+
+//easyjson:json
+type OmitNull struct {
+	Ptr       *int
+	Zero      int
+	Str       string
+	Iface     interface{}
+	Map       map[string]int
+	Slice     []int
+	Raw       json.RawMessage
+	Err       error
+	OmitEmpty *int `json:",omitempty"`
+}
+
+var omitNullValue = OmitNull{}
+var omitNullString = `{"Zero":0,"Str":"","Slice":[]}`
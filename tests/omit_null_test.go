@@ -0,0 +1,25 @@
+package tests
+
+import "testing"
+
+func TestOmitNull(t *testing.T) {
+	one := 1
+	for i, test := range []struct {
+		v    OmitNull
+		want string
+	}{
+		{v: omitNullValue, want: omitNullString},
+		{
+			v:    OmitNull{Ptr: &one, Zero: 1, Iface: 0, Map: map[string]int{}, Raw: []byte(`{}`)},
+			want: `{"Ptr":1,"Zero":1,"Str":"","Iface":0,"Map":{},"Slice":[],"Raw":{}}`,
+		},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d] MarshalJSON() error: %v", i, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] MarshalJSON() = %s; want %s", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOneOfRoundTrip(t *testing.T) {
+	for i, test := range []struct {
+		v    Order
+		want string
+	}{
+		{
+			v:    Order{ID: 1},
+			want: `{"ID":1,"Payment":null}`,
+		},
+		{
+			v:    Order{ID: 2, Payment: CardPayment{Last4: "1234"}},
+			want: `{"ID":2,"Payment":{"card":{"Last4":"1234"}}}`,
+		},
+		{
+			v:    Order{ID: 3, Payment: &WirePayment{IBAN: "DE00"}},
+			want: `{"ID":3,"Payment":{"wire":{"IBAN":"DE00"}}}`,
+		},
+		{
+			v:    Order{ID: 4, Refunds: []PaymentMethod{&WirePayment{IBAN: "FR00"}, nil, CardPayment{Last4: "9876"}}},
+			want: `{"ID":4,"Payment":null,"Refunds":[{"wire":{"IBAN":"FR00"}},null,{"card":{"Last4":"9876"}}]}`,
+		},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d, %v] MarshalJSON() error: %v", i, test.v.ID, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d, %v] MarshalJSON() = %s; want %s", i, test.v.ID, got, test.want)
+		}
+
+		var got Order
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Errorf("[%d, %v] UnmarshalJSON() error: %v", i, test.v.ID, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("[%d, %v] UnmarshalJSON() = %+v; want %+v", i, test.v.ID, got, test.v)
+		}
+	}
+}
+
+func TestOneOfUnmarshal(t *testing.T) {
+	for i, test := range []struct {
+		data    string
+		want    Order
+		wantErr bool
+	}{
+		{data: `{"Payment":{}}`, want: Order{}},
+		{data: `{"Payment":{"cash":{"Amount":1}}}`, want: Order{}},
+		{data: `{"Payment":{"cash":{},"card":{"Last4":"1234"}}}`, want: Order{Payment: CardPayment{Last4: "1234"}}},
+		{data: `{"Payment":{"wire":null}}`, want: Order{Payment: &WirePayment{}}},
+		{data: `{"Payment":[]}`, wantErr: true},
+		{data: `{"Payment":{"card":1}}`, wantErr: true},
+	} {
+		var got Order
+		err := got.UnmarshalJSON([]byte(test.data))
+		if err != nil && !test.wantErr {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v", i, test.data, err)
+		} else if err == nil && test.wantErr {
+			t.Errorf("[%d, %s] UnmarshalJSON() ok; want error", i, test.data)
+		}
+		if err == nil && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %+v; want %+v", i, test.data, got, test.want)
+		}
+	}
+}
+
+// cashPayment is a PaymentMethod not listed as a variant of the Order fields.
+type cashPayment struct{}
+
+func (cashPayment) isPaymentMethod() {}
+
+func TestOneOfMarshalUnknownVariant(t *testing.T) {
+	v := Order{Payment: cashPayment{}}
+	if _, err := v.MarshalJSON(); err == nil {
+		t.Errorf("MarshalJSON() ok; want error for a value that is not a variant")
+	}
+}
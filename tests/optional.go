@@ -0,0 +1,18 @@
+//go:build go1.18
+// +build go1.18
+
+package tests
+
+import (
+	"github.com/mailru/easyjson/opt"
+	"github.com/mailru/easyjson/tests/ext"
+)
+
+//easyjson:json
+type OptionalFields struct {
+	Count opt.Optional[int]       `json:"count"`
+	Name  opt.Optional[string]    `json:"name"`
+	Sub   opt.Optional[SubStruct] `json:"sub"`
+	Ext   opt.Optional[ext.Value] `json:"ext"`
+	Tags  []opt.Optional[string]  `json:"tags,omitempty"`
+}
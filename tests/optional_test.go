@@ -0,0 +1,49 @@
+//go:build go1.18
+// +build go1.18
+
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson/opt"
+	"github.com/mailru/easyjson/tests/ext"
+)
+
+func TestGenericOptional(t *testing.T) {
+	for i, test := range []struct {
+		v    OptionalFields
+		data string
+	}{
+		{v: OptionalFields{}, data: `{}`},
+		{v: OptionalFields{Count: opt.Some(0), Name: opt.Some("")}, data: `{"count":0,"name":""}`},
+		{v: OptionalFields{Count: opt.Some(5), Name: opt.Some("x")}, data: `{"count":5,"name":"x"}`},
+		{v: OptionalFields{Sub: opt.Some(SubStruct{})}, data: `{"sub":{"Value":"","Value2":""}}`},
+		{v: OptionalFields{Ext: opt.Some(ext.Value{V: 1})}, data: `{"ext":{"easyjson":1}}`},
+		{v: OptionalFields{Tags: []opt.Optional[string]{opt.Some("a"), {}}}, data: `{"tags":["a",null]}`},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil || string(data) != test.data {
+			t.Errorf("[%d] MarshalJSON() = %s, %v; want %s", i, data, err, test.data)
+		}
+
+		var got OptionalFields
+		if err := got.UnmarshalJSON([]byte(test.data)); err != nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v", i, test.data, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %+v; want %+v", i, test.data, got, test.v)
+		}
+	}
+}
+
+func TestGenericOptionalNull(t *testing.T) {
+	got := OptionalFields{Count: opt.Some(1), Name: opt.Some("x")}
+	if err := got.UnmarshalJSON([]byte(`{"count":null}`)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	if want := (OptionalFields{Name: opt.Some("x")}); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPackedUint32sRoundTrip(t *testing.T) {
+	for i, test := range []struct {
+		v    Telemetry
+		want string
+	}{
+		{v: Telemetry{Device: "a"}, want: `{"Device":"a","Samples":""}`},
+		{v: Telemetry{Device: "b", Samples: []uint32{1}}, want: `{"Device":"b","Samples":"AQAAAA=="}`},
+		{v: Telemetry{Device: "c", Samples: []uint32{1, 0xffffffff, 0x01020304}}, want: `{"Device":"c","Samples":"AQAAAP////8EAwIB"}`},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d, %v] MarshalJSON() error: %v", i, test.v.Device, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d, %v] MarshalJSON() = %s; want %s", i, test.v.Device, got, test.want)
+		}
+
+		var got Telemetry
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Errorf("[%d, %v] UnmarshalJSON() error: %v", i, test.v.Device, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("[%d, %v] UnmarshalJSON() = %+v; want %+v", i, test.v.Device, got, test.v)
+		}
+	}
+}
+
+func TestPackedUint32sUnmarshal(t *testing.T) {
+	var v Telemetry
+	if err := v.UnmarshalJSON([]byte(`{"Samples":null}`)); err != nil || v.Samples != nil {
+		t.Errorf("UnmarshalJSON() of null = %v, %v; want nil", v.Samples, err)
+	}
+
+	err := v.UnmarshalJSON([]byte(`{"Samples":"AQAAAAI="}`))
+	if err == nil || !strings.Contains(err.Error(), "not a multiple of 4") {
+		t.Errorf("UnmarshalJSON() of 5 bytes error = %v; want a length error", err)
+	}
+}
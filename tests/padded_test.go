@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPadded(t *testing.T) {
+	account := uint64(12345678901)
+	for i, test := range []struct {
+		value PaddedRecord
+		data  string
+	}{
+		{
+			value: PaddedRecord{ID: 42, Delta: 7},
+			data:  `{"id":"00000042","delta":"000007"}`,
+		},
+		{
+			value: PaddedRecord{ID: 0, Delta: -42},
+			data:  `{"id":"00000000","delta":"-00042"}`,
+		},
+		{
+			value: PaddedRecord{ID: 123456789, Delta: -1234567},
+			data:  `{"id":"123456789","delta":"-1234567"}`,
+		},
+		{
+			value: PaddedRecord{Codes: []int16{1, 99, 1000, -5}, Account: &account},
+			data:  `{"id":"00000000","delta":"000000","codes":["001","099","1000","-05"],"account":"12345678901"}`,
+		},
+	} {
+		data, err := test.value.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d] MarshalJSON() error: %v", i, err)
+		}
+		if string(data) != test.data {
+			t.Errorf("[%d] MarshalJSON() = %s; want %s", i, data, test.data)
+		}
+
+		var got PaddedRecord
+		if err := got.UnmarshalJSON([]byte(test.data)); err != nil {
+			t.Errorf("[%d] UnmarshalJSON() error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.value) {
+			t.Errorf("[%d] UnmarshalJSON() = %+v; want %+v", i, got, test.value)
+		}
+	}
+}
+
+func TestPaddedDecode(t *testing.T) {
+	var got PaddedRecord
+	if err := got.UnmarshalJSON([]byte(`{"id":"42","delta":"-000000000000000009"}`)); err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	if want := (PaddedRecord{ID: 42, Delta: -9}); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", got, want)
+	}
+
+	for i, data := range []string{
+		`{"id":42}`,
+		`{"id":"0000004x"}`,
+		`{"id":"-0000001"}`,
+	} {
+		var got PaddedRecord
+		if err := got.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() ok; want error", i, data)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package tests
+
+import "strconv"
+
+//easyjson:json
+type Passthrough struct {
+	Name  string
+	Count int
+}
+
+// MarshalJSON outputs a bespoke compact representation, which is kept by easyjson.
+func (p Passthrough) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + p.Name + `:` + strconv.Itoa(p.Count) + `"`), nil
+}
+
+//easyjson:json
+type PassthroughHolder struct {
+	Item  Passthrough
+	Items []Passthrough
+	Ptr   *Passthrough
+}
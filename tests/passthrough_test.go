@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestPassthroughMarshal(t *testing.T) {
+	v := PassthroughHolder{
+		Item:  Passthrough{Name: "a", Count: 1},
+		Items: []Passthrough{{Name: "b", Count: 2}},
+	}
+	want := `{"Item":"a:1","Items":["b:2"],"Ptr":null}`
+
+	data, err := easyjson.Marshal(v)
+	if err != nil {
+		t.Errorf("easyjson.Marshal() error: %v", err)
+	}
+	if got := string(data); got != want {
+		t.Errorf("easyjson.Marshal() = %s; want %s", got, want)
+	}
+
+	data, err = easyjson.Marshal(v.Item)
+	if err != nil {
+		t.Errorf("easyjson.Marshal() error: %v", err)
+	}
+	if got := string(data); got != `"a:1"` {
+		t.Errorf("easyjson.Marshal() = %s; want %s", got, `"a:1"`)
+	}
+}
+
+func TestPassthroughUnmarshal(t *testing.T) {
+	data := `{"Item":{"Name":"a","Count":1},"Items":[{"Name":"b","Count":2}],"Ptr":{"Name":"c"}}`
+	want := PassthroughHolder{
+		Item:  Passthrough{Name: "a", Count: 1},
+		Items: []Passthrough{{Name: "b", Count: 2}},
+		Ptr:   &Passthrough{Name: "c"},
+	}
+
+	var got PassthroughHolder
+	if err := easyjson.Unmarshal([]byte(data), &got); err != nil {
+		t.Errorf("easyjson.Unmarshal() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("easyjson.Unmarshal() = %+v; want %+v", got, want)
+	}
+
+	var item Passthrough
+	if err := item.UnmarshalJSON([]byte(`{"Name":"d","Count":4}`)); err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	if want := (Passthrough{Name: "d", Count: 4}); item != want {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", item, want)
+	}
+}
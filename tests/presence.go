@@ -0,0 +1,18 @@
+package tests
+
+import "github.com/mailru/easyjson"
+
+//easyjson:json
+type ProfilePatch struct {
+	Name    string            `json:"name"`
+	Email   *string           `json:"email"`
+	Age     int               `json:"age" easyjson:"aliases=years"`
+	Tags    []string          `json:"tags"`
+	Present easyjson.Presence `json:"_present,omitempty"`
+}
+
+//easyjson:json
+type QuietPatch struct {
+	Name    string            `json:"name"`
+	Present easyjson.Presence `json:"-"`
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestPresence(t *testing.T) {
+	for i, test := range []struct {
+		data string
+		want easyjson.Presence
+	}{
+		{data: `{}`},
+		{data: `{"name":"x"}`, want: easyjson.Presence{"name"}},
+		{data: `{"tags":[],"email":null,"unknown":1}`, want: easyjson.Presence{"tags", "email"}},
+		{data: `{"years":30,"name":"x","age":31}`, want: easyjson.Presence{"age", "name"}},
+		{data: `{"name":"x","_present":["tags"]}`, want: easyjson.Presence{"name"}},
+	} {
+		v := ProfilePatch{Present: easyjson.Presence{"stale"}}
+		if err := easyjson.Unmarshal([]byte(test.data), &v); err != nil {
+			t.Errorf("[%d, %q] Unmarshal() error: %v", i, test.data, err)
+			continue
+		}
+		if got := v.PresentFields(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %q] PresentFields() = %q; want %q", i, test.data, got, test.want)
+		}
+	}
+}
+
+func TestPresenceMarshal(t *testing.T) {
+	var v ProfilePatch
+	if err := easyjson.Unmarshal([]byte(`{"age":5,"name":"x"}`), &v); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if !v.Present.Has("age") || v.Present.Has("email") {
+		t.Errorf("Present = %q; want age and name", v.Present)
+	}
+
+	data, err := easyjson.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	want := `{"name":"x","email":null,"age":5,"tags":[],"_present":["age","name"]}`
+	if got := string(data); got != want {
+		t.Errorf("Marshal() = %v; want %v", got, want)
+	}
+
+	var q QuietPatch
+	if err := easyjson.Unmarshal([]byte(`{"name":"x"}`), &q); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	var tracker easyjson.PresenceTracker = q
+	if got := tracker.PresentFields(); !reflect.DeepEqual(got, easyjson.Presence{"name"}) {
+		t.Errorf("PresentFields() = %q; want [name]", got)
+	}
+	if data, _ := easyjson.Marshal(q); string(data) != `{"name":"x"}` {
+		t.Errorf("Marshal() = %s; want %v", data, `{"name":"x"}`)
+	}
+}
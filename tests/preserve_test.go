@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPreserveRaw(t *testing.T) {
+	payload := `{ "Scopes" : ["read", "write"],"Subject":"user\u0031" }`
+	data := []byte(`{"Signature":"abc",  "Payload":` + payload + ` , "Unknown":1}`)
+
+	var v Signed
+	if err := v.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+
+	want := SignedPayload{Subject: "user1", Scopes: []string{"read", "write"}}
+	if !reflect.DeepEqual(v.Payload, want) {
+		t.Errorf("UnmarshalJSON() Payload = %+v; want %+v", v.Payload, want)
+	}
+	if got := string(v.PayloadRaw); got != payload {
+		t.Errorf("UnmarshalJSON() PayloadRaw = %q; want %q", got, payload)
+	}
+
+	// The raw bytes are copied, so they do not change with the input buffer.
+	for i := range data {
+		data[i] = ' '
+	}
+	if got := string(v.PayloadRaw); got != payload {
+		t.Errorf("PayloadRaw after input change = %q; want %q", got, payload)
+	}
+}
+
+func TestPreserveRawNull(t *testing.T) {
+	var v Signed
+	if err := v.UnmarshalJSON([]byte(`{"Payload":null}`)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	if got := string(v.PayloadRaw); got != "null" {
+		t.Errorf("UnmarshalJSON() PayloadRaw = %q; want %q", got, "null")
+	}
+}
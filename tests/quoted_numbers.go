@@ -0,0 +1,13 @@
+package tests
+
+//easyjson:json
+type QuotedNumbers struct {
+	Ints  []int
+	Float float64
+	Uint8 uint8
+	Named NamedInt
+	Str   string
+}
+
+var quotedNumbersValue = QuotedNumbers{Ints: []int{1, 2, 3}, Float: 1.5, Uint8: 255, Named: -7, Str: "8"}
+var quotedNumbersString = `{"Ints":[1,2,3],"Float":1.5,"Uint8":255,"Named":-7,"Str":"8"}`
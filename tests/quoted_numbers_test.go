@@ -0,0 +1,23 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuotedNumbers(t *testing.T) {
+	var v QuotedNumbers
+	err := v.UnmarshalJSON([]byte(`{"Ints":[1, "2", 3],"Float":"1.5","Uint8":"255","Named":"-7","Str":"8"}`))
+	if err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	if !reflect.DeepEqual(v, quotedNumbersValue) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", v, quotedNumbersValue)
+	}
+
+	for _, data := range []string{`{"Ints":["a"]}`, `{"Uint8":"256"}`, `{"Str":8}`} {
+		if err := v.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("UnmarshalJSON(%s) ok; want error", data)
+		}
+	}
+}
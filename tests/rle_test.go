@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson"
+	"github.com/mailru/easyjson/jlexer"
+)
+
+func TestRLE(t *testing.T) {
+	repetitive := make([]int8, 1000)
+	repetitive[500] = -1
+	repetitive = append(repetitive, 7, 7)
+
+	for i, test := range []struct {
+		v    SparseSeries
+		want string
+	}{
+		{v: SparseSeries{}, want: `{"levels":[]}`},
+		{v: SparseSeries{Levels: []int8{5}}, want: `{"levels":[[5,1]]}`},
+		{v: SparseSeries{Levels: []int8{1, 2, 3, 2}}, want: `{"levels":[[1,1],[2,1],[3,1],[2,1]]}`},
+		{v: SparseSeries{Levels: repetitive}, want: `{"levels":[[0,500],[-1,1],[0,499],[7,2]]}`},
+		{
+			v:    SparseSeries{Samples: []uint32{4294967295, 4294967295, 0}},
+			want: `{"levels":[],"samples":[[4294967295,2],[0,1]]}`,
+		},
+	} {
+		data, err := easyjson.Marshal(test.v)
+		if err != nil {
+			t.Errorf("[%d] Marshal() error: %v", i, err)
+			continue
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] Marshal() = %v; want %v", i, got, test.want)
+		}
+
+		var got SparseSeries
+		if err := easyjson.Unmarshal(data, &got); err != nil {
+			t.Errorf("[%d] Unmarshal() error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("[%d] Unmarshal() = %+v; want %+v", i, got, test.v)
+		}
+	}
+}
+
+func TestRLEUnmarshal(t *testing.T) {
+	v := SparseSeries{Levels: []int8{1}, Samples: []uint32{1}}
+	if err := easyjson.Unmarshal([]byte(`{"levels":null,"samples":[[3,2],[3,1]]}`), &v); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if want := (SparseSeries{Samples: []uint32{3, 3, 3}}); !reflect.DeepEqual(v, want) {
+		t.Errorf("Unmarshal() = %+v; want %+v", v, want)
+	}
+
+	for i, data := range []string{
+		`{"levels":[[1,0]]}`,
+		`{"levels":[[1,-2]]}`,
+		`{"levels":[[1]]}`,
+		`{"levels":[[1,2,3]]}`,
+		`{"levels":[1,2]}`,
+		`{"levels":[[300,1]]}`,
+	} {
+		var v SparseSeries
+		if err := easyjson.Unmarshal([]byte(data), &v); err == nil {
+			t.Errorf("[%d, %q] Unmarshal() ok; want error", i, data)
+		}
+	}
+
+	// A short input can't expand to a huge slice.
+	l := jlexer.Lexer{Data: []byte(`{"levels":[[0,600],[1,600]]}`), MaxRunLength: 1000}
+	v.UnmarshalEasyJSON(&l)
+	if l.Error() == nil {
+		t.Errorf("UnmarshalEasyJSON() beyond MaxRunLength ok; want error")
+	}
+}
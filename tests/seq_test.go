@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestSeq(t *testing.T) {
+	values := []IOStruct{{Name: "a", Count: 1}, {}, {Name: "c\n", Count: 3}}
+	want := "\x1e" + `{"Name":"a","Count":1}` + "\n" +
+		"\x1e" + `{"Name":"","Count":0}` + "\n" +
+		"\x1e" + `{"Name":"c\n","Count":3}` + "\n"
+
+	out := &bytes.Buffer{}
+	w := easyjson.NewSeqWriter(out)
+	for i := range values {
+		if err := w.Write(values[i]); err != nil {
+			t.Errorf("[%d] Write() error: %v", i, err)
+		}
+	}
+	if got := out.String(); got != want {
+		t.Errorf("Write() output = %q; want %q", got, want)
+	}
+
+	var got []IOStruct
+	r := easyjson.NewSeqReader(bytes.NewReader(append([]byte("\x1e\x1e\n"), out.Bytes()...)))
+	for {
+		var v IOStruct
+		err := r.Read(&v)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Read() error: %v", err)
+		}
+		got = append(got, v)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("Read() = %+v; want %+v", got, values)
+	}
+}
+
+func TestSeqReadError(t *testing.T) {
+	r := easyjson.NewSeqReader(bytes.NewReader([]byte("\x1e{\"Name\":\n\x1e{}\n")))
+
+	var v IOStruct
+	if err := r.Read(&v); err == nil {
+		t.Errorf("Read() of a truncated record ok; want error")
+	}
+	if err := r.Read(&v); err != nil {
+		t.Errorf("Read() after a truncated record error: %v", err)
+	}
+}
+
+func TestSeqReaderReset(t *testing.T) {
+	r := easyjson.NewSeqReader(bytes.NewReader([]byte("\x1e{\"Name\":\"stale\"}\n\x1e{\"Name\":\"left\"}\n")))
+	var v IOStruct
+	if err := r.Read(&v); err != nil || v.Name != "stale" {
+		t.Fatalf("Read() = %+v, %v; want stale", v, err)
+	}
+
+	// The rest of the buffered data of the previous reader is discarded.
+	for i, name := range []string{"a", "b", "c"} {
+		r.Reset(bytes.NewReader([]byte("\x1e{\"Name\":\"" + name + "\",\"Count\":1}\n")))
+
+		var v IOStruct
+		if err := r.Read(&v); err != nil {
+			t.Fatalf("[%d] Read() error: %v", i, err)
+		}
+		if v.Name != name {
+			t.Errorf("[%d] Read() after Reset() = %+v; want %v", i, v, name)
+		}
+		if err := r.Read(&v); err != io.EOF {
+			t.Errorf("[%d] Read() at the end error = %v; want %v", i, err, io.EOF)
+		}
+	}
+
+	var zero easyjson.SeqReader
+	zero.Reset(bytes.NewReader([]byte("\x1e{\"Name\":\"z\"}\n")))
+	if err := zero.Read(&v); err != nil || v.Name != "z" {
+		t.Errorf("Read() of a zero reader after Reset() = %+v, %v; want z", v, err)
+	}
+}
+
+func TestSeqReaderResetAllocs(t *testing.T) {
+	data := []byte("\x1e{\"Name\":\"a\",\"Count\":1}\n\x1e{\"Name\":\"b\",\"Count\":2}\n")
+	in := bytes.NewReader(data)
+
+	decode := func(r *easyjson.SeqReader) {
+		var v IOStruct
+		for r.Read(&v) == nil {
+		}
+	}
+
+	r := easyjson.NewSeqReader(in)
+	reused := testing.AllocsPerRun(100, func() {
+		in.Reset(data)
+		r.Reset(in)
+		decode(r)
+	})
+	created := testing.AllocsPerRun(100, func() {
+		in.Reset(data)
+		decode(easyjson.NewSeqReader(in))
+	})
+	if reused >= created {
+		t.Errorf("allocations with Reset() = %v; want less than %v of a new reader", reused, created)
+	}
+
+	// The allocations do not grow with the number of decodes.
+	again := testing.AllocsPerRun(1000, func() {
+		in.Reset(data)
+		r.Reset(in)
+		decode(r)
+	})
+	if again != reused {
+		t.Errorf("allocations with Reset() = %v after more decodes; want %v", again, reused)
+	}
+}
@@ -0,0 +1,18 @@
+package tests
+
+//easyjson:json
+type BatchItem struct {
+	ID   int
+	Name string
+	Tags []string
+}
+
+var batchItemsValue = []BatchItem{
+	{ID: 1, Name: "first", Tags: []string{"a"}},
+	{ID: 2, Name: "second"},
+}
+
+var batchItemsString = `[` +
+	`{"ID":1,"Name":"first","Tags":["a"]},` +
+	`{"ID":2,"Name":"second","Tags":[]}` +
+	`]`
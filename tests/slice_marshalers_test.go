@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/mailru/easyjson/jwriter"
+)
+
+func TestMarshalSlice(t *testing.T) {
+	for i, test := range []struct {
+		items []BatchItem
+		want  string
+	}{
+		{items: batchItemsValue, want: batchItemsString},
+		{items: []BatchItem{}, want: `[]`},
+		{items: nil, want: `[]`},
+	} {
+		data, err := MarshalBatchItemSlice(test.items)
+		if err != nil {
+			t.Errorf("[%d] MarshalBatchItemSlice() error: %v", i, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] MarshalBatchItemSlice() = %s; want %s", i, got, test.want)
+		}
+	}
+}
+
+func batchItems(n int) []BatchItem {
+	items := make([]BatchItem, n)
+	for i := range items {
+		items[i] = BatchItem{ID: i, Name: "item", Tags: []string{"x", "y"}}
+	}
+	return items
+}
+
+func BenchmarkMarshalSlicePerElement(b *testing.B) {
+	items := batchItems(100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w := jwriter.Writer{}
+		w.RawByte('[')
+		for j, v := range items {
+			if j > 0 {
+				w.RawByte(',')
+			}
+			data, err := v.MarshalJSON()
+			w.Raw(data, err)
+		}
+		w.RawByte(']')
+		if _, err := w.BuildBytes(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalSliceBatch(b *testing.B) {
+	items := batchItems(100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := MarshalBatchItemSlice(items); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
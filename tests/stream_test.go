@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mailru/easyjson"
+	"github.com/mailru/easyjson/jwriter"
+)
+
+// failingMarshaler sets a writer error when marshaled.
+type failingMarshaler struct{}
+
+var errFailingMarshaler = errors.New("marshal failed")
+
+func (failingMarshaler) MarshalEasyJSON(w *jwriter.Writer) {
+	w.RawString(`{"partial":`)
+	w.Error = errFailingMarshaler
+}
+
+// countingWriter counts the data written to it.
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestArrayStream(t *testing.T) {
+	const (
+		count     = 100000
+		threshold = 4096
+	)
+
+	out := &countingWriter{}
+	w := &jwriter.Writer{}
+	s := easyjson.NewArrayStream(w, out, threshold)
+
+	s.Start()
+	maxSize := 0
+	for i := 0; i < count; i++ {
+		if err := s.Add(IOStruct{Name: "element", Count: i}); err != nil {
+			t.Fatalf("[%d] Add() error: %v", i, err)
+		}
+		if size := w.Size(); size > maxSize {
+			maxSize = size
+		}
+	}
+	if err := s.End(); err != nil {
+		t.Fatalf("End() error: %v", err)
+	}
+
+	if maxSize >= threshold {
+		t.Errorf("buffer size after Add() = %v; want < %v", maxSize, threshold)
+	}
+	if flushes := out.Len() / threshold / 2; out.writes < flushes {
+		t.Errorf("output of %v bytes in %v writes; want at least %v", out.Len(), out.writes, flushes)
+	}
+	if w.Size() != 0 {
+		t.Errorf("buffer size after End() = %v; want 0", w.Size())
+	}
+
+	var got []IOStruct
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error: %v", err)
+	}
+	if len(got) != count || got[count-1].Count != count-1 {
+		t.Errorf("json.Unmarshal() got %v elements; want %v", len(got), count)
+	}
+}
+
+func TestArrayStreamEmpty(t *testing.T) {
+	out := &bytes.Buffer{}
+	s := easyjson.NewArrayStream(&jwriter.Writer{}, out, 0)
+
+	s.Start()
+	if err := s.End(); err != nil {
+		t.Errorf("End() error: %v", err)
+	}
+	if got := out.String(); got != "[]" {
+		t.Errorf("End() output = %q; want %q", got, "[]")
+	}
+}
+
+func TestArrayStreamError(t *testing.T) {
+	out := &bytes.Buffer{}
+	s := easyjson.NewArrayStream(&jwriter.Writer{}, out, 1)
+
+	s.Start()
+	if err := s.Add(IOStruct{Name: "a"}); err != nil {
+		t.Errorf("Add() error: %v", err)
+	}
+	if err := s.Add(failingMarshaler{}); err != errFailingMarshaler {
+		t.Errorf("Add() of a failing value error = %v; want %v", err, errFailingMarshaler)
+	}
+	if err := s.Add(IOStruct{Name: "b"}); err != errFailingMarshaler {
+		t.Errorf("Add() after a failure error = %v; want %v", err, errFailingMarshaler)
+	}
+	if err := s.End(); err != errFailingMarshaler {
+		t.Errorf("End() after a failure error = %v; want %v", err, errFailingMarshaler)
+	}
+
+	want := `[{"Name":"a","Count":0}`
+	if got := out.String(); got != want {
+		t.Errorf("output = %q; want %q", got, want)
+	}
+}
+
+// failingWriter fails all writes after the first limit bytes.
+type failingWriter struct {
+	limit int
+}
+
+var errFailingWriter = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errFailingWriter
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestArrayStreamProgress(t *testing.T) {
+	out := &bytes.Buffer{}
+	s := easyjson.NewArrayStream(&jwriter.Writer{}, out, 1024)
+
+	var progress []int64
+	s.SetProgress(func(bytesWritten int64) {
+		progress = append(progress, bytesWritten)
+	})
+
+	s.Start()
+	for i := 0; i < 1000; i++ {
+		if err := s.Add(IOStruct{Name: "element", Count: i}); err != nil {
+			t.Fatalf("[%d] Add() error: %v", i, err)
+		}
+	}
+	if err := s.End(); err != nil {
+		t.Fatalf("End() error: %v", err)
+	}
+
+	if len(progress) < 2 {
+		t.Fatalf("progress callback invoked %v times; want several", len(progress))
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i] <= progress[i-1] {
+			t.Errorf("[%d] progress %v after %v; want increasing", i, progress[i], progress[i-1])
+		}
+	}
+	if got, want := progress[len(progress)-1], int64(out.Len()); got != want {
+		t.Errorf("final progress = %v; want %v", got, want)
+	}
+}
+
+func TestArrayStreamProgressError(t *testing.T) {
+	s := easyjson.NewArrayStream(&jwriter.Writer{}, &failingWriter{limit: 1500}, 1024)
+
+	var progress []int64
+	s.SetProgress(func(bytesWritten int64) {
+		progress = append(progress, bytesWritten)
+	})
+
+	s.Start()
+	var err error
+	for i := 0; i < 1000 && err == nil; i++ {
+		err = s.Add(IOStruct{Name: "element", Count: i})
+	}
+	if err != errFailingWriter {
+		t.Fatalf("Add() error = %v; want %v", err, errFailingWriter)
+	}
+	if err := s.End(); err != errFailingWriter {
+		t.Errorf("End() error = %v; want %v", err, errFailingWriter)
+	}
+
+	if len(progress) != 1 || progress[0] > 1500 {
+		t.Errorf("progress = %v; want a single successful flush", progress)
+	}
+}
+
+// ioRows iterates over rows of IOStruct values with counts from 0 to n-1.
+type ioRows struct {
+	n, cur int
+}
+
+func (r *ioRows) Next() bool {
+	r.cur++
+	return r.cur <= r.n
+}
+
+func (r *ioRows) MarshalRow(w *jwriter.Writer) {
+	IOStruct{Name: "row", Count: r.cur - 1}.MarshalEasyJSON(w)
+}
+
+func TestArrayStreamAddRows(t *testing.T) {
+	const count = 10000
+
+	out := &countingWriter{}
+	w := &jwriter.Writer{}
+	s := easyjson.NewArrayStream(w, out, 1024)
+
+	s.Start()
+	if err := s.Add(IOStruct{Name: "first"}); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	if err := s.AddRows(&ioRows{n: count}); err != nil {
+		t.Fatalf("AddRows() error: %v", err)
+	}
+	if w.Size() >= 1024 {
+		t.Errorf("buffer size after AddRows() = %v; want < %v", w.Size(), 1024)
+	}
+	if err := s.End(); err != nil {
+		t.Fatalf("End() error: %v", err)
+	}
+	if out.writes < 2 {
+		t.Errorf("output in %v writes; want several", out.writes)
+	}
+
+	var got []IOStruct
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error: %v", err)
+	}
+	if len(got) != count+1 || got[0].Name != "first" || got[count].Count != count-1 {
+		t.Errorf("json.Unmarshal() got %v elements; want %v", len(got), count+1)
+	}
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+// tagCornersStd has the tags of TagCorners, but no generated marshalers.
+type tagCornersStd struct {
+	Name  string `json:",omitempty"`
+	Skip  string `json:"-"`
+	Dash  string `json:"-,"`
+	Count int    `json:",string"`
+}
+
+func TestTagCorners(t *testing.T) {
+	for i, v := range []tagCornersStd{
+		{},
+		{Name: "n", Skip: "s", Dash: "d", Count: 3},
+	} {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("[%d] json.Marshal() error: %v", i, err)
+		}
+		got, err := easyjson.Marshal(TagCorners(v))
+		if err != nil {
+			t.Errorf("[%d] Marshal() error: %v", i, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("[%d] Marshal() = %s; want %s", i, got, want)
+		}
+	}
+
+	data := `{"Name":"n","Skip":"s","-":"d","Count":"3"}`
+	var want tagCornersStd
+	if err := json.Unmarshal([]byte(data), &want); err != nil {
+		t.Fatalf("json.Unmarshal() error: %v", err)
+	}
+	var got TagCorners
+	if err := easyjson.Unmarshal([]byte(data), &got); err != nil {
+		t.Errorf("Unmarshal() error: %v", err)
+	}
+	if v := (TagCorners{Name: "n", Dash: "d", Count: 3}); !reflect.DeepEqual(got, v) || tagCornersStd(got) != want {
+		t.Errorf("Unmarshal() = %+v; want %+v", got, v)
+	}
+}
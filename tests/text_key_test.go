@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestTextMapKeys(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	later := time.Date(2024, 3, 1, 1, 30, 0, 0, time.UTC)
+	v1 := Version(1<<8 | 2)
+
+	for i, test := range []struct {
+		v    TextKeyMaps
+		want string
+	}{
+		{v: TextKeyMaps{}, want: `{"hits":null}`},
+		{
+			v:    TextKeyMaps{Hits: map[time.Time]int{day: 3}},
+			want: `{"hits":{"2024-03-01T00:00:00Z":3}}`,
+		},
+		{
+			v: TextKeyMaps{
+				Releases: map[Version]string{v1: "stable"},
+				Nested:   map[time.Time][]int{later: {1, 2}},
+			},
+			want: `{"hits":null,"releases":{"v1.2":"stable"},"nested":{"2024-03-01T01:30:00Z":[1,2]}}`,
+		},
+	} {
+		data, err := easyjson.Marshal(test.v)
+		if err != nil {
+			t.Errorf("[%d] Marshal() error: %v", i, err)
+			continue
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] Marshal() = %v; want %v", i, got, test.want)
+		}
+
+		var got TextKeyMaps
+		if err := easyjson.Unmarshal(data, &got); err != nil {
+			t.Errorf("[%d] Unmarshal() error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("[%d] Unmarshal() = %+v; want %+v", i, got, test.v)
+		}
+	}
+}
+
+func TestTextMapKeysStd(t *testing.T) {
+	in := map[time.Time]int{
+		time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("", 3600)): 1,
+		time.Date(2023, 1, 2, 0, 0, 0, 500, time.UTC):                 2,
+	}
+	want, err := json.Marshal(map[string]map[time.Time]int{"hits": in})
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %v", err)
+	}
+
+	var v TextKeyMaps
+	if err := easyjson.Unmarshal(want, &v); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	for k, n := range in {
+		var found bool
+		for k1, n1 := range v.Hits {
+			found = found || k1.Equal(k) && n1 == n
+		}
+		if !found {
+			t.Errorf("Unmarshal() = %v; want key %v", v.Hits, k)
+		}
+	}
+}
+
+func TestTextMapKeysError(t *testing.T) {
+	var v TextKeyMaps
+	if err := easyjson.Unmarshal([]byte(`{"releases":{"1.2":"x"}}`), &v); err == nil {
+		t.Errorf("Unmarshal() of a bad key ok; want error")
+	}
+	if err := easyjson.Unmarshal([]byte(`{"hits":{"yesterday":1}}`), &v); err == nil {
+		t.Errorf("Unmarshal() of a bad time key ok; want error")
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestampTruncation(t *testing.T) {
+	tm := time.Date(2017, 1, 2, 3, 4, 5, 6789012, time.UTC)
+	v := Timestamps{Unix: tm, Milli: tm, Nano: tm}
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON() error: %v", err)
+	}
+
+	var got Timestamps
+	if err := got.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	if want := tm.Truncate(time.Second); !got.Unix.Equal(want) {
+		t.Errorf("Unix = %v; want %v", got.Unix, want)
+	}
+	if want := tm.Truncate(time.Millisecond); !got.Milli.Equal(want) {
+		t.Errorf("Milli = %v; want %v", got.Milli, want)
+	}
+	if !got.Nano.Equal(tm) {
+		t.Errorf("Nano = %v; want %v", got.Nano, tm)
+	}
+}
+
+func TestTimestampNull(t *testing.T) {
+	tm := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	v := Timestamps{Milli: tm, Ptr: &tm}
+
+	if err := v.UnmarshalJSON([]byte(`{"Milli":null,"Ptr":null}`)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	if !v.Milli.Equal(tm) || v.Ptr != nil {
+		t.Errorf("UnmarshalJSON() = %+v; want Milli unchanged and Ptr nil", v)
+	}
+}
+
+func TestTimeZones(t *testing.T) {
+	data := `{` +
+		`"UTC":"2017-01-02T03:04:05+03:00",` +
+		`"Local":"2017-01-02T03:04:05-07:00",` +
+		`"Ptr":"2017-01-02T03:04:05+03:00",` +
+		`"Unix":1483326245,` +
+		`"Slice":["2017-01-02T03:04:05+03:00","2017-01-02T03:04:05Z"],` +
+		`"Std":"2017-01-02T03:04:05+03:00"` +
+		`}`
+	offset := time.FixedZone("", 3*60*60)
+
+	var got TimeZones
+	if err := got.UnmarshalJSON([]byte(data)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+
+	for i, test := range []struct {
+		got  time.Time
+		want time.Time
+		loc  *time.Location
+	}{
+		{got: got.UTC, want: time.Date(2017, 1, 2, 3, 4, 5, 0, offset), loc: time.UTC},
+		{got: got.Local, want: time.Date(2017, 1, 2, 10, 4, 5, 0, time.UTC), loc: time.Local},
+		{got: *got.Ptr, want: time.Date(2017, 1, 2, 0, 4, 5, 0, time.UTC), loc: time.UTC},
+		{got: got.Unix, want: time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC), loc: time.Local},
+		{got: got.Slice[0], want: time.Date(2017, 1, 2, 0, 4, 5, 0, time.UTC), loc: time.UTC},
+		{got: got.Slice[1], want: time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC), loc: time.UTC},
+	} {
+		if !test.got.Equal(test.want) {
+			t.Errorf("[%d] decoded time = %v; want %v", i, test.got, test.want)
+		}
+		if test.got.Location() != test.loc {
+			t.Errorf("[%d] decoded time location = %v; want %v", i, test.got.Location(), test.loc)
+		}
+	}
+	if _, offset := got.Std.Zone(); offset != 3*60*60 {
+		t.Errorf("Std time zone offset = %v; want the input offset", offset)
+	}
+}
+
+func TestTimeZonesNull(t *testing.T) {
+	tm := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	got := TimeZones{UTC: tm, Ptr: &tm}
+	if err := got.UnmarshalJSON([]byte(`{"UTC":null,"Ptr":null}`)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	if !got.UTC.Equal(tm) || got.Ptr != nil {
+		t.Errorf("UnmarshalJSON() = %v, %v; want %v, nil", got.UTC, got.Ptr, tm)
+	}
+}
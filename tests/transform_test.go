@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func init() {
+	easyjson.RegisterTransform("slug", func(s string) string {
+		return strings.Join(strings.Fields(strings.ToLower(s)), "-")
+	})
+}
+
+func TestTransform(t *testing.T) {
+	for i, test := range []struct {
+		data string
+		want Normalized
+	}{
+		{
+			data: `{"email":"Alice@Example.COM","code":" ab1 ","name":"  Bob Smith ","tags":["Go","JSON"]}`,
+			want: Normalized{Email: "alice@example.com", Code: "AB1", Name: "bob smith", Tags: []string{"go", "json"}},
+		},
+		{
+			data: `{"slug":"Hello  Big World"}`,
+			want: Normalized{Slug: "hello-big-world"},
+		},
+		{
+			data: `{"email":"ÉCOLE@x.fr"}`,
+			want: Normalized{Email: "école@x.fr"},
+		},
+	} {
+		var got Normalized
+		if err := got.UnmarshalJSON([]byte(test.data)); err != nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v", i, test.data, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %+v; want %+v", i, test.data, got, test.want)
+		}
+	}
+}
+
+func TestTransformUnknown(t *testing.T) {
+	var got Normalized
+	err := got.UnmarshalJSON([]byte(`{"other":"x"}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown transform "missing"`) {
+		t.Errorf("UnmarshalJSON() error = %v; want an unknown transform error", err)
+	}
+}
+
+func TestRegisterTransformBuiltin(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterTransform(lower) did not panic")
+		}
+	}()
+	easyjson.RegisterTransform("lower", strings.ToUpper)
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrim(t *testing.T) {
+	for i, test := range []struct {
+		data string
+		want Trimmed
+	}{
+		{
+			data: `{"Name":"  hi  ","tags":[" a ","b\t"],"Plain":"  hi  "}`,
+			want: Trimmed{Name: "hi", Tags: []string{"a", "b"}, Plain: "  hi  "},
+		},
+		{
+			data: `{"Name":" two  words\n"}`,
+			want: Trimmed{Name: "two  words"},
+		},
+		{
+			data: `{"Name":"   ","tags":["\t"]}`,
+			want: Trimmed{Name: "", Tags: []string{""}},
+		},
+	} {
+		var got Trimmed
+		if err := got.UnmarshalJSON([]byte(test.data)); err != nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() error: %v", i, test.data, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d, %s] UnmarshalJSON() = %+v; want %+v", i, test.data, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package tests
+
+import "github.com/mailru/easyjson/tests/ext"
+
+type TypeMapped struct {
+	ID      ext.UUID
+	Amount  ext.Decimal
+	Prices  []ext.Decimal
+	Parent  *ext.UUID
+	Comment string
+}
+
+type Wide struct {
+	ID    ext.U128
+	Delta ext.I128
+	IDs   []ext.U128
+	Max   *ext.U128
+}
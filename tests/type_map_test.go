@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson/tests/ext"
+)
+
+var typeMappedParent = ext.UUID{0xf8, 0x1d, 0x4f, 0xae, 0x7d, 0xec, 0x11, 0xd0, 0xa7, 0x65, 0x00, 0xa0, 0xc9, 0x1e, 0x6b, 0xf6}
+
+var typeMappedValue = TypeMapped{
+	ID:      ext.UUID{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0},
+	Amount:  ext.Decimal{Cents: 1250},
+	Prices:  []ext.Decimal{{Cents: 1}, {Cents: -99}},
+	Parent:  &typeMappedParent,
+	Comment: "x",
+}
+
+var typeMappedString = `{"ID":"12345678-9abc-def0-1234-56789abcdef0","Amount":12.50,"Prices":[0.01,-0.99],` +
+	`"Parent":"f81d4fae-7dec-11d0-a765-00a0c91e6bf6","Comment":"x"}`
+
+func TestTypeMapMarshal(t *testing.T) {
+	data, err := typeMappedValue.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON() error: %v", err)
+	}
+	if got := string(data); got != typeMappedString {
+		t.Errorf("MarshalJSON() = %s; want %s", got, typeMappedString)
+	}
+}
+
+func TestTypeMapUnmarshal(t *testing.T) {
+	var got TypeMapped
+	if err := got.UnmarshalJSON([]byte(typeMappedString)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, typeMappedValue) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", got, typeMappedValue)
+	}
+}
+
+func TestTypeMapUnmarshalErrors(t *testing.T) {
+	for i, data := range []string{
+		`{"ID":"not-a-uuid"}`,
+		`{"ID":123}`,
+		`{"Amount":"12.50"}`,
+		`{"Amount":12.5}`,
+		`{"Prices":[1.00,{}]}`,
+	} {
+		var got TypeMapped
+		if err := got.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() ok; want error", i, data)
+		}
+	}
+}
+
+func TestTypeMapNull(t *testing.T) {
+	got := TypeMapped{Amount: ext.Decimal{Cents: 5}}
+	if err := got.UnmarshalJSON([]byte(`{"ID":null,"Amount":null,"Parent":null}`)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	if want := (TypeMapped{Amount: ext.Decimal{Cents: 5}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", got, want)
+	}
+}
+
+func TestInt128RoundTrip(t *testing.T) {
+	max := ext.U128{Hi: math.MaxUint64, Lo: math.MaxUint64}
+	for i, test := range []struct {
+		v    Wide
+		want string
+	}{
+		{
+			v:    Wide{},
+			want: `{"ID":"0","Delta":"0","IDs":[],"Max":null}`,
+		},
+		{
+			v: Wide{
+				ID:    ext.U128{Hi: 1, Lo: 2},
+				Delta: ext.I128{Hi: math.MinInt64},
+				IDs:   []ext.U128{{Lo: 7}, max},
+				Max:   &max,
+			},
+			want: `{"ID":"18446744073709551618","Delta":"-170141183460469231731687303715884105728",` +
+				`"IDs":["7","340282366920938463463374607431768211455"],"Max":"340282366920938463463374607431768211455"}`,
+		},
+		{
+			v:    Wide{Delta: ext.I128{Hi: math.MaxInt64, Lo: math.MaxUint64}},
+			want: `{"ID":"0","Delta":"170141183460469231731687303715884105727","IDs":[],"Max":null}`,
+		},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d] MarshalJSON() error: %v", i, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] MarshalJSON() = %s; want %s", i, got, test.want)
+		}
+
+		var got Wide
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Errorf("[%d] UnmarshalJSON() error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("[%d] UnmarshalJSON() = %+v; want %+v", i, got, test.v)
+		}
+	}
+}
+
+func TestInt128UnmarshalErrors(t *testing.T) {
+	for i, data := range []string{
+		`{"ID":1}`,
+		`{"ID":"340282366920938463463374607431768211456"}`,
+		`{"Delta":"170141183460469231731687303715884105728"}`,
+	} {
+		var got Wide
+		if err := got.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("[%d, %s] UnmarshalJSON() ok; want error", i, data)
+		}
+	}
+}
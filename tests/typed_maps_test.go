@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTypedMaps(t *testing.T) {
+	data := `{"Items":{"a":{"Name":"x","Count":1},"b":null},"Ints":{"a":[1,2],"b":[]}}`
+	want := TypedMaps{
+		Items: map[string]*TypedMapItem{"a": {Name: "x", Count: 1}, "b": nil},
+		Ints:  map[string][]int{"a": {1, 2}, "b": nil},
+	}
+
+	var got TypedMaps
+	if err := got.UnmarshalJSON([]byte(data)); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", got, want)
+	}
+}
+
+func TestTypedMapsError(t *testing.T) {
+	for _, data := range []string{
+		`{"Items":{"a":{"Count":"1"}}}`,
+		`{"Ints":{"a":[1,"2"]}}`,
+		`{"Items":{"a":[]}}`,
+	} {
+		var v TypedMaps
+		if err := v.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("UnmarshalJSON(%s) ok; want error", data)
+		}
+	}
+}
+
+// typedMapsData returns an object with maps of n entries each.
+func typedMapsData(n int) []byte {
+	items := make([]string, n)
+	ints := make([]string, n)
+	for i := range items {
+		key := strconv.Quote("key" + strconv.Itoa(i))
+		items[i] = key + `:{"Name":"name","Count":` + strconv.Itoa(i) + `}`
+		ints[i] = key + `:[1,2,3,` + strconv.Itoa(i) + `]`
+	}
+	return []byte(`{"Items":{` + strings.Join(items, ",") + `},"Ints":{` + strings.Join(ints, ",") + `}}`)
+}
+
+func BenchmarkTypedMapsUnmarshal(b *testing.B) {
+	data := typedMapsData(100)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v TypedMaps
+		if err := v.UnmarshalJSON(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenericMapsUnmarshal(b *testing.B) {
+	data := typedMapsData(100)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v GenericMaps
+		if err := v.UnmarshalJSON(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestValueOmitEmpty(t *testing.T) {
+	zero, one := 0, 1
+	for i, test := range []struct {
+		v    SparseMaps
+		want string
+	}{
+		{
+			v:    SparseMaps{},
+			want: `{"labels":null,"groups":null,"nested":null,"settings":null}`,
+		},
+		{
+			v: SparseMaps{
+				Labels: map[string]string{"a": "x", "b": ""},
+				Counts: map[string]*int{"zero": &zero, "nil": nil},
+				Groups: map[string][]string{"empty": {}, "nil": nil},
+				Nested: map[string]map[string]int{"a": {"z": 0}, "b": {}},
+			},
+			want: `{"labels":{"a":"x"},"counts":{"zero":0},"groups":{},"nested":{"a":{"z":0}},"settings":null}`,
+		},
+		{
+			v: SparseMaps{
+				Counts:   map[string]*int{"one": &one},
+				Settings: map[string]EnvelopeData{"zero": {}},
+			},
+			want: `{"labels":null,"counts":{"one":1},"groups":null,"nested":null,"settings":{"zero":{"x":0}}}`,
+		},
+		{
+			v:    SparseMaps{Counts: map[string]*int{"nil": nil}},
+			want: `{"labels":null,"counts":{},"groups":null,"nested":null,"settings":null}`,
+		},
+	} {
+		data, err := easyjson.Marshal(test.v)
+		if err != nil {
+			t.Errorf("[%d] Marshal() error: %v", i, err)
+			continue
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] Marshal() = %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestValueOmitEmptyNilAsEmpty(t *testing.T) {
+	v := NilAsEmptySparse{Labels: map[string]string{"a": "", "b": ""}}
+	data, err := easyjson.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	want := `{"Labels":{}}`
+	if got := string(data); got != want {
+		t.Errorf("Marshal() = %v; want %v", got, want)
+	}
+
+	var got NilAsEmptySparse
+	if err := easyjson.Unmarshal([]byte(`{"Labels":{"a":"","b":"x"}}`), &got); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if want := map[string]string{"a": "", "b": "x"}; !reflect.DeepEqual(got.Labels, want) {
+		t.Errorf("Unmarshal() Labels = %v; want %v", got.Labels, want)
+	}
+}
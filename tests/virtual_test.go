@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mailru/easyjson/jwriter"
+)
+
+func TestVirtualFieldsMarshal(t *testing.T) {
+	for i, test := range []struct {
+		v    Employee
+		want string
+	}{
+		{
+			v:    Employee{First: "Ann", Last: "Lee", Started: 2020},
+			want: `{"first":"Ann","last":"Lee","started":2020,"Reports":[],"full_name":"Ann Lee","years":4,"manager":false}`,
+		},
+		{
+			v:    Employee{First: "Bob", Last: "Ng", Started: 2010, Reports: []string{"Ann"}},
+			want: `{"first":"Bob","last":"Ng","started":2010,"Reports":["Ann"],"full_name":"Bob Ng","years":14,"manager":true}`,
+		},
+	} {
+		data, err := test.v.MarshalJSON()
+		if err != nil {
+			t.Errorf("[%d] MarshalJSON() error: %v", i, err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("[%d] MarshalJSON() = %s; want %s", i, got, test.want)
+		}
+	}
+}
+
+func TestVirtualFieldsFiltered(t *testing.T) {
+	v := Employee{First: "Ann", Last: "Lee", Started: 2020}
+	want := `{"first":"Ann","full_name":"Ann Lee"}`
+
+	w := jwriter.Writer{}
+	v.MarshalEasyJSONFiltered(&w, map[string]bool{"first": true, "full_name": true})
+	if got := string(w.Buffer.BuildBytes()); got != want {
+		t.Errorf("MarshalEasyJSONFiltered() = %s; want %s", got, want)
+	}
+}
+
+func TestVirtualFieldsUnmarshal(t *testing.T) {
+	data := `{"first":"Ann","last":"Lee","started":2020,"Reports":null,"full_name":"Someone Else","years":{"x":1},"manager":true}`
+	want := Employee{First: "Ann", Last: "Lee", Started: 2020}
+
+	var got Employee
+	if err := got.UnmarshalJSON([]byte(data)); err != nil {
+		t.Errorf("UnmarshalJSON() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", got, want)
+	}
+}
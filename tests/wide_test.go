@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"testing"
+)
+
+var wideRecordData = []byte(`{"id":1,"account_id":42,"region":3,"zone":7,"shard":12,"replica":2,` +
+	`"created":1500000000,"updated":1500000100,"deleted":false,"archived":true,"score":0.5,` +
+	`"weight":12.25,"retries":3,"priority":-1,"version":9,"checksum":18446744073709551615,"unknown":[1,{"a":2}]}`)
+
+func TestWideRecordAllocs(t *testing.T) {
+	var v WideRecord
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := v.UnmarshalJSON(wideRecordData); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("UnmarshalJSON() allocations = %v; want 0", allocs)
+	}
+
+	want := WideRecord{
+		ID: 1, AccountID: 42, Region: 3, Zone: 7, Shard: 12, Replica: 2,
+		Created: 1500000000, Updated: 1500000100, Archived: true, Score: 0.5,
+		Weight: 12.25, Retries: 3, Priority: -1, Version: 9, Checksum: 18446744073709551615,
+	}
+	if v != want {
+		t.Errorf("UnmarshalJSON() = %+v; want %+v", v, want)
+	}
+}
+
+func BenchmarkWideRecordUnmarshal(b *testing.B) {
+	b.SetBytes(int64(len(wideRecordData)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v WideRecord
+		if err := v.UnmarshalJSON(wideRecordData); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package easyjson
+
+import (
+	"fmt"
+	"sync"
+)
+
+// builtinTransforms are the names of transforms applied by the generated unmarshalers directly:
+// lower and upper change the case, trim removes leading and trailing whitespace.
+var builtinTransforms = []string{"lower", "upper", "trim"}
+
+// transforms holds the custom functions applied to decoded strings of fields marked with
+// `easyjson:"transform=name"`.
+var transforms = struct {
+	sync.RWMutex
+	funcs map[string]func(string) string
+}{funcs: make(map[string]func(string) string)}
+
+// RegisterTransform registers a function applied to the decoded string values of the fields
+// marked with `easyjson:"transform=<name>"`, e.g. for a Unicode normalization. It panics if the
+// name is one of the builtin lower, upper and trim, which can't be replaced.
+func RegisterTransform(name string, fn func(string) string) {
+	for _, b := range builtinTransforms {
+		if name == b {
+			panic(fmt.Sprintf("easyjson: transform %v is built in", name))
+		}
+	}
+	transforms.Lock()
+	transforms.funcs[name] = fn
+	transforms.Unlock()
+}
+
+// Transform applies the function registered with RegisterTransform to s, returning an error if
+// there is no function with the name.
+func Transform(name, s string) (string, error) {
+	transforms.RLock()
+	fn := transforms.funcs[name]
+	transforms.RUnlock()
+
+	if fn == nil {
+		return s, fmt.Errorf("easyjson: unknown transform %q", name)
+	}
+	return fn(s), nil
+}